@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hectorgimenez/koolo/internal/utils/winproc"
+)
+
+// attachCommandOutput makes the output of the CLI commands visible. Koolo is built as a GUI application, so it has no
+// console: we attach to the one of the terminal running the command, or write to a log file when there isn't one
+// (started from the explorer). The returned function closes the log file.
+func attachCommandOutput(command string) (func(), error) {
+	if r, _, _ := winproc.AttachConsole.Call(uintptr(winproc.ATTACH_PARENT_PROCESS)); r != 0 {
+		out, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0)
+		if err == nil {
+			setCommandOutput(out)
+			// Leave the prompt of the terminal in a new line
+			fmt.Println()
+
+			return func() { out.Close() }, nil
+		}
+	}
+
+	if err := os.MkdirAll("logs", os.ModePerm); err != nil {
+		return nil, fmt.Errorf("error creating log directory: %w", err)
+	}
+	out, err := os.Create(filepath.Join("logs", command+"-"+time.Now().Format("2006-01-02-15-04-05")+".txt"))
+	if err != nil {
+		return nil, err
+	}
+	setCommandOutput(out)
+
+	return func() { out.Close() }, nil
+}
+
+func setCommandOutput(out *os.File) {
+	os.Stdout = out
+	os.Stderr = out
+	// The standard logger keeps the stderr it was created with
+	log.SetOutput(out)
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"log/slog"
 	_ "net/http/pprof"
+	"os"
 	"runtime/debug"
 
 	sloggger "github.com/hectorgimenez/koolo/cmd/koolo/log"
@@ -13,8 +14,12 @@ import (
 	"github.com/hectorgimenez/koolo/internal/config"
 	"github.com/hectorgimenez/koolo/internal/event"
 	"github.com/hectorgimenez/koolo/internal/remote/discord"
+	"github.com/hectorgimenez/koolo/internal/remote/email"
+	"github.com/hectorgimenez/koolo/internal/remote/routing"
 	"github.com/hectorgimenez/koolo/internal/remote/telegram"
+	"github.com/hectorgimenez/koolo/internal/remote/webhook"
 	"github.com/hectorgimenez/koolo/internal/server"
+	"github.com/hectorgimenez/koolo/internal/town"
 	"github.com/hectorgimenez/koolo/internal/utils"
 	"github.com/hectorgimenez/koolo/internal/utils/winproc"
 	"github.com/inkeliz/gowebview"
@@ -37,6 +42,23 @@ func wrapWithRecover(logger *slog.Logger, f func() error) func() error {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "render-map" {
+		closeOutput, err := attachCommandOutput("render-map")
+		if err != nil {
+			utils.ShowDialog("Error rendering map", err.Error())
+			return
+		}
+		err = renderMapCommand(os.Args[2:])
+		if err != nil {
+			log.Printf("Error rendering map: %s", err.Error())
+		}
+		closeOutput()
+		if err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
 	err := config.Load()
 	if err != nil {
 		utils.ShowDialog("Error loading configuration", err.Error())
@@ -102,6 +124,15 @@ func main() {
 		return nil
 	}))
 
+	// Notifiers are registered in the router, it decides which one gets every event based on the notification rules
+	router, err := routing.NewRouter(config.Koolo.Notifications, logger)
+	if err != nil {
+		logger.Error("Notification rules could not been loaded", slog.Any("error", err))
+		return
+	}
+	router.SetItemValue(town.SellValue)
+	eventListener.Register(router.Handle)
+
 	// Discord Bot initialization
 	if config.Koolo.Discord.Enabled {
 		discordBot, err := discord.NewBot(config.Koolo.Discord.Token, config.Koolo.Discord.ChannelID, manager)
@@ -110,7 +141,7 @@ func main() {
 			return
 		}
 
-		eventListener.Register(discordBot.Handle)
+		router.Register("discord", discordBot.Handle, discordBot.Deliver)
 		g.Go(wrapWithRecover(logger, func() error {
 			return discordBot.Start(ctx)
 		}))
@@ -118,18 +149,46 @@ func main() {
 
 	// Telegram Bot initialization
 	if config.Koolo.Telegram.Enabled {
-		telegramBot, err := telegram.NewBot(config.Koolo.Telegram.Token, config.Koolo.Telegram.ChatID, logger)
+		telegramBot, err := telegram.NewBot(config.Koolo.Telegram.Token, config.Koolo.Telegram.ChatID, config.Koolo.Telegram.BotAdmins, manager, logger)
 		if err != nil {
 			logger.Error("Telegram could not been initialized", slog.Any("error", err))
 			return
 		}
 
-		eventListener.Register(telegramBot.Handle)
+		router.Register("telegram", telegramBot.Handle, telegramBot.Deliver)
 		g.Go(wrapWithRecover(logger, func() error {
 			return telegramBot.Start(ctx)
 		}))
 	}
 
+	// Webhook notifier initialization
+	if config.Koolo.Webhook.Enabled {
+		webhookNotifier, err := webhook.NewNotifier(logger)
+		if err != nil {
+			logger.Error("Webhook notifier could not been initialized", slog.Any("error", err))
+			return
+		}
+
+		router.Register("webhook", webhookNotifier.Handle, webhookNotifier.Deliver)
+		g.Go(wrapWithRecover(logger, func() error {
+			return webhookNotifier.Start(ctx)
+		}))
+	}
+
+	// Email notifier initialization
+	if config.Koolo.Email.Enabled {
+		emailNotifier, err := email.NewNotifier(config.Koolo.Email, manager, logger)
+		if err != nil {
+			logger.Error("Email notifier could not been initialized", slog.Any("error", err))
+			return
+		}
+
+		router.Register("email", emailNotifier.Handle, emailNotifier.Deliver)
+		g.Go(wrapWithRecover(logger, func() error {
+			return emailNotifier.Start(ctx)
+		}))
+	}
+
 	g.Go(wrapWithRecover(logger, func() error {
 		defer cancel()
 		return srv.Listen(8087)
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image/png"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/area"
+	"github.com/hectorgimenez/d2go/pkg/data/difficulty"
+	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/game"
+	"github.com/hectorgimenez/koolo/internal/game/map_client"
+	"github.com/hectorgimenez/koolo/internal/maprender"
+	"github.com/hectorgimenez/koolo/internal/pather/astar"
+)
+
+// renderMapCommand renders a level from the cached or fixture map data, usage:
+// koolo render-map --seed N --difficulty hell --area PitLevel1 [--from X,Y --to X,Y] [--out file.png|file.svg]
+func renderMapCommand(args []string) error {
+	flags := flag.NewFlagSet("render-map", flag.ContinueOnError)
+	seed := flags.Uint("seed", 0, "map seed, it's logged when a run fails")
+	diff := flags.String("difficulty", difficulty.Normal, "normal, nightmare or hell")
+	areaName := flags.String("area", "", "area name (PitLevel1, \"Pit Level 1\") or ID")
+	from := flags.String("from", "", "optional path start, absolute X,Y position")
+	to := flags.String("to", "", "optional path end, absolute X,Y position")
+	fixtures := flags.String("fixtures", "", "directory with map data fixtures, by default the map data configured in koolo.yaml is used")
+	scale := flags.Int("scale", 2, "size in pixels of every tile")
+	out := flags.String("out", "", "output file, .png or .svg (default {seed}_{difficulty}_{area}.png)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *seed == 0 || *areaName == "" {
+		flags.Usage()
+		return errors.New("seed and area are required")
+	}
+
+	areaID, err := parseArea(*areaName)
+	if err != nil {
+		return err
+	}
+
+	df := difficulty.Difficulty(strings.ToLower(*diff))
+	if df != difficulty.Normal && df != difficulty.Nightmare && df != difficulty.Hell {
+		return fmt.Errorf("unknown difficulty: %s", *diff)
+	}
+
+	// Configuration is optional, it's only used to find the map data cache
+	_ = config.Load()
+	var provider map_client.MapProvider
+	if *fixtures != "" {
+		provider = map_client.FixtureProvider{Dir: *fixtures}
+	} else {
+		provider = game.NewMapProvider(slog.Default())
+	}
+
+	mapData, err := provider.GetMapData(*seed, df)
+	if err != nil {
+		return err
+	}
+
+	areaData, found := game.BuildAreaData(mapData)[areaID]
+	if !found {
+		return fmt.Errorf("area %s not found in the map data", areaID.Area().Name)
+	}
+
+	m := maprender.Map{Area: areaData}
+	if *from != "" && *to != "" {
+		start, err := parsePosition(*from)
+		if err != nil {
+			return err
+		}
+		goal, err := parsePosition(*to)
+		if err != nil {
+			return err
+		}
+
+		path, distance, found := astar.CalculatePath(areaData.Grid, areaData.RelativePosition(start), areaData.RelativePosition(goal))
+		if !found {
+			fmt.Printf("Path from %s to %s not found\n", *from, *to)
+		} else {
+			fmt.Printf("Path from %s to %s: %d tiles\n", *from, *to, distance)
+			m.Path = path
+		}
+	}
+
+	if *out == "" {
+		*out = fmt.Sprintf("%d_%s_%s.png", *seed, df, strings.ReplaceAll(areaID.Area().Name, " ", ""))
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(*out), ".svg") {
+		err = m.SVG(file, *scale)
+	} else {
+		err = png.Encode(file, m.PNG(*scale))
+	}
+	if err != nil {
+		return fmt.Errorf("error writing %s: %w", *out, err)
+	}
+
+	fmt.Printf("Map written to %s\n", *out)
+
+	return nil
+}
+
+// parseArea accepts area IDs and names, ignoring spaces and case so both PitLevel1 and "Pit Level 1" work
+func parseArea(name string) (area.ID, error) {
+	if id, err := strconv.Atoi(name); err == nil {
+		if _, found := area.Areas[area.ID(id)]; found {
+			return area.ID(id), nil
+		}
+	}
+
+	normalize := func(s string) string {
+		return strings.ToLower(strings.ReplaceAll(s, " ", ""))
+	}
+	for id, a := range area.Areas {
+		if a.Name != "" && normalize(a.Name) == normalize(name) {
+			return id, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown area: %s", name)
+}
+
+func parsePosition(s string) (data.Position, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return data.Position{}, fmt.Errorf("invalid position %s, expected X,Y", s)
+	}
+
+	x, errX := strconv.Atoi(strings.TrimSpace(parts[0]))
+	y, errY := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if errX != nil || errY != nil {
+		return data.Position{}, fmt.Errorf("invalid position %s, expected X,Y", s)
+	}
+
+	return data.Position{X: x, Y: y}, nil
+}
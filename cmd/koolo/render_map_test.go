@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/area"
+)
+
+func TestParseArea(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected area.ID
+		wantErr  bool
+	}{
+		{name: "PitLevel1", expected: area.PitLevel1},
+		{name: "Pit Level 1", expected: area.PitLevel1},
+		{name: "pitlevel1", expected: area.PitLevel1},
+		{name: fmt.Sprint(int(area.PitLevel1)), expected: area.PitLevel1},
+		{name: "Pit Level 9", wantErr: true},
+		{name: "99999", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := parseArea(tt.name)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got area %d", id)
+				}
+				return
+			}
+			if err != nil || id != tt.expected {
+				t.Errorf("expected area %d, got %d (error %v)", tt.expected, id, err)
+			}
+		})
+	}
+}
+
+func TestParsePosition(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected data.Position
+		wantErr  bool
+	}{
+		{input: "100,200", expected: data.Position{X: 100, Y: 200}},
+		{input: " 100 , 200 ", expected: data.Position{X: 100, Y: 200}},
+		{input: "100", wantErr: true},
+		{input: "100,200,300", wantErr: true},
+		{input: "a,200", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			pos, err := parsePosition(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error value: %v", err)
+			}
+			if !tt.wantErr && pos != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, pos)
+			}
+		})
+	}
+}
+
+// writeRenderFixture writes a fully walkable 20x20 Pit Level 1 for seed 1 in hell, its offset is 100,200
+func writeRenderFixture(t *testing.T) string {
+	dir := t.TempDir()
+	rows := strings.TrimSuffix(strings.Repeat("[0],", 20), ",")
+	lvl := fmt.Sprintf(`{"type":"map","id":%d,"name":"Pit Level 1","offset":{"x":100,"y":200},"size":{"width":20,"height":20},"map":[%s]}`, area.PitLevel1, rows)
+	if err := os.WriteFile(filepath.Join(dir, "1_2.jsonl"), []byte("koolo-map v1\r\n"+lvl+"\r\n"), 0644); err != nil {
+		t.Fatalf("writing fixture: %v", err)
+	}
+
+	return dir
+}
+
+func TestRenderMapCommand(t *testing.T) {
+	fixtures := writeRenderFixture(t)
+	out := t.TempDir()
+
+	pngFile := filepath.Join(out, "pit.png")
+	err := renderMapCommand([]string{"--seed", "1", "--difficulty", "hell", "--area", "Pit Level 1", "--fixtures", fixtures,
+		"--from", "102,202", "--to", "115,215", "--scale", "3", "--out", pngFile})
+	if err != nil {
+		t.Fatalf("rendering png: %v", err)
+	}
+
+	f, err := os.Open(pngFile)
+	if err != nil {
+		t.Fatalf("opening png: %v", err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("decoding png: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X != 60 || size.Y != 60 {
+		t.Errorf("expected a 60x60 image, got %v", size)
+	}
+
+	svgFile := filepath.Join(out, "pit.svg")
+	err = renderMapCommand([]string{"--seed", "1", "--difficulty", "hell", "--area", "PitLevel1", "--fixtures", fixtures,
+		"--from", "102,202", "--to", "115,215", "--out", svgFile})
+	if err != nil {
+		t.Fatalf("rendering svg: %v", err)
+	}
+	svg, err := os.ReadFile(svgFile)
+	if err != nil {
+		t.Fatalf("reading svg: %v", err)
+	}
+	if !strings.HasPrefix(string(svg), "<svg") || !strings.Contains(string(svg), "<polyline") {
+		t.Errorf("svg doesn't contain the map with the path: %s", svg)
+	}
+}
+
+func TestRenderMapCommandErrors(t *testing.T) {
+	fixtures := writeRenderFixture(t)
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "missing seed", args: []string{"--area", "PitLevel1", "--fixtures", fixtures}},
+		{name: "unknown difficulty", args: []string{"--seed", "1", "--difficulty", "inferno", "--area", "PitLevel1", "--fixtures", fixtures}},
+		{name: "area not in map data", args: []string{"--seed", "1", "--difficulty", "hell", "--area", "PitLevel2", "--fixtures", fixtures}},
+		{name: "missing fixture", args: []string{"--seed", "2", "--difficulty", "hell", "--area", "PitLevel1", "--fixtures", fixtures}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append(tt.args, "--out", filepath.Join(t.TempDir(), "map.png"))
+			if err := renderMapCommand(args); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package action
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/item"
+	"github.com/hectorgimenez/d2go/pkg/nip"
+	"github.com/hectorgimenez/koolo/internal/action/step"
+	"github.com/hectorgimenez/koolo/internal/context"
+	"github.com/hectorgimenez/koolo/internal/event"
+	"github.com/hectorgimenez/koolo/internal/game"
+	"github.com/hectorgimenez/koolo/internal/ui"
+	"github.com/hectorgimenez/koolo/internal/utils"
+)
+
+type scoredCharm struct {
+	data.Item
+	score int
+}
+
+type charmUpgrade struct {
+	carried   scoredCharm
+	candidate scoredCharm
+}
+
+// ManageCharms replaces the charms carried in the locked inventory slots with better ones found in the stash,
+// charms are ranked using the scored charm rules from the character config.
+func ManageCharms() error {
+	ctx := context.Get()
+	ctx.SetLastAction("ManageCharms")
+
+	if !ctx.CharacterCfg.Charms.Enabled || len(ctx.CharacterCfg.Runtime.CharmRules) == 0 {
+		return nil
+	}
+
+	upgrades := findCharmUpgrades()
+	if len(upgrades) == 0 {
+		return nil
+	}
+
+	ctx.Logger.Info("Found charm upgrades, swapping charms...", slog.Int("upgrades", len(upgrades)))
+
+	if err := OpenStash(); err != nil {
+		return err
+	}
+	// Clear messages like TZ change or public game spam. Prevent bot from clicking on messages
+	ClearMessages()
+
+	for _, u := range upgrades {
+		if err := swapCharm(u.carried, u.candidate); err != nil {
+			ctx.Logger.Warn("Failed swapping charm", slog.Any("error", err))
+			break
+		}
+	}
+
+	return step.CloseAllMenus()
+}
+
+func findCharmUpgrades() []charmUpgrade {
+	ctx := context.Get()
+
+	// Only charms matching some rule are taken into account, we don't want to touch the ones the user placed manually
+	carried := make([]scoredCharm, 0)
+	for _, itm := range ctx.Data.Inventory.ByLocation(item.LocationInventory) {
+		if !isCharm(itm) || ctx.CharacterCfg.Inventory.InventoryLock[itm.Position.Y][itm.Position.X] != 0 {
+			continue
+		}
+		if score, found := charmScore(itm); found {
+			carried = append(carried, scoredCharm{Item: itm, score: score})
+		}
+	}
+
+	candidates := make([]scoredCharm, 0)
+	for _, itm := range ctx.Data.Inventory.ByLocation(item.LocationStash, item.LocationSharedStash) {
+		if !isCharm(itm) {
+			continue
+		}
+		if score, found := charmScore(itm); found {
+			candidates = append(candidates, scoredCharm{Item: itm, score: score})
+		}
+	}
+
+	// Best candidates first, so they replace the worst carried charms
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	replaced := make(map[data.UnitID]bool)
+	upgrades := make([]charmUpgrade, 0)
+	for _, candidate := range candidates {
+		worst := -1
+		for i, c := range carried {
+			// Charms must have the same size to fit in the same slot
+			if replaced[c.UnitID] || c.Desc().Type != candidate.Desc().Type || c.score >= candidate.score {
+				continue
+			}
+			if worst == -1 || c.score < carried[worst].score {
+				worst = i
+			}
+		}
+
+		if worst != -1 {
+			replaced[carried[worst].UnitID] = true
+			upgrades = append(upgrades, charmUpgrade{carried: carried[worst], candidate: candidate})
+		}
+	}
+
+	return upgrades
+}
+
+func swapCharm(carried, candidate scoredCharm) error {
+	ctx := context.Get()
+	ctx.SetLastStep("swapCharm")
+
+	// Move the carried charm to the same tab where the candidate is, so there is room for it after taking the candidate
+	SwitchStashTab(candidate.Location.Page + 1)
+
+	screenPos := ui.GetScreenCoordsForItem(carried.Item)
+	ctx.HID.ClickWithModifier(game.LeftButton, screenPos.X, screenPos.Y, game.CtrlKey)
+	utils.Sleep(500)
+	ctx.RefreshGameData()
+
+	if isItemInInventory(carried.UnitID) {
+		return fmt.Errorf("not enough room in stash for %s", carried.Desc().Name)
+	}
+
+	// Pick up the candidate and drop it in the slot we just freed
+	screenPos = ui.GetScreenCoordsForItem(candidate.Item)
+	ctx.HID.Click(game.LeftButton, screenPos.X, screenPos.Y)
+	utils.Sleep(300)
+	screenPos = ui.GetScreenCoordsToPlaceInInventory(candidate.Item, carried.Position)
+	ctx.HID.Click(game.LeftButton, screenPos.X, screenPos.Y)
+	utils.Sleep(500)
+	ctx.RefreshGameData()
+
+	added, found := ctx.Data.Inventory.FindByID(candidate.UnitID)
+	if !found || added.Location.LocationType != item.LocationInventory || added.Position != carried.Position {
+		return fmt.Errorf("%s could not be placed in the inventory", candidate.Desc().Name)
+	}
+
+	ctx.Logger.Info(fmt.Sprintf("Charm %s [%s] swapped by %s [%s]", carried.Desc().Name, carried.Quality.ToString(), added.Desc().Name, added.Quality.ToString()),
+		slog.Int("oldScore", carried.score),
+		slog.Int("newScore", candidate.score),
+	)
+	event.Send(event.CharmSwapped(event.Text(ctx.Name, fmt.Sprintf("Charm %s [%s] replaced by %s [%s]", carried.Desc().Name, carried.Quality.ToString(), added.Desc().Name, added.Quality.ToString())), carried.Item, added))
+
+	return nil
+}
+
+// charmScore returns the highest score of the charm rules matching the given charm
+func charmScore(itm data.Item) (int, bool) {
+	ctx := context.Get()
+
+	best, found := 0, false
+	for _, rule := range ctx.CharacterCfg.Runtime.CharmRules {
+		if res, err := rule.Evaluate(itm); err == nil && res == nip.RuleResultFullMatch && (!found || rule.Score > best) {
+			best, found = rule.Score, true
+		}
+	}
+
+	return best, found
+}
+
+func isCharm(itm data.Item) bool {
+	switch itm.Desc().Type {
+	case item.TypeSmallCharm, item.TypeMediumCharm, item.TypeLargeCharm:
+		return true
+	}
+
+	return false
+}
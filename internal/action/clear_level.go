@@ -3,25 +3,94 @@ package action
 import (
 	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
 
 	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/area"
 	"github.com/hectorgimenez/d2go/pkg/data/stat"
 	"github.com/hectorgimenez/koolo/internal/context"
 	"github.com/hectorgimenez/koolo/internal/game"
 	"github.com/hectorgimenez/koolo/internal/utils"
 )
 
-func ClearCurrentLevel(openChests bool, filter data.MonsterFilter) error {
+// loadedRoomsDistance is the distance from the player where the game keeps the monsters and objects loaded
+const loadedRoomsDistance = 40
+
+type ClearLevelOpts struct {
+	skipEmptyRooms bool
+	routeEnd       area.ID
+}
+
+type ClearLevelOption func(*ClearLevelOpts)
+
+// WithSkipEmptyRooms skips the rooms without monsters matching the filter, rooms with chests to open are never skipped.
+// Rooms can only be checked once the player is close enough for their monsters to be loaded.
+func WithSkipEmptyRooms(skip bool) ClearLevelOption {
+	return func(opts *ClearLevelOpts) {
+		opts.skipEmptyRooms = skip
+	}
+}
+
+// WithRouteEnd finishes the route close to the entrance of the given adjacent area, by default it finishes close to
+// the waypoint (if any)
+func WithRouteEnd(dst area.ID) ClearLevelOption {
+	return func(opts *ClearLevelOpts) {
+		opts.routeEnd = dst
+	}
+}
+
+func ClearCurrentLevel(openChests bool, filter data.MonsterFilter, options ...ClearLevelOption) error {
 	ctx := context.Get()
 	ctx.SetLastAction("ClearCurrentLevel")
 
-	rooms := ctx.PathFinder.OptimizeRoomsTraverseOrder()
-	for _, r := range rooms {
+	opts := &ClearLevelOpts{}
+	for _, o := range options {
+		o(opts)
+	}
+
+	// Monsters are only loaded for the rooms around the player, so empty rooms are discarded while the route is walked
+	// and the rest of the route is planned again every time it happens
+	rooms := ctx.Data.Rooms
+	pending := make([]int, len(rooms))
+	for i := range pending {
+		pending[i] = i
+	}
+
+	planner := ctx.PathFinder.NewRoomsPlanner(rooms, routeEnd(opts.routeEnd))
+	route := planner.Route(-1, pending)
+	ctx.Logger.Info("Rooms route calculated",
+		slog.Int("rooms", len(route.Rooms)),
+		slog.Int("initialLength", route.InitialLength),
+		slog.Int("length", route.Length),
+	)
+
+	skipped := 0
+	for len(route.Indexes) > 0 {
+		idx := route.Indexes[0]
+		route.Indexes = route.Indexes[1:]
+		pending = slices.DeleteFunc(pending, func(i int) bool { return i == idx })
+		r := rooms[idx]
+
+		if opts.skipEmptyRooms && isEmptyLoadedRoom(r, filter, openChests) {
+			skipped++
+			continue
+		}
+
 		err := clearRoom(r, filter)
 		if err != nil {
 			ctx.Logger.Warn("Failed to clear room: %v", err)
 		}
 
+		if opts.skipEmptyRooms {
+			remaining := len(pending)
+			pending = slices.DeleteFunc(pending, func(i int) bool { return isEmptyLoadedRoom(rooms[i], filter, openChests) })
+			if discarded := remaining - len(pending); discarded > 0 {
+				skipped += discarded
+				route = planner.Route(idx, pending)
+			}
+		}
+
 		if !openChests {
 			continue
 		}
@@ -45,9 +114,71 @@ func ClearCurrentLevel(openChests bool, filter data.MonsterFilter) error {
 		}
 	}
 
+	ctx.Logger.Debug("Finished clearing rooms", slog.Int("rooms", len(rooms)), slog.Int("skippedRooms", skipped))
+
 	return nil
 }
 
+// isEmptyLoadedRoom returns true when the monsters of the room are loaded and none of them matches the filter, rooms
+// with chests to open are never empty
+func isEmptyLoadedRoom(room data.Room, filter data.MonsterFilter, openChests bool) bool {
+	return isRoomLoaded(room) && !roomHasMonsters(room, filter) && !(openChests && roomHasChests(room))
+}
+
+// isRoomLoaded returns true when the whole room is close enough to the player to have its monsters and objects loaded
+func isRoomLoaded(room data.Room) bool {
+	p := context.Get().Data.PlayerUnit.Position
+
+	return room.X >= p.X-loadedRoomsDistance && room.Y >= p.Y-loadedRoomsDistance &&
+		room.X+room.Width <= p.X+loadedRoomsDistance && room.Y+room.Height <= p.Y+loadedRoomsDistance
+}
+
+// routeEnd returns the position of the given adjacent area entrance, or the waypoint of the current area. Zero position
+// means the route can finish anywhere.
+func routeEnd(dst area.ID) data.Position {
+	ctx := context.Get()
+
+	if dst != 0 {
+		for _, l := range ctx.Data.AdjacentLevels {
+			if l.Area == dst {
+				return l.Position
+			}
+		}
+	}
+
+	for _, o := range ctx.Data.AreaData.Objects {
+		if o.IsWaypoint() {
+			return o.Position
+		}
+	}
+
+	return data.Position{}
+}
+
+func roomHasMonsters(room data.Room, filter data.MonsterFilter) bool {
+	ctx := context.Get()
+
+	for _, m := range ctx.Data.Monsters.Enemies(filter) {
+		if m.Stats[stat.Life] > 0 && room.IsInside(m.Position) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func roomHasChests(room data.Room) bool {
+	ctx := context.Get()
+
+	for _, o := range ctx.Data.Objects {
+		if o.IsChest() && o.Selectable && room.IsInside(o.Position) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func clearRoom(room data.Room, filter data.MonsterFilter) error {
 	ctx := context.Get()
 	ctx.SetLastAction("clearRoom")
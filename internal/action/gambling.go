@@ -2,7 +2,10 @@ package action
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 
 	"github.com/hectorgimenez/d2go/pkg/data"
 	"github.com/hectorgimenez/d2go/pkg/data/item"
@@ -11,6 +14,7 @@ import (
 	"github.com/hectorgimenez/d2go/pkg/nip"
 	"github.com/hectorgimenez/koolo/internal/action/step"
 	"github.com/hectorgimenez/koolo/internal/context"
+	"github.com/hectorgimenez/koolo/internal/event"
 	"github.com/hectorgimenez/koolo/internal/game"
 	"github.com/hectorgimenez/koolo/internal/town"
 	"github.com/hectorgimenez/koolo/internal/ui"
@@ -18,40 +22,36 @@ import (
 	"github.com/lxn/win"
 )
 
+// unknownGamblingPrice is the price assumed for the first item gambled in a session with a budget, around the highest
+// gambling prices at the end game
+const unknownGamblingPrice = 400000
+
 func Gamble() error {
 	ctx := context.Get()
 	ctx.SetLastAction("Gamble")
 
-	stashedGold, _ := ctx.Data.PlayerUnit.FindStat(stat.StashGold, 0)
-	if ctx.CharacterCfg.Gambling.Enabled && stashedGold.Value >= 2480000 {
-		ctx.Logger.Info("Time to gamble! Visiting vendor...")
-
-		vendorNPC := town.GetTownByArea(ctx.Data.PlayerUnit.Area).GamblingNPC()
-
-		// Fix for Anya position
-		if vendorNPC == npc.Drehya {
-			_ = MoveToCoords(data.Position{
-				X: 5107,
-				Y: 5119,
-			})
-		}
+	if !ctx.CharacterCfg.Gambling.Enabled || len(ctx.CharacterCfg.Gambling.Items) == 0 {
+		return nil
+	}
 
-		InteractNPC(vendorNPC)
-		// Jamella gamble button is the second one
-		if vendorNPC == npc.Jamella {
-			ctx.HID.KeySequence(win.VK_HOME, win.VK_DOWN, win.VK_RETURN)
-		} else {
-			ctx.HID.KeySequence(win.VK_HOME, win.VK_DOWN, win.VK_DOWN, win.VK_RETURN)
-		}
+	stashedGold, _ := ctx.Data.PlayerUnit.FindStat(stat.StashGold, 0)
+	if stashedGold.Value < ctx.CharacterCfg.Gambling.StartGold {
+		return nil
+	}
 
-		if !ctx.Data.OpenMenus.NPCShop {
-			return errors.New("failed opening gambling window")
-		}
+	if isGamblingBudgetExhausted() {
+		ctx.Logger.Debug("Gambling session budget exhausted, skipping gambling",
+			slog.Int("goldSpent", ctx.Gambling.GoldSpent),
+			slog.Int("sessionBudget", ctx.CharacterCfg.Gambling.SessionBudget))
+		return nil
+	}
 
-		return gambleItems()
+	ctx.Logger.Info("Time to gamble! Visiting vendor...")
+	if err := openGamblingWindow(); err != nil {
+		return err
 	}
 
-	return nil
+	return gambleItems()
 }
 
 func GambleSingleItem(items []string, desiredQuality item.Quality) error {
@@ -150,6 +150,8 @@ func gambleItems() error {
 	ctx.SetLastAction("gambleItems")
 
 	var itemBought data.Item
+	var gambledItemName item.Name
+	var goldBeforeBuying int
 	var refreshAttempts int
 	var currentItemIndex int
 	const maxRefreshAttempts = 11
@@ -158,89 +160,219 @@ func gambleItems() error {
 		ctx.PauseIfNotPriority()
 		ctx.RefreshGameData()
 
-		// Check if we should stop gambling due to low gold
-		if ctx.Data.PlayerUnit.TotalPlayerGold() < 500000 {
-			ctx.Logger.Info("Finished gambling - gold below 500k",
-				slog.Int("currentGold", ctx.Data.PlayerUnit.TotalPlayerGold()))
-			return step.CloseAllMenus()
-		}
-
 		// Process bought item if we have one
 		if itemBought.Name != "" {
+			found := false
 			// Find the bought item in inventory
 			for _, itm := range ctx.Data.Inventory.ByLocation(item.LocationInventory) {
 				if itm.UnitID == itemBought.UnitID {
 					itemBought = itm
+					found = true
 					ctx.Logger.Debug("Gambled for item", slog.Any("item", itemBought))
 					break
 				}
 			}
 
-			// Check if item matches NIP rules
-			if _, result := ctx.Data.CharacterCfg.Runtime.Rules.EvaluateAll(itemBought); result == nip.RuleResultFullMatch {
-				ctx.Logger.Info("Found item matching NIP rules, keeping", slog.Any("item", itemBought))
+			// Purchase didn't go through, probably the inventory is full
+			if !found {
+				ctx.Logger.Info("Gambled item not found in inventory, inventory may be full. Stopping gambling", slog.String("item", string(gambledItemName)))
+				return step.CloseAllMenus()
+			}
+
+			goldSpent := max(goldBeforeBuying-ctx.Data.PlayerUnit.TotalPlayerGold(), 0)
+			ctx.Gambling.GoldSpent += goldSpent
+			ctx.Gambling.SpentPerItem[gambledItemName] += goldSpent
+			ctx.Gambling.PricePerItem[gambledItemName] = max(ctx.Gambling.PricePerItem[gambledItemName], goldSpent)
+
+			regamble := false
+			rule, result := gamblingRules().EvaluateAll(itemBought)
+			if result == nip.RuleResultFullMatch {
+				ctx.Logger.Info("Found item matching gambling rules, keeping", slog.Any("item", itemBought), slog.String("rule", rule.RawLine))
+				ctx.CurrentGame.GambledItems[itemBought.UnitID] = rule
+				event.Send(event.ItemGambled(event.Text(ctx.Name, fmt.Sprintf("Gambled %s [%s], keeping it", itemBought.Desc().Name, itemBought.Quality.ToString())), itemBought, goldSpent, 0, true))
 			} else {
 				// Filter not pass, selling the item
-				ctx.Logger.Debug("Item doesn't match NIP rules, selling", slog.Any("item", itemBought))
+				regamble = matchesGamblingBase(itemBought)
+				ctx.Logger.Debug("Item doesn't match gambling rules, selling", slog.Any("item", itemBought), slog.Bool("baseMatches", regamble))
+				goldBeforeSelling := ctx.Data.PlayerUnit.TotalPlayerGold()
 				town.SellItem(itemBought)
+				ctx.RefreshGameData()
+				goldRecovered := max(ctx.Data.PlayerUnit.TotalPlayerGold()-goldBeforeSelling, 0)
+				event.Send(event.ItemGambled(event.Text(ctx.Name, fmt.Sprintf("Gambled %s [%s], sold it", itemBought.Desc().Name, itemBought.Quality.ToString())), itemBought, goldSpent, goldRecovered, false))
 			}
 
 			itemBought = data.Item{} // Reset itemBought after processing
 			refreshAttempts = 0      // Reset refresh counter after successful purchase
 
-			// Move to next item in the gambling list
-			currentItemIndex = (currentItemIndex + 1) % len(ctx.Data.CharacterCfg.Gambling.Items)
+			// The base we want but not the stats, so let's re-gamble the same item, otherwise move to next item in the
+			// gambling list
+			if !regamble {
+				currentItemIndex = (currentItemIndex + 1) % len(ctx.CharacterCfg.Gambling.Items)
+			}
 			continue
 		}
 
+		// Check if we should stop gambling due to low gold
+		if ctx.Data.PlayerUnit.TotalPlayerGold() < ctx.CharacterCfg.Gambling.StopGold {
+			ctx.Logger.Info("Finished gambling - gold below stop threshold",
+				slog.Int("currentGold", ctx.Data.PlayerUnit.TotalPlayerGold()),
+				slog.Int("stopGold", ctx.CharacterCfg.Gambling.StopGold))
+			return step.CloseAllMenus()
+		}
+
+		if isGamblingBudgetExhausted() {
+			ctx.Logger.Info("Finished gambling - session budget left isn't enough for any item",
+				slog.Int("goldSpent", ctx.Gambling.GoldSpent),
+				slog.Int("sessionBudget", ctx.CharacterCfg.Gambling.SessionBudget))
+			return step.CloseAllMenus()
+		}
+
+		// Skip the items that already reached their spending limit or don't fit in the session budget
+		idx, found := nextGambleItemIndex(currentItemIndex)
+		if !found {
+			ctx.Logger.Info("Finished gambling - all items reached their spending limit")
+			return step.CloseAllMenus()
+		}
+		if idx != currentItemIndex {
+			currentItemIndex = idx
+			refreshAttempts = 0
+		}
+
 		// Try to find and buy items
-		itemFound := false
-		if len(ctx.Data.CharacterCfg.Gambling.Items) > 0 {
-			// Get current item to gamble for
-			currentItem := ctx.Data.CharacterCfg.Gambling.Items[currentItemIndex]
-			itm, found := ctx.Data.Inventory.Find(currentItem, item.LocationVendor)
-			if found {
-				town.BuyItem(itm, 1)
-				itemBought = itm
-				itemFound = true
-			}
+		currentItem := ctx.Data.CharacterCfg.Gambling.Items[currentItemIndex]
+		if itm, found := ctx.Data.Inventory.Find(currentItem, item.LocationVendor); found {
+			goldBeforeBuying = ctx.Data.PlayerUnit.TotalPlayerGold()
+			town.BuyItem(itm, 1)
+			itemBought = itm
+			gambledItemName = currentItem
+			continue
 		}
 
 		// If no items found, try refreshing the gambling window
-		if !itemFound {
-			refreshAttempts++
-			if refreshAttempts >= maxRefreshAttempts {
-				ctx.Logger.Info("Too many refresh attempts without finding items, reopening gambling window")
-				// Close and reopen gambling window
-				if err := step.CloseAllMenus(); err != nil {
-					return err
-				}
-				utils.Sleep(200)
+		refreshAttempts++
+		if refreshAttempts >= maxRefreshAttempts {
+			ctx.Logger.Info("Too many refresh attempts without finding items, reopening gambling window")
+			// Close and reopen gambling window
+			if err := step.CloseAllMenus(); err != nil {
+				return err
+			}
+			utils.Sleep(200)
 
-				vendorNPC := town.GetTownByArea(ctx.Data.PlayerUnit.Area).GamblingNPC()
-				if err := InteractNPC(vendorNPC); err != nil {
-					return err
-				}
+			if err := openGamblingWindow(); err != nil {
+				return err
+			}
 
-				// Select gamble option
-				if vendorNPC == npc.Jamella {
-					ctx.HID.KeySequence(win.VK_HOME, win.VK_DOWN, win.VK_RETURN)
-				} else {
-					ctx.HID.KeySequence(win.VK_HOME, win.VK_DOWN, win.VK_DOWN, win.VK_RETURN)
-				}
+			refreshAttempts = 0
+			continue
+		}
 
-				refreshAttempts = 0
-				continue
-			}
+		ctx.Logger.Debug("Refreshing.. ",
+			slog.Int("Attempt", refreshAttempts),
+			slog.String("Looking For ", string(currentItem)))
+		RefreshGamblingWindow(ctx)
+		utils.Sleep(500)
+	}
+}
 
-			ctx.Logger.Debug("Refreshing.. ",
-				slog.Int("Attempt", refreshAttempts),
-				slog.String("Looking For ", string(ctx.Data.CharacterCfg.Gambling.Items[currentItemIndex])))
-			RefreshGamblingWindow(ctx)
-			utils.Sleep(500)
+func openGamblingWindow() error {
+	ctx := context.Get()
+
+	vendorNPC := town.GetTownByArea(ctx.Data.PlayerUnit.Area).GamblingNPC()
+
+	// Fix for Anya position
+	if vendorNPC == npc.Drehya {
+		_ = MoveToCoords(data.Position{
+			X: 5107,
+			Y: 5119,
+		})
+	}
+
+	if err := InteractNPC(vendorNPC); err != nil {
+		return err
+	}
+
+	// Jamella gamble button is the second one
+	if vendorNPC == npc.Jamella {
+		ctx.HID.KeySequence(win.VK_HOME, win.VK_DOWN, win.VK_RETURN)
+	} else {
+		ctx.HID.KeySequence(win.VK_HOME, win.VK_DOWN, win.VK_DOWN, win.VK_RETURN)
+	}
+
+	if !ctx.Data.OpenMenus.NPCShop {
+		return errors.New("failed opening gambling window")
+	}
+
+	return nil
+}
+
+// gamblingRules returns the dedicated gambling rules, falling back to the pickit rules if there aren't any
+func gamblingRules() nip.Rules {
+	ctx := context.Get()
+	if len(ctx.CharacterCfg.Runtime.GamblingRules) > 0 {
+		return ctx.CharacterCfg.Runtime.GamblingRules
+	}
+
+	return ctx.CharacterCfg.Runtime.Rules
+}
+
+// matchesGamblingBase returns true when the first part of a gambling rule (base, quality...) matches the item. Gambled
+// items are identified so the rules never return a partial match for them, they are evaluated as unidentified instead.
+func matchesGamblingBase(itm data.Item) bool {
+	unidentified := itm
+	unidentified.Identified = false
+	_, result := gamblingRules().EvaluateAll(unidentified)
+
+	return result == nip.RuleResultPartial
+}
+
+// isGamblingBudgetExhausted returns true when the gold left in the session budget isn't enough to gamble any item
+func isGamblingBudgetExhausted() bool {
+	ctx := context.Get()
+
+	return !slices.ContainsFunc(ctx.CharacterCfg.Gambling.Items, fitsGamblingBudget)
+}
+
+// fitsGamblingBudget returns true when gambling the item won't go over the session budget. Vendor prices can't be read,
+// so the highest price paid for the item in this session is used, or the highest price paid for any item, or
+// unknownGamblingPrice when nothing was gambled yet.
+func fitsGamblingBudget(name item.Name) bool {
+	ctx := context.Get()
+	budget := ctx.CharacterCfg.Gambling.SessionBudget
+	if budget <= 0 {
+		return true
+	}
+
+	price, found := ctx.Gambling.PricePerItem[name]
+	if !found {
+		price = unknownGamblingPrice
+		if len(ctx.Gambling.PricePerItem) > 0 {
+			price = slices.Max(slices.Collect(maps.Values(ctx.Gambling.PricePerItem)))
 		}
 	}
+
+	return ctx.Gambling.GoldSpent+price <= budget
 }
+
+// nextGambleItemIndex returns the first item in the gambling list, starting from the given index, that didn't reach its
+// spending limit and fits in the session budget
+func nextGambleItemIndex(from int) (int, bool) {
+	ctx := context.Get()
+	items := ctx.CharacterCfg.Gambling.Items
+
+	for i := range items {
+		idx := (from + i) % len(items)
+		if !fitsGamblingBudget(items[idx]) {
+			continue
+		}
+		limit, found := ctx.CharacterCfg.Gambling.ItemLimits[items[idx]]
+		if !found || limit <= 0 || ctx.Gambling.SpentPerItem[items[idx]] < limit {
+			return idx, true
+		}
+	}
+
+	return 0, false
+}
+
 func RefreshGamblingWindow(ctx *context.Status) {
 	if ctx.Data.LegacyGraphics {
 		ctx.HID.Click(game.LeftButton, ui.GambleRefreshButtonXClassic, ui.GambleRefreshButtonYClassic)
@@ -1,6 +1,7 @@
 package action
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"sort"
@@ -203,7 +204,6 @@ func MoveTo(toFunc func() (data.Position, bool)) error {
 		utils.Sleep(500)
 	}
 
-	openedDoors := make(map[object.Name]data.Position)
 	previousIterationPosition := data.Position{}
 	lastMovement := false
 
@@ -221,39 +221,7 @@ func MoveTo(toFunc func() (data.Position, bool)) error {
 
 		// If we can teleport, don't bother with the rest
 		if ctx.Data.CanTeleport() {
-			return step.MoveTo(to)
-		}
-
-		// Check for doors blocking path
-		for _, o := range ctx.Data.Objects {
-			if o.IsDoor() && ctx.PathFinder.DistanceFromMe(o.Position) < 10 && openedDoors[o.Name] != o.Position {
-				if o.Selectable {
-					ctx.Logger.Info("Door detected and teleport is not available, trying to open it...")
-					openedDoors[o.Name] = o.Position
-					err := step.InteractObject(o, func() bool {
-						obj, found := ctx.Data.Objects.FindByID(o.ID)
-						return found && !obj.Selectable
-					})
-					if err != nil {
-						return err
-					}
-				}
-			}
-		}
-		// Check if there is any object blocking our path
-		for _, o := range ctx.Data.Objects {
-			if o.Name == object.Barrel && ctx.PathFinder.DistanceFromMe(o.Position) < 3 {
-				err := step.InteractObject(o, func() bool {
-					obj, found := ctx.Data.Objects.FindByID(o.ID)
-					//additional click on barrel to avoid getting stuck
-					x, y := ctx.PathFinder.GameCoordsToScreenCords(o.Position.X, o.Position.Y)
-					ctx.HID.Click(game.LeftButton, x, y)
-					return found && !obj.Selectable
-				})
-				if err != nil {
-					return err
-				}
-			}
+			return leaveIfStuck(step.MoveTo(to))
 		}
 
 		// Check for monsters close to player
@@ -329,7 +297,23 @@ func MoveTo(toFunc func() (data.Position, bool)) error {
 
 		err := step.MoveTo(to)
 		if err != nil {
-			return err
+			return leaveIfStuck(err)
 		}
 	}
 }
+
+// leaveIfStuck goes back to town when the character got stuck and the movement recovery strategies didn't work, the
+// error is returned anyway, the current run can not continue from here
+func leaveIfStuck(err error) error {
+	if !errors.Is(err, step.ErrStuck) {
+		return err
+	}
+
+	ctx := context.Get()
+	ctx.Logger.Warn("Character stuck, returning to town", slog.String("error", err.Error()))
+	if tpErr := ReturnTown(); tpErr != nil {
+		return fmt.Errorf("%w, returning to town failed: %v", err, tpErr)
+	}
+
+	return err
+}
@@ -0,0 +1,160 @@
+package action
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/item"
+	"github.com/hectorgimenez/d2go/pkg/data/npc"
+	"github.com/hectorgimenez/d2go/pkg/nip"
+	"github.com/hectorgimenez/koolo/internal/action/step"
+	"github.com/hectorgimenez/koolo/internal/context"
+	"github.com/hectorgimenez/koolo/internal/town"
+	"github.com/hectorgimenez/koolo/internal/utils"
+	"github.com/lxn/win"
+)
+
+// unknownPurchasePrice is the price assumed for the first item bought with a gold budget
+const unknownPurchasePrice = 200000
+
+// ShopVendor opens the trade window of the given vendor and buys the items matching the shopping rules without
+// spending more than goldBudget (0 means no limit). Returns the gold spent.
+func ShopVendor(vendorNPC npc.ID, goldBudget int) (int, error) {
+	ctx := context.Get()
+	ctx.SetLastAction("ShopVendor")
+
+	// Fix for Anya position
+	if vendorNPC == npc.Drehya {
+		_ = MoveToCoords(data.Position{
+			X: 5107,
+			Y: 5119,
+		})
+	}
+
+	if err := InteractNPC(vendorNPC); err != nil {
+		return 0, err
+	}
+
+	// Jamella trade button is the first one
+	if vendorNPC == npc.Jamella {
+		ctx.HID.KeySequence(win.VK_HOME, win.VK_RETURN)
+	} else {
+		ctx.HID.KeySequence(win.VK_HOME, win.VK_DOWN, win.VK_RETURN)
+	}
+	utils.Sleep(300)
+	ctx.RefreshGameData()
+
+	if !ctx.Data.OpenMenus.NPCShop {
+		return 0, fmt.Errorf("failed opening trade window for vendor %d", vendorNPC)
+	}
+
+	spent := 0
+	for _, itm := range ctx.Data.Inventory.ByLocation(item.LocationVendor) {
+		rule, result := ctx.CharacterCfg.Runtime.ShoppingRules.EvaluateAll(itm)
+		logVendorItem(vendorNPC, itm, result)
+
+		if result != nip.RuleResultFullMatch {
+			continue
+		}
+
+		gold := ctx.Data.PlayerUnit.TotalPlayerGold()
+		if gold <= ctx.CharacterCfg.Shopping.MinGoldToKeep {
+			ctx.Logger.Info("Not enough gold to keep shopping", slog.Int("gold", gold), slog.Int("minGoldToKeep", ctx.CharacterCfg.Shopping.MinGoldToKeep))
+			break
+		}
+
+		if goldBudget > 0 && spent >= goldBudget {
+			ctx.Logger.Info("Shopping gold budget reached", slog.Int("spent", spent), slog.Int("goldBudget", goldBudget))
+			break
+		}
+
+		price := expectedPurchasePrice(itm)
+		if gold-price < ctx.CharacterCfg.Shopping.MinGoldToKeep {
+			ctx.Logger.Info("Buying the item would leave less than the gold to keep, skipping",
+				slog.String("item", string(itm.Name)),
+				slog.Int("expectedPrice", price),
+				slog.Int("gold", gold),
+				slog.Int("minGoldToKeep", ctx.CharacterCfg.Shopping.MinGoldToKeep))
+			continue
+		}
+
+		if goldBudget > 0 && spent+price > goldBudget {
+			ctx.Logger.Info("Item doesn't fit in the shopping gold budget, skipping",
+				slog.String("item", string(itm.Name)),
+				slog.Int("expectedPrice", price),
+				slog.Int("budgetLeft", goldBudget-spent))
+			continue
+		}
+
+		SwitchStashTab(itm.Location.Page + 1)
+		town.BuyItem(itm, 1)
+		ctx.RefreshGameData()
+
+		if !isItemInInventory(itm.UnitID) {
+			ctx.Logger.Warn("Failed buying item from vendor, inventory may be full", slog.String("item", string(itm.Name)))
+			continue
+		}
+
+		itemSpent := max(gold-ctx.Data.PlayerUnit.TotalPlayerGold(), 0)
+		spent += itemSpent
+		ctx.PurchasePrices[purchasePriceKey(itm)] = max(ctx.PurchasePrices[purchasePriceKey(itm)], itemSpent)
+		ctx.CurrentGame.PurchasedItems[itm.UnitID] = rule
+		ctx.Logger.Info(fmt.Sprintf("Purchased %s [%s] from vendor", itm.Desc().Name, itm.Quality.ToString()),
+			slog.Int("gold", itemSpent),
+			slog.String("nipFile", fmt.Sprintf("%s:%d", rule.Filename, rule.LineNumber)),
+			slog.String("rawRule", rule.RawLine),
+		)
+	}
+
+	return spent, step.CloseAllMenus()
+}
+
+// expectedPurchasePrice returns the highest price paid for the same item and quality, vendor prices can't be read
+// before buying. For items never bought it's the highest price paid for any item, or unknownPurchasePrice.
+func expectedPurchasePrice(itm data.Item) int {
+	ctx := context.Get()
+	if price, found := ctx.PurchasePrices[purchasePriceKey(itm)]; found {
+		return price
+	}
+	if len(ctx.PurchasePrices) > 0 {
+		return slices.Max(slices.Collect(maps.Values(ctx.PurchasePrices)))
+	}
+
+	return unknownPurchasePrice
+}
+
+func purchasePriceKey(itm data.Item) string {
+	return fmt.Sprintf("%s:%s", itm.Name, itm.Quality.ToString())
+}
+
+func isItemInInventory(unitID data.UnitID) bool {
+	for _, itm := range context.Get().Data.Inventory.ByLocation(item.LocationInventory) {
+		if itm.UnitID == unitID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func logVendorItem(vendorNPC npc.ID, itm data.Item, result nip.RuleResult) {
+	ctx := context.Get()
+
+	logFn := ctx.Logger.Debug
+	if ctx.CharacterCfg.Shopping.LogVendorItems {
+		logFn = ctx.Logger.Info
+	}
+
+	logFn("Vendor item",
+		slog.Int("vendor", int(vendorNPC)),
+		slog.String("item", string(itm.Name)),
+		slog.String("quality", itm.Quality.ToString()),
+		slog.String("identifiedName", itm.IdentifiedName),
+		slog.Bool("ethereal", itm.Ethereal),
+		slog.Any("stats", itm.Stats),
+		slog.Bool("matched", result == nip.RuleResultFullMatch),
+	)
+}
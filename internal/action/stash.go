@@ -170,12 +170,13 @@ func stashInventory(firstRun bool) {
 				)
 				break
 			}
+			currentTab++
 			if currentTab == 5 {
 				ctx.Logger.Info("Stash is full ...")
-				//TODO: Stash is full stop the bot
+				event.Send(event.StashFull(event.WithScreenshot(ctx.Name, fmt.Sprintf("Stash is full, %s could not be stashed", i.Desc().Name), ctx.GameReader.Screenshot()), i))
+				break
 			}
-			ctx.Logger.Debug(fmt.Sprintf("Tab %d is full, switching to next one", currentTab))
-			currentTab++
+			ctx.Logger.Debug(fmt.Sprintf("Tab %d is full, switching to next one", currentTab-1))
 			SwitchStashTab(currentTab)
 		}
 	}
@@ -217,6 +218,11 @@ func shouldStashIt(i data.Item, firstRun bool) (bool, string, string) {
 		return false, "", ""
 	}
 
+	// Items kept while gambling or shopping were evaluated against their own rules, not the pickit ones
+	if rule, found := ctx.CurrentGame.KeptVendorItemRule(i.UnitID); found {
+		return true, rule.RawLine, rule.Filename + ":" + strconv.Itoa(rule.LineNumber)
+	}
+
 	// Let's stash everything during first run, we don't want to sell items from the user
 	if firstRun {
 		return true, "FirstRun", ""
@@ -291,22 +297,38 @@ func stashItemAction(i data.Item, rule string, ruleFile string, skipLogging bool
 	}
 
 	dropLocation := "unknown"
+	_, gambled := ctx.CurrentGame.GambledItems[i.UnitID]
 
 	// log the contents of picked up items
 	ctx.Logger.Info(fmt.Sprintf("Picked up items: %v", ctx.CurrentGame.PickedUpItems))
 
-	if _, found := ctx.CurrentGame.PickedUpItems[int(i.UnitID)]; found {
-		areaId := ctx.CurrentGame.PickedUpItems[int(i.UnitID)]
-		dropLocation = area.ID(ctx.CurrentGame.PickedUpItems[int(i.UnitID)]).Area().Name
+	// The item could have been picked up in a previous game
+	pickup, pickedUp := ctx.Pickups[i.UnitID]
+	if pickedUp {
+		dropLocation = pickup.Area.Area().Name
 
-		if slices.Contains(ctx.Data.TerrorZones, area.ID(areaId)) {
+		if slices.Contains(ctx.Data.TerrorZones, pickup.Area) {
 			dropLocation += " (terrorized)"
 		}
+		delete(ctx.Pickups, i.UnitID)
+	}
+
+	if gambled {
+		dropLocation = "Gambled"
+	} else if _, purchased := ctx.CurrentGame.PurchasedItems[i.UnitID]; purchased {
+		dropLocation = "Purchased"
+	}
+
+	drop := data.Drop{Item: i, Rule: rule, RuleFile: ruleFile, DropLocation: dropLocation}
+
+	// Goals count every stashed item, including the ones we don't notify about
+	if !skipLogging && len(ctx.CharacterCfg.Goals) > 0 {
+		event.Send(event.GoalItemStashed(event.Text(ctx.Name, fmt.Sprintf("Item %s [%d] stashed", i.Name, i.Quality)), drop))
 	}
 
 	// Don't log items that we already have in inventory during first run or that we don't want to notify about (gems, low runes .. etc)
 	if !skipLogging && shouldNotifyAboutStashing(i) && ruleFile != "" {
-		event.Send(event.ItemStashed(event.WithScreenshot(ctx.Name, fmt.Sprintf("Item %s [%d] stashed", i.Name, i.Quality), screenshot), data.Drop{Item: i, Rule: rule, RuleFile: ruleFile, DropLocation: dropLocation}))
+		event.Send(event.ItemStashed(event.WithScreenshot(ctx.Name, fmt.Sprintf("Item %s [%d] stashed", i.Name, i.Quality), screenshot), drop, gambled, pickup.Run, pickup.RunStartedAt, pickup.Area))
 	}
 
 	return true
@@ -3,6 +3,7 @@ package step
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"math"
 	"time"
 
@@ -10,10 +11,21 @@ import (
 	"github.com/hectorgimenez/d2go/pkg/data/mode"
 	"github.com/hectorgimenez/d2go/pkg/data/skill"
 	"github.com/hectorgimenez/koolo/internal/context"
+	"github.com/hectorgimenez/koolo/internal/game"
+	"github.com/hectorgimenez/koolo/internal/pather"
 	"github.com/hectorgimenez/koolo/internal/utils"
 )
 
-const DistanceToFinishMoving = 4
+const (
+	DistanceToFinishMoving = 4
+	// obstacleInteractionDistance is how close to a door, breakable object or barricade in the path we have to be to
+	// remove it
+	obstacleInteractionDistance = 6
+	// obstaclePathPadding is the max distance between the path and an obstacle to consider it's blocking the path
+	obstaclePathPadding = 2
+	// maxBarricadeAttacks is the amount of attacks after giving up destroying a barricade
+	maxBarricadeAttacks = 30
+)
 
 type MoveOpts struct {
 	distanceOverride *int
@@ -59,12 +71,10 @@ func MoveTo(dest data.Position, options ...MoveOption) error {
 	}()
 
 	timeout := time.Second * 30
-	idleThreshold := time.Second * 3
-	idleStartTime := time.Time{}
+	watchdog := newMovementWatchdog()
 
 	startedAt := time.Now()
 	lastRun := time.Time{}
-	previousPosition := data.Position{}
 	previousDistance := 0
 
 	for {
@@ -86,22 +96,6 @@ func MoveTo(dest data.Position, options ...MoveOption) error {
 			continue
 		}
 
-		// Check for idle state
-		if ctx.Data.PlayerUnit.Position == previousPosition {
-			if idleStartTime.IsZero() {
-				idleStartTime = time.Now()
-			} else if time.Since(idleStartTime) > idleThreshold {
-				// Perform anti-idle action
-				ctx.Logger.Debug("Anti-idle triggered")
-				ctx.PathFinder.RandomMovement()
-				idleStartTime = time.Time{} // Reset idle timer
-				continue
-			}
-		} else {
-			idleStartTime = time.Time{} // Reset idle timer if position changed
-			previousPosition = ctx.Data.PlayerUnit.Position
-		}
-
 		// Press the Teleport keybinding if it's available, otherwise use vigor (if available)
 		if ctx.Data.CanTeleport() {
 			if ctx.Data.PlayerUnit.RightSkill != skill.Teleport {
@@ -113,6 +107,32 @@ func MoveTo(dest data.Position, options ...MoveOption) error {
 			}
 		}
 
+		// Teleport hops can cross gaps and walls, fall back to the walking path if they can not be calculated
+		if ctx.Data.CanTeleport() {
+			if hops, found := ctx.PathFinder.GetTeleportHops(dest); found {
+				// Destination reached, or it's not walkable and we are already on the closest walkable tile
+				if ctx.PathFinder.DistanceFromMe(dest) <= minDistanceToFinishMoving || len(hops) == 0 || (len(hops) == 1 && ctx.PathFinder.DistanceFromMe(hops[0]) <= 2) {
+					return nil
+				}
+
+				// Exit on timeout
+				if timeout > 0 && time.Since(startedAt) > timeout {
+					return nil
+				}
+
+				lastRun = time.Now()
+				if watchdog.stuck(ctx.PathFinder.DistanceFromMe(dest)) {
+					if err := watchdog.recover(nil); err != nil {
+						return err
+					}
+					continue
+				}
+
+				ctx.PathFinder.MoveCharacter(ctx.PathFinder.GameCoordsToScreenCords(hops[0].X, hops[0].Y))
+				continue
+			}
+		}
+
 		path, distance, found := ctx.PathFinder.GetPath(dest)
 		if !found {
 			if ctx.PathFinder.DistanceFromMe(dest) < minDistanceToFinishMoving+5 {
@@ -130,7 +150,39 @@ func MoveTo(dest data.Position, options ...MoveOption) error {
 			return nil
 		}
 
+		// Paths can go through closed doors, breakable objects and barricades, remove them when we are close enough and
+		// calculate the path again
+		if o, found := objectObstacleOnPath(path); found && ctx.PathFinder.DistanceFromMe(o.Position) <= obstacleInteractionDistance {
+			ctx.Logger.Debug("Object blocking the path, opening or breaking it", slog.Any("object", o.Name))
+			err := InteractObject(o, func() bool {
+				obj, found := ctx.Data.Objects.FindByID(o.ID)
+				if found && obj.Selectable && pather.IsBreakableObstacle(obj) {
+					// Additional click on breakable objects to avoid getting stuck
+					x, y := ctx.PathFinder.GameCoordsToScreenCords(obj.Position.X, obj.Position.Y)
+					ctx.HID.Click(game.LeftButton, x, y)
+				}
+				return !found || !obj.Selectable
+			})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if m, found := barricadeOnPath(path); found && ctx.PathFinder.DistanceFromMe(m.Position) <= obstacleInteractionDistance {
+			ctx.Logger.Debug("Barricade blocking the path, destroying it", slog.Any("barricade", m.Name))
+			if err := destroyBarricade(m); err != nil {
+				return err
+			}
+			continue
+		}
+
 		lastRun = time.Now()
+		if watchdog.stuck(distance) {
+			if err := watchdog.recover(path); err != nil {
+				return err
+			}
+			continue
+		}
 
 		// This is a workaround to avoid the character to get stuck in the same position when the hitbox of the destination is too big
 		if distance < 20 && math.Abs(float64(previousDistance-distance)) < DistanceToFinishMoving {
@@ -141,8 +193,82 @@ func MoveTo(dest data.Position, options ...MoveOption) error {
 			minDistanceToFinishMoving = DistanceToFinishMoving
 		}
 
-		previousPosition = ctx.Data.PlayerUnit.Position
 		previousDistance = distance
 		ctx.PathFinder.MoveThroughPath(path, walkDuration)
 	}
 }
+
+// objectObstacleOnPath returns the first closed door or breakable object the path goes through
+func objectObstacleOnPath(path pather.Path) (data.Object, bool) {
+	ctx := context.Get()
+
+	for _, p := range absolutePath(path) {
+		for _, o := range ctx.Data.Objects {
+			if (pather.IsClosedDoor(o) || pather.IsBreakableObstacle(o)) && isCloseToPath(p, o.Position) {
+				return o, true
+			}
+		}
+	}
+
+	return data.Object{}, false
+}
+
+// barricadeOnPath returns the first barricade the path goes through
+func barricadeOnPath(path pather.Path) (data.Monster, bool) {
+	ctx := context.Get()
+
+	for _, p := range absolutePath(path) {
+		for _, m := range ctx.Data.Monsters {
+			if pather.IsBarricade(m) && isCloseToPath(p, m.Position) {
+				return m, true
+			}
+		}
+	}
+
+	return data.Monster{}, false
+}
+
+// absolutePath converts the path, relative to its grid, to absolute positions. The first tile is the character position.
+func absolutePath(path pather.Path) []data.Position {
+	ctx := context.Get()
+
+	offsetX := ctx.Data.PlayerUnit.Position.X - path.From().X
+	offsetY := ctx.Data.PlayerUnit.Position.Y - path.From().Y
+	positions := make([]data.Position, 0, len(path))
+	for _, p := range path {
+		positions = append(positions, data.Position{X: p.X + offsetX, Y: p.Y + offsetY})
+	}
+
+	return positions
+}
+
+func isCloseToPath(pathPosition, obstacle data.Position) bool {
+	dx, dy := pathPosition.X-obstacle.X, pathPosition.Y-obstacle.Y
+
+	return dx >= -obstaclePathPadding && dx <= obstaclePathPadding && dy >= -obstaclePathPadding && dy <= obstaclePathPadding
+}
+
+// destroyBarricade attacks the barricade standing still until it's destroyed. Attack steps can't be used, they move
+// to the target and the movement would find the barricade blocking the path again.
+func destroyBarricade(barricade data.Monster) error {
+	ctx := context.Get()
+	ctx.SetLastStep("DestroyBarricade")
+
+	ctx.HID.KeyDown(ctx.Data.KeyBindings.StandStill)
+	defer ctx.HID.KeyUp(ctx.Data.KeyBindings.StandStill)
+
+	for i := 0; i < maxBarricadeAttacks; i++ {
+		ctx.PauseIfNotPriority()
+
+		m, found := ctx.Data.Monsters.FindByID(barricade.UnitID)
+		if !found || !pather.IsBarricade(m) {
+			return nil
+		}
+
+		x, y := ctx.PathFinder.GameCoordsToScreenCords(m.Position.X, m.Position.Y)
+		ctx.HID.Click(game.LeftButton, x, y)
+		time.Sleep(ctx.Data.PlayerCastDuration())
+	}
+
+	return fmt.Errorf("barricade %d could not be destroyed", barricade.Name)
+}
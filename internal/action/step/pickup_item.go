@@ -103,6 +103,12 @@ func PickupItem(it data.Item, itemPickupAttempt int) error {
 			ctx.Logger.Info(fmt.Sprintf("Picked up: %s [%s] | Item Pickup Attempt:%d | Spiral Attempt:%d", targetItem.Desc().Name, targetItem.Quality.ToString(), itemPickupAttempt, spiralAttempt))
 
 			ctx.CurrentGame.PickedUpItems[int(targetItem.UnitID)] = int(ctx.Data.PlayerUnit.Area.Area().ID)
+			ctx.Pickups[targetItem.UnitID] = context.Pickup{
+				Run:          ctx.CurrentGame.CurrentRun,
+				RunStartedAt: ctx.CurrentGame.CurrentRunStartedAt,
+				Area:         ctx.Data.PlayerUnit.Area,
+				PickedUpAt:   time.Now(),
+			}
 
 			return nil // Success!
 		}
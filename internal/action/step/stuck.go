@@ -0,0 +1,161 @@
+package step
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/skill"
+	"github.com/hectorgimenez/koolo/internal/context"
+	"github.com/hectorgimenez/koolo/internal/event"
+	"github.com/hectorgimenez/koolo/internal/pather"
+)
+
+const (
+	// stuckTimeout is the time without getting closer to the destination before considering the character stuck
+	stuckTimeout = time.Second * 3
+	// minProgressDistance is how much closer to the destination the character has to get to count as progress
+	minProgressDistance = 2
+	// blockedPathTiles is the amount of path tiles in front of the character marked as blocked when re-pathing
+	blockedPathTiles = 3
+)
+
+// ErrStuck is returned when all the recovery strategies failed, the caller should leave the area (town portal)
+var ErrStuck = errors.New("character is stuck")
+
+type stuckRecovery int
+
+const (
+	recoverySidestep stuckRecovery = iota
+	recoveryRepath
+	recoveryTeleport
+	recoveryCloseMenus
+	recoveryTownPortal
+)
+
+func (r stuckRecovery) String() string {
+	switch r {
+	case recoverySidestep:
+		return "sidestep"
+	case recoveryRepath:
+		return "re-path"
+	case recoveryTeleport:
+		return "teleport"
+	case recoveryCloseMenus:
+		return "close menus"
+	default:
+		return "town portal"
+	}
+}
+
+// movementWatchdog detects when the character is not getting closer to the destination, every time it happens again
+// without any progress in between a stronger recovery strategy is used
+type movementWatchdog struct {
+	bestDistance int
+	lastProgress time.Time
+	next         stuckRecovery
+	now          func() time.Time
+}
+
+func newMovementWatchdog() *movementWatchdog {
+	return &movementWatchdog{
+		bestDistance: math.MaxInt,
+		lastProgress: time.Now(),
+		now:          time.Now,
+	}
+}
+
+// stuck registers the current distance to the destination, and returns true if there was no progress for stuckTimeout
+func (w *movementWatchdog) stuck(distance int) bool {
+	// First distance after starting or recovering, there is nothing to compare with
+	if w.bestDistance == math.MaxInt {
+		w.bestDistance = distance
+		w.lastProgress = w.now()
+		return false
+	}
+
+	if distance <= w.bestDistance-minProgressDistance {
+		w.bestDistance = distance
+		w.lastProgress = w.now()
+		w.next = recoverySidestep
+		return false
+	}
+
+	return w.now().Sub(w.lastProgress) > stuckTimeout
+}
+
+// nextRecovery returns the recovery strategy to use, skipping the ones that can't be applied
+func (w *movementWatchdog) nextRecovery(hasPath, canTeleport, menuOpen bool) stuckRecovery {
+	recovery := w.next
+	if recovery == recoveryRepath && !hasPath {
+		recovery++
+	}
+	if recovery == recoveryTeleport && !canTeleport {
+		recovery++
+	}
+	if recovery == recoveryCloseMenus && !menuOpen {
+		recovery++
+	}
+
+	return recovery
+}
+
+// recovered registers the applied recovery, the next one will be stronger unless there is progress before
+func (w *movementWatchdog) recovered(recovery stuckRecovery) {
+	w.next = recovery + 1
+	w.bestDistance = math.MaxInt
+	w.lastProgress = w.now()
+}
+
+// recover applies the next recovery strategy, path is the current walking path (relative to its grid), it can be nil
+func (w *movementWatchdog) recover(path pather.Path) error {
+	ctx := context.Get()
+
+	_, teleportBound := ctx.Data.KeyBindings.KeyBindingForSkill(skill.Teleport)
+	recovery := w.nextRecovery(len(path) >= 2, teleportBound && !ctx.Data.PlayerUnit.Area.IsTown(), ctx.Data.OpenMenus.IsMenuOpen())
+
+	pos := ctx.Data.PlayerUnit.Position
+	ctx.Logger.Warn("Character stuck, trying to recover",
+		slog.String("area", ctx.Data.PlayerUnit.Area.Area().Name),
+		slog.Any("position", pos),
+		slog.String("recovery", recovery.String()),
+	)
+	event.Send(event.Stuck(
+		event.Text(ctx.Name, fmt.Sprintf("Character stuck in %s at %d,%d, recovery: %s", ctx.Data.PlayerUnit.Area.Area().Name, pos.X, pos.Y, recovery)),
+		ctx.Data.PlayerUnit.Area,
+		pos,
+		ctx.GameReader.MapSeed(),
+		recovery.String(),
+	))
+
+	switch recovery {
+	case recoverySidestep:
+		ctx.PathFinder.RandomMovement()
+	case recoveryRepath:
+		// Path is relative to its grid, the first tile is the character position
+		offsetX, offsetY := pos.X-path.From().X, pos.Y-path.From().Y
+		blocked := make([]data.Position, 0, blockedPathTiles)
+		for _, p := range path[1:min(len(path), blockedPathTiles+1)] {
+			blocked = append(blocked, data.Position{X: p.X + offsetX, Y: p.Y + offsetY})
+		}
+		ctx.PathFinder.BlockPositions(blocked...)
+	case recoveryTeleport:
+		if ctx.Data.PlayerUnit.RightSkill != skill.Teleport {
+			ctx.HID.PressKeyBinding(ctx.Data.KeyBindings.MustKBForSkill(skill.Teleport))
+		}
+		ctx.PathFinder.RandomTeleport()
+	case recoveryCloseMenus:
+		if err := CloseAllMenus(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w in %s at %d,%d", ErrStuck, ctx.Data.PlayerUnit.Area.Area().Name, pos.X, pos.Y)
+	}
+
+	w.recovered(recovery)
+
+	return nil
+}
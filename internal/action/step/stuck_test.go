@@ -0,0 +1,112 @@
+package step
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMovementWatchdogStuck(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	now := start
+	w := newMovementWatchdog()
+	w.now = func() time.Time { return now }
+
+	tests := []struct {
+		name     string
+		after    time.Duration
+		distance int
+		stuck    bool
+	}{
+		{name: "first distance", distance: 100},
+		{name: "no progress yet", after: time.Second * 2, distance: 100},
+		{name: "progress", after: time.Second * 3, distance: 98},
+		{name: "not enough progress", after: time.Second * 5, distance: 97},
+		{name: "moving away", after: time.Second * 6, distance: 110},
+		{name: "at the timeout", after: time.Second * 6, distance: 99},
+		{name: "after the timeout", after: time.Second*6 + time.Millisecond, distance: 99, stuck: true},
+		{name: "progress after being stuck", after: time.Second * 7, distance: 90},
+	}
+
+	for _, tt := range tests {
+		now = start.Add(tt.after)
+		if stuck := w.stuck(tt.distance); stuck != tt.stuck {
+			t.Errorf("%s: expected stuck %t, got %t", tt.name, tt.stuck, stuck)
+		}
+	}
+}
+
+func TestMovementWatchdogRecoveryLevels(t *testing.T) {
+	type check struct {
+		// progress is a distance registered before getting stuck, 0 if there was no progress
+		progress    int
+		hasPath     bool
+		canTeleport bool
+		menuOpen    bool
+		expected    stuckRecovery
+	}
+
+	tests := []struct {
+		name   string
+		checks []check
+	}{
+		{
+			name: "every recovery without progress",
+			checks: []check{
+				{hasPath: true, canTeleport: true, menuOpen: true, expected: recoverySidestep},
+				{hasPath: true, canTeleport: true, menuOpen: true, expected: recoveryRepath},
+				{hasPath: true, canTeleport: true, menuOpen: true, expected: recoveryTeleport},
+				{hasPath: true, canTeleport: true, menuOpen: true, expected: recoveryCloseMenus},
+				{hasPath: true, canTeleport: true, menuOpen: true, expected: recoveryTownPortal},
+			},
+		},
+		{
+			name: "recoveries that can't be applied are skipped",
+			checks: []check{
+				{expected: recoverySidestep},
+				{expected: recoveryTownPortal},
+			},
+		},
+		{
+			name: "no path but teleport",
+			checks: []check{
+				{canTeleport: true, expected: recoverySidestep},
+				{canTeleport: true, expected: recoveryTeleport},
+				{canTeleport: true, expected: recoveryTownPortal},
+			},
+		},
+		{
+			name: "progress resets the recoveries",
+			checks: []check{
+				{hasPath: true, expected: recoverySidestep},
+				{hasPath: true, expected: recoveryRepath},
+				{progress: 50, hasPath: true, expected: recoverySidestep},
+				{hasPath: true, expected: recoveryRepath},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+			w := newMovementWatchdog()
+			w.now = func() time.Time { return now }
+
+			for i, c := range tt.checks {
+				w.stuck(100)
+				if c.progress > 0 {
+					w.stuck(c.progress)
+				}
+				now = now.Add(stuckTimeout + time.Second)
+				if !w.stuck(100) {
+					t.Fatalf("check %d: expected stuck", i)
+				}
+
+				recovery := w.nextRecovery(c.hasPath, c.canTeleport, c.menuOpen)
+				if recovery != c.expected {
+					t.Errorf("check %d: expected %s, got %s", i, c.expected, recovery)
+				}
+				w.recovered(recovery)
+			}
+		})
+	}
+}
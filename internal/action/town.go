@@ -38,6 +38,7 @@ func PreRun(firstRun bool) error {
 
 	// Refill pots, sell, buy etc
 	VendorRefill(false, true)
+	DropJunk()
 
 	// Gamble
 	Gamble()
@@ -45,6 +46,9 @@ func PreRun(firstRun bool) error {
 	// Stash again if needed
 	Stash(false)
 
+	// Swap carried charms if we found better ones
+	ManageCharms()
+
 	CubeRecipes()
 
 	// Leveling related checks
@@ -89,9 +93,11 @@ func InRunReturnTownRoutine() error {
 	IdentifyAll(false)
 
 	VendorRefill(false, true)
+	DropJunk()
 	Stash(false)
 	Gamble()
 	Stash(false)
+	ManageCharms()
 	CubeRecipes()
 
 	if ctx.CharacterCfg.Game.Leveling.EnsurePointsAllocation {
@@ -1,6 +1,7 @@
 package action
 
 import (
+	"fmt"
 	"log/slog"
 
 	"github.com/hectorgimenez/koolo/internal/action/step"
@@ -81,6 +82,29 @@ func BuyAtVendor(vendor npc.ID, items ...VendorItemRequest) error {
 	return step.CloseAllMenus()
 }
 
+// DropJunk drops the items that would be sold but are worth less than the min sell value, on dry run mode it only logs
+// the items that would be sold or dropped
+func DropJunk() error {
+	ctx := context.Get()
+	ctx.SetLastAction("DropJunk")
+
+	if ctx.CharacterCfg.Selling.DryRun {
+		town.LogDryRunJunk()
+		return nil
+	}
+
+	for _, itm := range town.ItemsToBeDropped() {
+		ctx.Logger.Info(fmt.Sprintf("Dropping %s [%s], it's worth less than the min sell value", itm.Desc().Name, itm.Quality.ToString()))
+		if err := DropInventoryItem(itm); err != nil {
+			return err
+		}
+		// We don't want to pick it up again
+		ctx.CurrentGame.BlacklistedItems = append(ctx.CurrentGame.BlacklistedItems, itm)
+	}
+
+	return nil
+}
+
 type VendorItemRequest struct {
 	Item     item.Name
 	Quantity int
@@ -91,8 +115,8 @@ func shouldVisitVendor() bool {
 	ctx := context.Get()
 	ctx.SetLastStep("shouldVisitVendor")
 
-	// Check if we should sell junk
-	if len(town.ItemsToBeSold()) > 0 {
+	// Check if we should sell junk, nothing will be sold on dry run mode so there is no need to visit the vendor
+	if !ctx.CharacterCfg.Selling.DryRun && len(town.ItemsToBeSold()) > 0 {
 		return true
 	}
 
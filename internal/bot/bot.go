@@ -16,12 +16,14 @@ import (
 )
 
 type Bot struct {
-	ctx *botCtx.Context
+	ctx   *botCtx.Context
+	stats *StatsHandler
 }
 
-func NewBot(ctx *botCtx.Context) *Bot {
+func NewBot(ctx *botCtx.Context, stats *StatsHandler) *Bot {
 	return &Bot{
-		ctx: ctx,
+		ctx:   ctx,
+		stats: stats,
 	}
 }
 func (b *Bot) Run(ctx context.Context, firstRun bool, runs []run.Run) error {
@@ -201,7 +203,12 @@ func (b *Bot) Run(ctx context.Context, firstRun bool, runs []run.Run) error {
 			case <-ctx.Done():
 				return nil
 			default:
-				event.Send(event.RunStarted(event.Text(b.ctx.Name, fmt.Sprintf("Starting run: %s", r.Name())), r.Name()))
+				b.ctx.CurrentGame.CurrentRun = r.Name()
+				sampleExperience(b.ctx, b.stats)
+				startExperience, startGold := PlayerExperience(b.ctx.Data.PlayerUnit), b.ctx.Data.PlayerUnit.TotalPlayerGold()
+				runStarted := event.RunStarted(event.Text(b.ctx.Name, fmt.Sprintf("Starting run: %s", r.Name())), r.Name())
+				b.ctx.CurrentGame.CurrentRunStartedAt = runStarted.OccurredAt()
+				event.Send(runStarted)
 				err = action.PreRun(firstRun)
 				if err != nil {
 					return err
@@ -226,7 +233,10 @@ func (b *Bot) Run(ctx context.Context, firstRun bool, runs []run.Run) error {
 					runFinishReason = event.FinishedOK
 				}
 
-				event.Send(event.RunFinished(event.Text(b.ctx.Name, fmt.Sprintf("Finished run: %s", r.Name())), r.Name(), runFinishReason))
+				sampleExperience(b.ctx, b.stats)
+				experienceGained := PlayerExperience(b.ctx.Data.PlayerUnit) - startExperience
+				goldGained := b.ctx.Data.PlayerUnit.TotalPlayerGold() - startGold
+				event.Send(event.RunFinished(event.Text(b.ctx.Name, fmt.Sprintf("Finished run: %s", r.Name())), r.Name(), runFinishReason, experienceGained, goldGained))
 
 				if err != nil {
 					return err
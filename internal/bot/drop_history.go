@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/area"
+	"github.com/hectorgimenez/koolo/internal/event"
+)
+
+// DropRecord is a stashed item with the supervisor, run and area where it was found
+type DropRecord struct {
+	Supervisor string    `json:"supervisor"`
+	StashedAt  time.Time `json:"stashedAt"`
+	RunName    string    `json:"runName"`
+	Area       area.ID   `json:"area"`
+	Gambled    bool      `json:"gambled"`
+	Drop       data.Drop `json:"drop"`
+}
+
+// DropHistory keeps every stashed item on disk, one file by supervisor, so drops aren't lost when the supervisor or
+// Koolo are restarted like the ones in Stats
+type DropHistory struct {
+	mu     sync.Mutex
+	logger *slog.Logger
+}
+
+func NewDropHistory(logger *slog.Logger) *DropHistory {
+	return &DropHistory{logger: logger}
+}
+
+func (h *DropHistory) Handle(_ context.Context, e event.Event) error {
+	evt, ok := e.(event.ItemStashedEvent)
+	if !ok {
+		return nil
+	}
+
+	content, err := json.Marshal(DropRecord{
+		Supervisor: evt.Supervisor(),
+		StashedAt:  evt.OccurredAt(),
+		RunName:    evt.RunName,
+		Area:       evt.Area,
+		Gambled:    evt.Gambled,
+		Drop:       evt.Item,
+	})
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	f, err := os.OpenFile(dropHistoryFile(evt.Supervisor()), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(content, '\n'))
+
+	return err
+}
+
+// Drops returns the drop history of the given supervisors, oldest first by supervisor
+func (h *DropHistory) Drops(supervisors ...string) []DropRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	drops := make([]DropRecord, 0)
+	for _, name := range supervisors {
+		records, err := readDropHistory(dropHistoryFile(name))
+		if err != nil {
+			h.logger.Warn("Error reading drop history", slog.String("supervisor", name), slog.Any("error", err))
+		}
+		drops = append(drops, records...)
+	}
+
+	return drops
+}
+
+func dropHistoryFile(supervisor string) string {
+	return filepath.Join("config", supervisor, "drop_history.jsonl")
+}
+
+// readDropHistory returns the records of the file, a broken line (e.g. Koolo was closed while writing it) is skipped
+func readDropHistory(path string) ([]DropRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []DropRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r DropRecord
+		if err = json.Unmarshal(scanner.Bytes(), &r); err == nil {
+			records = append(records, r)
+		}
+	}
+
+	return records, scanner.Err()
+}
@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/stat"
+	botCtx "github.com/hectorgimenez/koolo/internal/context"
+	"github.com/hectorgimenez/koolo/internal/event"
+)
+
+const maxLevel = 99
+
+// experienceByLevel is the total experience needed to reach every level (the index), from the game experience table
+var experienceByLevel = [maxLevel + 1]int{
+	0, 0, 500, 1500, 3750, 7875, 14175, 22680, 32886, 44396,
+	57715, 72144, 90180, 112725, 140906, 176132, 220165, 275207, 344008, 430010,
+	537513, 671891, 839864, 1049830, 1312287, 1640359, 2050449, 2563061, 3203826, 3902260,
+	4663553, 5493363, 6397855, 7383752, 8458379, 9629723, 10906488, 12298162, 13815086, 15468534,
+	17270791, 19235252, 21376515, 23710491, 26254525, 29027522, 32050088, 35344686, 38935798, 42850109,
+	47116709, 51767302, 56836449, 62361819, 68384473, 74949165, 82104680, 89904191, 98405658, 107672256,
+	117772849, 128782495, 140783010, 153863570, 168121381, 183662396, 200602101, 219066380, 239192444, 261129853,
+	285041630, 311105466, 339515048, 370481492, 404234916, 441026148, 481128591, 524840254, 572485967, 624419793,
+	681027665, 742730244, 809986056, 883294891, 963201521, 1050299747, 1145236814, 1248718217, 1361512946, 1484459201,
+	1618470619, 1764543065, 1923762030, 2097310703, 2286478756, 2492671933, 2717422497, 2962400612, 3229426756, 3520485254,
+}
+
+// ExperienceProgress is the summary of the experience tracking shown in the dashboard and sent by the notifiers
+type ExperienceProgress struct {
+	Tracked           bool
+	Level             int
+	Experience        int
+	Gained            int
+	PerHour           float64
+	ToNextLevel       int
+	NextLevelPercent  float64
+	NextLevelETA      time.Duration
+	NextLevelETAKnown bool
+	LevelUps          []LevelUpStats
+}
+
+type LevelUpStats struct {
+	Level     int
+	ReachedAt time.Time
+}
+
+// PlayerExperience returns the experience of the character, it's stored as unsigned in memory and goes over the int32
+// range at the highest levels
+func PlayerExperience(pu data.PlayerUnit) int {
+	exp, _ := pu.FindStat(stat.Experience, 0)
+
+	return int(uint32(exp.Value))
+}
+
+// sampleExperience registers the current level and experience of the character in the stats, a LevelUp event is sent
+// for every level reached since the previous sample. It's called at game and run boundaries.
+func sampleExperience(ctx *botCtx.Context, stats *StatsHandler) {
+	lvl, found := ctx.Data.PlayerUnit.FindStat(stat.Level, 0)
+	if !found || lvl.Value == 0 {
+		return
+	}
+
+	for _, level := range stats.UpdateExperience(lvl.Value, PlayerExperience(ctx.Data.PlayerUnit), time.Now()) {
+		progress := stats.Stats().ExperienceProgress()
+		msg := fmt.Sprintf("Level up! Reached level %d", level)
+		if progress.NextLevelETAKnown && level == progress.Level {
+			msg += fmt.Sprintf(", next level in %s", progress.NextLevelETA.Round(time.Minute))
+		}
+		event.Send(event.LevelUp(event.Text(ctx.Name, msg), level, progress.PerHour, progress.NextLevelETA))
+	}
+}
+
+// UpdateExperience registers the level and experience of the character, the first values received are the reference
+// to calculate the experience gained. It returns the levels reached since the previous values.
+func (h *StatsHandler) UpdateExperience(level, experience int, sampledAt time.Time) []int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.stats.ExperienceTracked {
+		h.stats.StartingExperience = experience
+		h.stats.ExperienceTrackedAt = sampledAt
+		h.stats.Level = level
+		h.stats.ExperienceTracked = true
+	}
+
+	var reached []int
+	for l := h.stats.Level + 1; l <= level; l++ {
+		reached = append(reached, l)
+		h.stats.LevelUps = append(h.stats.LevelUps, LevelUpStats{Level: l, ReachedAt: sampledAt})
+	}
+	h.stats.Level = level
+	h.stats.CurrentExperience = experience
+	h.stats.ExperienceSampledAt = sampledAt
+
+	return reached
+}
+
+// ExperienceGained returns the experience gained since the supervisor was started
+func (s Stats) ExperienceGained() int {
+	return s.CurrentExperience - s.StartingExperience
+}
+
+// ExperiencePerHour returns the experience gained by hour between the first and the last sample
+func (s Stats) ExperiencePerHour() float64 {
+	hours := s.ExperienceSampledAt.Sub(s.ExperienceTrackedAt).Hours()
+	if hours <= 0 {
+		return 0
+	}
+
+	return float64(s.ExperienceGained()) / hours
+}
+
+// ExperienceProgress returns the experience summary, the ETA to the next level is only known once experience has been
+// gained
+func (s Stats) ExperienceProgress() ExperienceProgress {
+	p := ExperienceProgress{
+		Tracked:    s.ExperienceTracked,
+		Level:      s.Level,
+		Experience: s.CurrentExperience,
+		Gained:     s.ExperienceGained(),
+		PerHour:    s.ExperiencePerHour(),
+		LevelUps:   s.LevelUps,
+	}
+	if !s.ExperienceTracked || s.Level < 1 || s.Level >= maxLevel {
+		return p
+	}
+
+	current, next := experienceByLevel[s.Level], experienceByLevel[s.Level+1]
+	p.ToNextLevel = max(next-s.CurrentExperience, 0)
+	p.NextLevelPercent = float64(s.CurrentExperience-current) / float64(next-current) * 100
+	if p.PerHour > 0 {
+		p.NextLevelETA = time.Duration(float64(p.ToNextLevel) / p.PerHour * float64(time.Hour)).Round(time.Second)
+		p.NextLevelETAKnown = true
+	}
+
+	return p
+}
@@ -0,0 +1,103 @@
+package bot
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/stat"
+)
+
+func TestUpdateExperienceLevelUps(t *testing.T) {
+	h := newTestStatsHandler()
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		level      int
+		experience int
+		reached    []int
+	}{
+		// The first sample is the reference, nothing was reached yet
+		{name: "first sample", level: 10, experience: experienceByLevel[10] + 1000},
+		{name: "same level", level: 10, experience: experienceByLevel[10] + 5000},
+		{name: "one level", level: 11, experience: experienceByLevel[11], reached: []int{11}},
+		{name: "several levels", level: 14, experience: experienceByLevel[14] + 10, reached: []int{12, 13, 14}},
+	}
+
+	for i, tt := range tests {
+		reached := h.UpdateExperience(tt.level, tt.experience, start.Add(time.Minute*time.Duration(i)))
+		if !slices.Equal(reached, tt.reached) {
+			t.Errorf("%s: expected levels %v, got %v", tt.name, tt.reached, reached)
+		}
+	}
+
+	stats := h.Stats()
+	var levels []int
+	for _, l := range stats.LevelUps {
+		levels = append(levels, l.Level)
+	}
+	if !slices.Equal(levels, []int{11, 12, 13, 14}) {
+		t.Errorf("expected level ups 11 to 14, got %v", levels)
+	}
+	if stats.Level != 14 || stats.ExperienceGained() != experienceByLevel[14]+10-experienceByLevel[10]-1000 {
+		t.Errorf("unexpected level %d or experience gained %d", stats.Level, stats.ExperienceGained())
+	}
+}
+
+func TestExperienceProgressETA(t *testing.T) {
+	h := newTestStatsHandler()
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	// Half of the level 90 experience is gained in an hour
+	h.UpdateExperience(90, experienceByLevel[90], start)
+	progress := h.Stats().ExperienceProgress()
+	if progress.NextLevelETAKnown || progress.PerHour != 0 {
+		t.Errorf("the ETA can't be known without experience gained, got %+v", progress)
+	}
+
+	levelExperience := experienceByLevel[91] - experienceByLevel[90]
+	h.UpdateExperience(90, experienceByLevel[90]+levelExperience/2, start.Add(time.Hour))
+	progress = h.Stats().ExperienceProgress()
+
+	if progress.PerHour != float64(levelExperience/2) {
+		t.Errorf("expected %d experience per hour, got %f", levelExperience/2, progress.PerHour)
+	}
+	if progress.ToNextLevel != levelExperience-levelExperience/2 {
+		t.Errorf("expected %d experience to the next level, got %d", levelExperience-levelExperience/2, progress.ToNextLevel)
+	}
+	if progress.NextLevelPercent < 49.99 || progress.NextLevelPercent > 50 {
+		t.Errorf("expected 50%% of the level, got %f", progress.NextLevelPercent)
+	}
+	if !progress.NextLevelETAKnown || progress.NextLevelETA != time.Hour {
+		t.Errorf("expected the next level in an hour, got %s (known %t)", progress.NextLevelETA, progress.NextLevelETAKnown)
+	}
+}
+
+func TestExperienceProgressLevel99(t *testing.T) {
+	h := newTestStatsHandler()
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	h.UpdateExperience(98, experienceByLevel[98], start)
+	reached := h.UpdateExperience(99, experienceByLevel[99], start.Add(time.Hour))
+	if !slices.Equal(reached, []int{99}) {
+		t.Errorf("expected level 99 reached, got %v", reached)
+	}
+
+	progress := h.Stats().ExperienceProgress()
+	if progress.Level != 99 || progress.ToNextLevel != 0 || progress.NextLevelPercent != 0 || progress.NextLevelETAKnown {
+		t.Errorf("there is no next level after 99, got %+v", progress)
+	}
+	if progress.Gained != experienceByLevel[99]-experienceByLevel[98] {
+		t.Errorf("expected the experience gained to reach 99, got %d", progress.Gained)
+	}
+}
+
+func TestPlayerExperience(t *testing.T) {
+	// The experience of the highest levels goes over the int32 range, memory reads it as a negative value
+	pu := data.PlayerUnit{Stats: stat.Stats{{ID: stat.Experience, Value: int(int32(uint32(experienceByLevel[99])))}}}
+	if got := PlayerExperience(pu); got != experienceByLevel[99] {
+		t.Errorf("expected %d, got %d", experienceByLevel[99], got)
+	}
+}
@@ -0,0 +1,243 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/nip"
+	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/event"
+)
+
+// ErrGoalReached is returned by the supervisor when it stops because a goal with the stop action has been reached
+var ErrGoalReached = errors.New("goal reached")
+
+type GoalProgress struct {
+	Name      string
+	Type      string
+	Target    int
+	Current   int
+	Reached   bool
+	ReachedAt time.Time
+}
+
+type goalState struct {
+	Current   int       `json:"current"`
+	ReachedAt time.Time `json:"reachedAt,omitempty"`
+}
+
+type GoalsHandler struct {
+	name          string
+	cfg           *config.CharacterCfg
+	logger        *slog.Logger
+	mu            sync.Mutex
+	state         map[string]*goalState
+	pendingAction config.GoalAction
+}
+
+func NewGoalsHandler(name string, cfg *config.CharacterCfg, logger *slog.Logger) *GoalsHandler {
+	h := &GoalsHandler{
+		name:   name,
+		cfg:    cfg,
+		logger: logger,
+		state:  make(map[string]*goalState),
+	}
+
+	if err := h.load(); err != nil {
+		logger.Warn("Error loading goals progress, starting from scratch", slog.Any("error", err))
+	}
+
+	return h
+}
+
+// Reset sets the config and logger of a new supervisor start, the pending action of the previous one is discarded
+func (h *GoalsHandler) Reset(cfg *config.CharacterCfg, logger *slog.Logger) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.cfg = cfg
+	h.logger = logger
+	h.pendingAction = config.GoalActionNone
+}
+
+func (h *GoalsHandler) Handle(_ context.Context, e event.Event) error {
+	// Only handle events from the supervisor
+	if !strings.EqualFold(e.Supervisor(), h.name) {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	changed := false
+	switch evt := e.(type) {
+	case event.GoalItemStashedEvent:
+		for _, g := range h.cfg.Goals {
+			rule, found := h.cfg.Runtime.GoalRules[g.Name]
+			if !found {
+				continue
+			}
+			if res, err := rule.Evaluate(evt.Item.Item); err == nil && res == nip.RuleResultFullMatch {
+				st := h.goalState(g.Name)
+				st.Current++
+				changed = true
+
+				_, target := goalTarget(g)
+				msg := fmt.Sprintf("Goal item found: %s (%d/%d)", g.Name, st.Current, target)
+				// We are inside the event listener, sending synchronously would block it forever
+				go event.Send(event.GoalItemFound(event.Text(h.name, msg), g, evt.Item))
+			}
+		}
+	case event.RunFinishedEvent:
+		if evt.Reason != event.FinishedOK {
+			return nil
+		}
+		for _, g := range h.cfg.Goals {
+			if goalType, _ := goalTarget(g); goalType == "runs" && (g.RunName == "" || strings.EqualFold(g.RunName, evt.RunName)) {
+				h.goalState(g.Name).Current++
+				changed = true
+			}
+		}
+	default:
+		return nil
+	}
+
+	if !changed {
+		return nil
+	}
+
+	h.checkGoals()
+
+	return h.save()
+}
+
+// CheckCharacter updates the level and gold goals, it should be called while the character is in game
+func (h *GoalsHandler) CheckCharacter(level, gold int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, g := range h.cfg.Goals {
+		switch goalType, _ := goalTarget(g); goalType {
+		case "level":
+			h.goalState(g.Name).Current = level
+		case "gold":
+			h.goalState(g.Name).Current = gold
+		}
+	}
+
+	h.checkGoals()
+
+	if err := h.save(); err != nil {
+		h.logger.Warn("Error saving goals progress", slog.Any("error", err))
+	}
+}
+
+// TakePendingAction returns the action of the goals reached since the last call, stop takes precedence over pause
+func (h *GoalsHandler) TakePendingAction() config.GoalAction {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	action := h.pendingAction
+	h.pendingAction = config.GoalActionNone
+
+	return action
+}
+
+func (h *GoalsHandler) Progress() []GoalProgress {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	progress := make([]GoalProgress, 0, len(h.cfg.Goals))
+	for _, g := range h.cfg.Goals {
+		st := h.goalState(g.Name)
+		goalType, target := goalTarget(g)
+		progress = append(progress, GoalProgress{
+			Name:      g.Name,
+			Type:      goalType,
+			Target:    target,
+			Current:   st.Current,
+			Reached:   !st.ReachedAt.IsZero(),
+			ReachedAt: st.ReachedAt,
+		})
+	}
+
+	return progress
+}
+
+func (h *GoalsHandler) checkGoals() {
+	for _, g := range h.cfg.Goals {
+		st := h.goalState(g.Name)
+		_, target := goalTarget(g)
+		if !st.ReachedAt.IsZero() || target <= 0 || st.Current < target {
+			continue
+		}
+
+		st.ReachedAt = time.Now()
+		h.logger.Info(fmt.Sprintf("Goal %s reached", g.Name), slog.Int("current", st.Current), slog.Int("target", target), slog.String("action", string(g.Action)))
+
+		if g.Action == config.GoalActionStop || (g.Action == config.GoalActionPause && h.pendingAction != config.GoalActionStop) {
+			h.pendingAction = g.Action
+		}
+
+		// We are inside the event listener, sending synchronously would block it forever
+		go event.Send(event.GoalReached(event.Text(h.name, fmt.Sprintf("Goal reached: %s (%d/%d)", g.Name, st.Current, target)), g))
+	}
+}
+
+func (h *GoalsHandler) goalState(name string) *goalState {
+	st, found := h.state[name]
+	if !found {
+		st = &goalState{}
+		h.state[name] = st
+	}
+
+	return st
+}
+
+func (h *GoalsHandler) progressFile() string {
+	return filepath.Join("config", h.name, "goals_progress.json")
+}
+
+func (h *GoalsHandler) load() error {
+	content, err := os.ReadFile(h.progressFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	return json.Unmarshal(content, &h.state)
+}
+
+func (h *GoalsHandler) save() error {
+	content, err := json.MarshalIndent(h.state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(h.progressFile(), content, 0644)
+}
+
+func goalTarget(g config.Goal) (string, int) {
+	switch {
+	case g.Item != "":
+		return "item", g.Quantity
+	case g.Level > 0:
+		return "level", g.Level
+	case g.Gold > 0:
+		return "gold", g.Gold
+	case g.Runs > 0:
+		return "runs", g.Runs
+	}
+
+	return "", 0
+}
@@ -1,9 +1,12 @@
 package bot
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strconv"
+	"sync"
 	"syscall"
 	"time"
 	"unsafe"
@@ -26,14 +29,38 @@ type SupervisorManager struct {
 	supervisors    map[string]Supervisor
 	crashDetectors map[string]*game.CrashDetector
 	eventListener  *event.Listener
+	dropHistory    *DropHistory
+
+	crashesMu sync.Mutex
+	crashes   map[string][]time.Time
+
+	// goalsHandlers are registered in the event listener once by supervisor and reused every time it's started
+	goalsHandlersMu sync.Mutex
+	goalsHandlers   map[string]*GoalsHandler
+
+	// stoppedGoals is the goals progress read from disk of the supervisors not running, by supervisor
+	stoppedGoalsMu sync.Mutex
+	stoppedGoals   map[string]stoppedGoalsProgress
+}
+
+type stoppedGoalsProgress struct {
+	cfg      *config.CharacterCfg
+	progress []GoalProgress
 }
 
 func NewSupervisorManager(logger *slog.Logger, eventListener *event.Listener) *SupervisorManager {
+	dropHistory := NewDropHistory(logger)
+	eventListener.Register(dropHistory.Handle)
+
 	return &SupervisorManager{
 		logger:         logger,
 		supervisors:    make(map[string]Supervisor),
 		crashDetectors: make(map[string]*game.CrashDetector),
 		eventListener:  eventListener,
+		dropHistory:    dropHistory,
+		crashes:        make(map[string][]time.Time),
+		goalsHandlers:  make(map[string]*GoalsHandler),
+		stoppedGoals:   make(map[string]stoppedGoalsProgress),
 	}
 }
 
@@ -48,6 +75,11 @@ func (mng *SupervisorManager) AvailableSupervisors() []string {
 	return availableSupervisors
 }
 
+// DropHistory returns every item stashed by any of the supervisors, including previous Koolo executions
+func (mng *SupervisorManager) DropHistory() []DropRecord {
+	return mng.dropHistory.Drops(mng.AvailableSupervisors()...)
+}
+
 func (mng *SupervisorManager) Start(supervisorName string, attachToExisting bool, pidHwnd ...uint32) error {
 	// Avoid multiple instances of the supervisor - shitstorm prevention
 	if _, exists := mng.supervisors[supervisorName]; exists {
@@ -103,7 +135,10 @@ func (mng *SupervisorManager) Start(supervisorName string, attachToExisting bool
 	go crashDetector.Start()
 
 	err = supervisor.Start()
-	if err != nil {
+	if errors.Is(err, ErrGoalReached) {
+		mng.logger.Info(fmt.Sprintf("Supervisor %s reached its goals, stopping", supervisorName))
+		mng.Stop(supervisorName)
+	} else if err != nil {
 		mng.logger.Error(fmt.Sprintf("error running supervisor %s: %s", supervisorName, err.Error()))
 	}
 
@@ -157,6 +192,11 @@ func (mng *SupervisorManager) Stop(supervisor string) {
 		// Delete him from the list of Supervisors
 		delete(mng.supervisors, supervisor)
 
+		// The goals progress changed while it was running
+		mng.stoppedGoalsMu.Lock()
+		delete(mng.stoppedGoals, supervisor)
+		mng.stoppedGoalsMu.Unlock()
+
 		if cd, ok := mng.crashDetectors[supervisor]; ok {
 			cd.Stop()
 			delete(mng.crashDetectors, supervisor)
@@ -181,6 +221,31 @@ func (mng *SupervisorManager) Status(characterName string) Stats {
 	return Stats{}
 }
 
+// GoalsProgress returns the goals progress of the given character, it's read from disk when the supervisor is not running
+// and kept until the supervisor is stopped again or the config is reloaded
+func (mng *SupervisorManager) GoalsProgress(characterName string) []GoalProgress {
+	if supervisor, found := mng.supervisors[characterName]; found {
+		return supervisor.GoalsProgress()
+	}
+
+	cfg, found := config.Characters[characterName]
+	if !found {
+		return nil
+	}
+
+	mng.stoppedGoalsMu.Lock()
+	defer mng.stoppedGoalsMu.Unlock()
+
+	if cached, found := mng.stoppedGoals[characterName]; found && cached.cfg == cfg {
+		return cached.progress
+	}
+
+	progress := NewGoalsHandler(characterName, cfg, mng.logger).Progress()
+	mng.stoppedGoals[characterName] = stoppedGoalsProgress{cfg: cfg, progress: progress}
+
+	return progress
+}
+
 func (mng *SupervisorManager) GetData(characterName string) *game.Data {
 	for name, supervisor := range mng.supervisors {
 		if name == characterName {
@@ -259,14 +324,16 @@ func (mng *SupervisorManager) buildSupervisor(supervisorName string, logger *slo
 	}
 	ctx.Char = char
 
-	bot := NewBot(ctx.Context)
-
 	statsHandler := NewStatsHandler(supervisorName, logger)
 	mng.eventListener.Register(statsHandler.Handle)
 
+	bot := NewBot(ctx.Context, statsHandler)
+
+	goalsHandler := mng.goalsHandler(supervisorName, cfg, logger)
+
 	var supervisor Supervisor
 
-	supervisor, err = NewSinglePlayerSupervisor(supervisorName, bot, statsHandler)
+	supervisor, err = NewSinglePlayerSupervisor(supervisorName, bot, statsHandler, goalsHandler)
 
 	if err != nil {
 		return nil, nil, err
@@ -275,6 +342,20 @@ func (mng *SupervisorManager) buildSupervisor(supervisorName string, logger *slo
 
 	// This function will be used to restart the client - passed to the crashDetector
 	restartFunc := func() {
+		alert := config.Koolo.CrashAlert
+		if alert.Crashes > 0 && mng.crashCircuitOpen(supervisorName, alert.Crashes, alert.Window) {
+			msg := fmt.Sprintf("Client crashed %d times in %s", alert.Crashes, alert.Window)
+			if alert.StopSupervisor {
+				mng.logger.Error("Client crashed too many times, it won't be restarted", slog.String("supervisor", supervisorName))
+				mng.Stop(supervisorName)
+				event.Send(event.CrashCircuitOpen(event.Text(supervisorName, msg+", it won't be restarted"), alert.Crashes, alert.Window))
+				return
+			}
+
+			mng.logger.Warn("Client crashed too many times", slog.String("supervisor", supervisorName))
+			event.Send(event.CrashCircuitOpen(event.Text(supervisorName, msg), alert.Crashes, alert.Window))
+		}
+
 		mng.logger.Info("Restarting supervisor after crash", slog.String("supervisor", supervisorName))
 		mng.Stop(supervisorName)
 		time.Sleep(5 * time.Second) // Wait a bit before restarting
@@ -340,6 +421,44 @@ func (mng *SupervisorManager) buildSupervisor(supervisorName string, logger *slo
 	return supervisor, crashDetector, nil
 }
 
+// goalsHandler returns the goals handler of the supervisor. There is no way to unregister an event handler, so it's
+// only registered the first time the supervisor is started, the next starts reuse it with the current config.
+func (mng *SupervisorManager) goalsHandler(supervisorName string, cfg *config.CharacterCfg, logger *slog.Logger) *GoalsHandler {
+	mng.goalsHandlersMu.Lock()
+	defer mng.goalsHandlersMu.Unlock()
+
+	if h, found := mng.goalsHandlers[supervisorName]; found {
+		h.Reset(cfg, logger)
+		return h
+	}
+
+	h := NewGoalsHandler(supervisorName, cfg, logger)
+	mng.eventListener.Register(h.Handle)
+	mng.goalsHandlers[supervisorName] = h
+
+	return h
+}
+
+// crashCircuitOpen registers a crash of the supervisor client and returns true when it crashed the given amount of
+// times within the window. The crash history is cleared when the circuit opens, so the count begins from scratch.
+func (mng *SupervisorManager) crashCircuitOpen(supervisorName string, threshold int, window time.Duration) bool {
+	mng.crashesMu.Lock()
+	defer mng.crashesMu.Unlock()
+
+	now := time.Now()
+	crashes := slices.DeleteFunc(mng.crashes[supervisorName], func(t time.Time) bool {
+		return now.Sub(t) >= window
+	})
+	crashes = append(crashes, now)
+	if len(crashes) >= threshold {
+		delete(mng.crashes, supervisorName)
+		return true
+	}
+	mng.crashes[supervisorName] = crashes
+
+	return false
+}
+
 func (mng *SupervisorManager) GetSupervisorStats(supervisor string) Stats {
 	if mng.supervisors[supervisor] == nil {
 		return Stats{}
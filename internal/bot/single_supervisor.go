@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"log/slog"
 	"math/rand"
+	"slices"
 	"time"
 
 	"github.com/hectorgimenez/d2go/pkg/data/skill"
+	"github.com/hectorgimenez/d2go/pkg/data/stat"
 	"github.com/hectorgimenez/koolo/internal/config"
 	ct "github.com/hectorgimenez/koolo/internal/context"
 	"github.com/hectorgimenez/koolo/internal/event"
@@ -18,8 +20,15 @@ import (
 	"github.com/hectorgimenez/koolo/internal/utils"
 )
 
+// A death loop is deathLoopThreshold deaths within deathLoopWindow, the character is probably too weak for the runs
+const (
+	deathLoopThreshold = 3
+	deathLoopWindow    = time.Minute * 30
+)
+
 type SinglePlayerSupervisor struct {
 	*baseSupervisor
+	deaths []time.Time
 }
 
 func (s *SinglePlayerSupervisor) GetData() *game.Data {
@@ -30,8 +39,8 @@ func (s *SinglePlayerSupervisor) GetContext() *ct.Context {
 	return s.bot.ctx
 }
 
-func NewSinglePlayerSupervisor(name string, bot *Bot, statsHandler *StatsHandler) (*SinglePlayerSupervisor, error) {
-	bs, err := newBaseSupervisor(bot, name, statsHandler)
+func NewSinglePlayerSupervisor(name string, bot *Bot, statsHandler *StatsHandler, goalsHandler *GoalsHandler) (*SinglePlayerSupervisor, error) {
+	bs, err := newBaseSupervisor(bot, name, statsHandler, goalsHandler)
 	if err != nil {
 		return nil, err
 	}
@@ -41,6 +50,22 @@ func NewSinglePlayerSupervisor(name string, bot *Bot, statsHandler *StatsHandler
 	}, nil
 }
 
+// checkDeathLoop registers a death and sends a DeathLoopEvent when there are too many of them recently, the history
+// is cleared after that so it's not sent again for every following death
+func (s *SinglePlayerSupervisor) checkDeathLoop() {
+	now := time.Now()
+	s.deaths = slices.DeleteFunc(append(s.deaths, now), func(t time.Time) bool {
+		return now.Sub(t) >= deathLoopWindow
+	})
+	if len(s.deaths) < deathLoopThreshold {
+		return
+	}
+
+	s.deaths = nil
+	s.bot.ctx.Logger.Warn(fmt.Sprintf("Character died %d times in %s", deathLoopThreshold, deathLoopWindow))
+	event.Send(event.DeathLoop(event.Text(s.name, fmt.Sprintf("Character died %d times in %s", deathLoopThreshold, deathLoopWindow)), deathLoopThreshold, deathLoopWindow))
+}
+
 // Start will return error if it can not be started, otherwise will always return nil
 func (s *SinglePlayerSupervisor) Start() error {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -90,6 +115,8 @@ func (s *SinglePlayerSupervisor) Start() error {
 
 			// Refresh game data to make sure we have the latest information
 			s.bot.ctx.RefreshGameData()
+			s.statsHandler.UpdateGold(s.bot.ctx.Data.PlayerUnit.TotalPlayerGold())
+			sampleExperience(s.bot.ctx, s.statsHandler)
 
 			// Perform keybindings check on the first run only
 			if firstRun {
@@ -122,6 +149,9 @@ func (s *SinglePlayerSupervisor) Start() error {
 					gameFinishReason = event.FinishedError
 				}
 				event.Send(event.GameFinished(event.WithScreenshot(s.name, err.Error(), s.bot.ctx.GameReader.Screenshot()), gameFinishReason))
+				if gameFinishReason == event.FinishedDied {
+					s.checkDeathLoop()
+				}
 				s.bot.ctx.Logger.Warn(
 					fmt.Sprintf("Game finished with errors, reason: %s. Game total time: %0.2fs", err.Error(), time.Since(gameStart).Seconds()),
 					slog.String("supervisor", s.name),
@@ -132,11 +162,37 @@ func (s *SinglePlayerSupervisor) Start() error {
 				event.Send(event.GameFinished(event.Text(s.name, "Game finished successfully"), gameFinishReason))
 			}
 
+			// Level and gold goals are checked while we still have the character data
+			lvl, _ := s.bot.ctx.Data.PlayerUnit.FindStat(stat.Level, 0)
+			s.goalsHandler.CheckCharacter(lvl.Value, s.bot.ctx.Data.PlayerUnit.TotalPlayerGold())
+			s.statsHandler.UpdateGold(s.bot.ctx.Data.PlayerUnit.TotalPlayerGold())
+			sampleExperience(s.bot.ctx, s.statsHandler)
+
 			if exitErr := s.bot.ctx.Manager.ExitGame(); exitErr != nil {
 				errMsg := fmt.Sprintf("Error exiting game %s", exitErr.Error())
 				event.Send(event.GameFinished(event.WithScreenshot(s.name, errMsg, s.bot.ctx.GameReader.Screenshot()), event.FinishedError))
 				return errors.New(errMsg)
 			}
+
+			switch s.goalsHandler.TakePendingAction() {
+			case config.GoalActionStop:
+				s.bot.ctx.Logger.Info("Goal reached, stopping supervisor")
+				return ErrGoalReached
+			case config.GoalActionPause:
+				s.bot.ctx.Logger.Info("Goal reached, pausing supervisor")
+				if s.bot.ctx.ExecutionPriority != ct.PriorityPause {
+					s.TogglePause()
+				}
+				// Stay out of game until the supervisor is resumed
+				for s.bot.ctx.ExecutionPriority == ct.PriorityPause {
+					select {
+					case <-ctx.Done():
+						return nil
+					default:
+						utils.Sleep(500)
+					}
+				}
+			}
 		}
 	}
 }
@@ -2,12 +2,18 @@ package bot
 
 import (
 	"context"
+	"image"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hectorgimenez/d2go/pkg/data"
 	"github.com/hectorgimenez/koolo/internal/event"
+	"github.com/hectorgimenez/koolo/internal/utils"
 )
 
 const (
@@ -18,12 +24,20 @@ const (
 	Crashed    SupervisorStatus = "Crashed"
 )
 
+// maxTimelineScreenshots is the amount of error screenshots kept on disk by supervisor for the timeline, including the
+// ones of previous executions
+const maxTimelineScreenshots = 50
+
 type SupervisorStatus string
 
 type StatsHandler struct {
+	// mu protects stats, Handle runs in the event listener, the updates in the supervisor and the HTTP server reads them
+	mu     sync.Mutex
 	stats  *Stats
 	name   string
 	logger *slog.Logger
+	// screenshotsMu serializes the screenshot writes, they are done in the background
+	screenshotsMu sync.Mutex
 }
 
 func NewStatsHandler(name string, logger *slog.Logger) *StatsHandler {
@@ -43,6 +57,9 @@ func (h *StatsHandler) Handle(_ context.Context, e event.Event) error {
 		return nil
 	}
 
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	switch evt := e.(type) {
 	case event.GameCreatedEvent:
 		h.stats.Games = append(h.stats.Games, GameStats{
@@ -52,8 +69,13 @@ func (h *StatsHandler) Handle(_ context.Context, e event.Event) error {
 
 	case event.GameFinishedEvent:
 		if len(h.stats.Games) > 0 {
-			h.stats.Games[len(h.stats.Games)-1].FinishedAt = evt.OccurredAt()
-			h.stats.Games[len(h.stats.Games)-1].Reason = evt.Reason
+			lastGame := &h.stats.Games[len(h.stats.Games)-1]
+			lastGame.FinishedAt = evt.OccurredAt()
+			lastGame.Reason = evt.Reason
+			lastGame.Message = evt.Message()
+			if evt.Image() != nil {
+				h.saveScreenshot(lastGame, evt)
+			}
 		}
 
 	case event.RunStartedEvent:
@@ -69,6 +91,8 @@ func (h *StatsHandler) Handle(_ context.Context, e event.Event) error {
 			lastRun := &h.stats.Games[len(h.stats.Games)-1].Runs[len(h.stats.Games[len(h.stats.Games)-1].Runs)-1]
 			lastRun.FinishedAt = evt.OccurredAt()
 			lastRun.Reason = evt.Reason
+			lastRun.ExperienceGained = evt.ExperienceGained
+			lastRun.GoldGained = evt.GoldGained
 		}
 
 	case event.GamePausedEvent:
@@ -80,6 +104,24 @@ func (h *StatsHandler) Handle(_ context.Context, e event.Event) error {
 
 	case event.ItemStashedEvent:
 		h.stats.Drops = append(h.stats.Drops, evt.Item)
+		if len(h.stats.Games) > 0 && len(h.stats.Games[len(h.stats.Games)-1].Runs) > 0 {
+			lastRun := &h.stats.Games[len(h.stats.Games)-1].Runs[len(h.stats.Games[len(h.stats.Games)-1].Runs)-1]
+			lastRun.Stashed = append(lastRun.Stashed, StashedItem{Drop: evt.Item, StashedAt: evt.OccurredAt()})
+		}
+		// Items are usually stashed during a later run, even in the next game, they are credited to the run where they
+		// were picked up
+		if run := h.findRun(evt.RunName, evt.RunStartedAt); run != nil {
+			run.Items = append(run.Items, evt.Item.Item)
+		}
+
+	case event.ItemGambledEvent:
+		h.stats.Gambling.ItemsBought++
+		h.stats.Gambling.GoldSpent += evt.GoldSpent
+		h.stats.Gambling.GoldRecovered += evt.GoldRecovered
+		if evt.Kept {
+			h.stats.Gambling.ItemsKept++
+			h.stats.Gambling.GoldSpentOnKept += evt.GoldSpent
+		}
 
 	case event.UsedPotionEvent:
 		if len(h.stats.Games) > 0 && len(h.stats.Games[len(h.stats.Games)-1].Runs) > 0 {
@@ -91,8 +133,108 @@ func (h *StatsHandler) Handle(_ context.Context, e event.Event) error {
 	return nil
 }
 
+// findRun returns the run with the given name and start time from any game, nil if it's not found
+func (h *StatsHandler) findRun(name string, startedAt time.Time) *RunStats {
+	if name == "" {
+		return nil
+	}
+
+	for i := len(h.stats.Games) - 1; i >= 0; i-- {
+		runs := h.stats.Games[i].Runs
+		for j := len(runs) - 1; j >= 0; j-- {
+			if runs[j].Name == name && runs[j].StartedAt.Equal(startedAt) {
+				return &runs[j]
+			}
+		}
+	}
+
+	return nil
+}
+
+// saveScreenshot saves the screenshot of the game finished event to be shown in the timeline. It's encoded in the
+// background to not block the event listener, only the latest maxTimelineScreenshots are kept.
+func (h *StatsHandler) saveScreenshot(game *GameStats, evt event.GameFinishedEvent) {
+	dir := filepath.Join("screenshots", "timeline", h.name)
+	path := filepath.Join(dir, evt.OccurredAt().Format("2006-01-02 15_04_05.000")+".jpeg")
+	game.Screenshot = path
+
+	saved := 0
+	for i := len(h.stats.Games) - 1; i >= 0; i-- {
+		if h.stats.Games[i].Screenshot == "" {
+			continue
+		}
+		if saved++; saved > maxTimelineScreenshots {
+			h.stats.Games[i].Screenshot = ""
+		}
+	}
+
+	go h.writeScreenshot(dir, path, evt.Image())
+}
+
+func (h *StatsHandler) writeScreenshot(dir, path string, img image.Image) {
+	h.screenshotsMu.Lock()
+	defer h.screenshotsMu.Unlock()
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		h.logger.Warn("Error creating timeline screenshots directory", slog.Any("error", err))
+		return
+	}
+	if err := utils.SaveImageJPEG(img, path); err != nil {
+		h.logger.Warn("Error saving timeline screenshot", slog.Any("error", err))
+		return
+	}
+
+	if err := pruneScreenshots(dir, maxTimelineScreenshots); err != nil {
+		h.logger.Warn("Error removing old timeline screenshots", slog.Any("error", err))
+	}
+}
+
+// pruneScreenshots removes the oldest screenshots of the directory, keeping the given amount. The file names are the
+// time they were taken, so the name order is the time order.
+func pruneScreenshots(dir string, keep int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	entries = slices.DeleteFunc(entries, func(e os.DirEntry) bool {
+		return e.IsDir() || filepath.Ext(e.Name()) != ".jpeg"
+	})
+	for len(entries) > keep {
+		if err = os.Remove(filepath.Join(dir, entries[0].Name())); err != nil {
+			return err
+		}
+		entries = entries[1:]
+	}
+
+	return nil
+}
+
+// UpdateGold registers the current gold of the character (inventory and stash), the first value received is the
+// reference to calculate the gold earned
+func (h *StatsHandler) UpdateGold(gold int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.stats.GoldTracked {
+		h.stats.StartingGold = gold
+		h.stats.GoldTracked = true
+	}
+	h.stats.CurrentGold = gold
+}
+
+// Stats returns a copy of the stats, the games and runs are copied because they are updated in place
 func (h *StatsHandler) Stats() Stats {
-	return *h.stats
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := *h.stats
+	s.Games = slices.Clone(s.Games)
+	for i := range s.Games {
+		s.Games[i].Runs = slices.Clone(s.Games[i].Runs)
+	}
+
+	return s
 }
 
 type Stats struct {
@@ -101,6 +243,28 @@ type Stats struct {
 	Details          string
 	Drops            []data.Drop
 	Games            []GameStats
+	Gambling         GamblingStats
+	StartingGold     int
+	CurrentGold      int
+	GoldTracked      bool
+	// Experience is sampled at game and run boundaries, see UpdateExperience
+	Level               int
+	StartingExperience  int
+	CurrentExperience   int
+	ExperienceTracked   bool
+	ExperienceTrackedAt time.Time
+	ExperienceSampledAt time.Time
+	LevelUps            []LevelUpStats
+}
+
+type GamblingStats struct {
+	ItemsBought int
+	ItemsKept   int
+	GoldSpent   int
+	// GoldRecovered is the gold received selling back the items that were not kept
+	GoldRecovered int
+	// GoldSpentOnKept is the gold paid for the kept items, not their value
+	GoldSpentOnKept int
 }
 
 type GameStats struct {
@@ -108,15 +272,33 @@ type GameStats struct {
 	FinishedAt time.Time
 	Reason     event.FinishReason
 	Runs       []RunStats
+	// Message is the game finished message, it includes the error when the game didn't finish properly
+	Message string
+	// Screenshot is the path of the screenshot taken when the game finished with an error, empty if there is none
+	Screenshot string
 }
 
 type RunStats struct {
-	Name        string
-	Reason      event.FinishReason
-	StartedAt   time.Time
-	Items       []data.Item
-	FinishedAt  time.Time
-	UsedPotions []event.UsedPotionEvent
+	Name      string
+	Reason    event.FinishReason
+	StartedAt time.Time
+	// Items are the stashed items picked up during the run
+	Items            []data.Item
+	FinishedAt       time.Time
+	UsedPotions      []event.UsedPotionEvent
+	Stashed          []StashedItem
+	ExperienceGained int
+	GoldGained       int
+}
+
+type StashedItem struct {
+	Drop      data.Drop
+	StashedAt time.Time
+}
+
+// GoldEarned returns the gold earned since the supervisor was started, it's negative when more gold was spent
+func (s Stats) GoldEarned() int {
+	return s.CurrentGold - s.StartingGold
 }
 
 func (s Stats) TotalGames() int {
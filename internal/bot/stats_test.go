@@ -0,0 +1,136 @@
+package bot
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/event"
+)
+
+func newTestStatsHandler() *StatsHandler {
+	return NewStatsHandler("sorc", slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestItemStashedCreditsPickupRun(t *testing.T) {
+	h := newTestStatsHandler()
+	ctx := context.Background()
+
+	// The item is picked up during the last run of a game and stashed in the next game, in a run with the same name
+	h.Handle(ctx, event.GameCreated(event.Text("sorc", "Game created"), "koolo-1", ""))
+	pickupRun := event.RunStarted(event.Text("sorc", "Starting run"), "mephisto")
+	h.Handle(ctx, pickupRun)
+	h.Handle(ctx, event.RunFinished(event.Text("sorc", "Run finished"), "mephisto", event.FinishedOK, 0, 0))
+	h.Handle(ctx, event.GameFinished(event.Text("sorc", "Game finished"), event.FinishedOK))
+	h.Handle(ctx, event.GameCreated(event.Text("sorc", "Game created"), "koolo-2", ""))
+	h.Handle(ctx, event.RunStarted(event.Text("sorc", "Starting run"), "mephisto"))
+
+	shako := data.Drop{Item: data.Item{Name: "Shako"}}
+	h.Handle(ctx, event.ItemStashed(event.Text("sorc", "Item stashed"), shako, false, "mephisto", pickupRun.OccurredAt(), 0))
+	// Unknown runs are not credited, the item is still a drop
+	ring := data.Drop{Item: data.Item{Name: "Ring"}}
+	h.Handle(ctx, event.ItemStashed(event.Text("sorc", "Item stashed"), ring, false, "mephisto", time.Now().Add(-time.Hour), 0))
+
+	stats := h.Stats()
+	if len(stats.Drops) != 2 {
+		t.Errorf("expected 2 drops, got %d", len(stats.Drops))
+	}
+	if items := stats.Games[0].Runs[0].Items; len(items) != 1 || items[0].Name != "Shako" {
+		t.Errorf("expected the item credited to the pickup run, got %v", items)
+	}
+	if items := stats.Games[1].Runs[0].Items; len(items) != 0 {
+		t.Errorf("expected nothing credited to the run of the next game, got %v", items)
+	}
+	// The item is stashed during the run of the next game
+	if stashed := stats.Games[1].Runs[0].Stashed; len(stashed) != 2 {
+		t.Errorf("expected 2 items stashed during the current run, got %d", len(stashed))
+	}
+}
+
+func TestPruneScreenshots(t *testing.T) {
+	dir := t.TempDir()
+	// Screenshots of a previous execution and the current one, and a file that is not a screenshot
+	files := []string{
+		"2024-05-01 10_00_00.000.jpeg",
+		"2024-05-01 11_00_00.000.jpeg",
+		"2024-05-02 09_00_00.000.jpeg",
+		"2024-05-02 09_30_00.000.jpeg",
+		"notes.txt",
+	}
+	for _, name := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+
+	if err := pruneScreenshots(dir, 2); err != nil {
+		t.Fatalf("pruneScreenshots: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("reading directory: %v", err)
+	}
+	var kept []string
+	for _, e := range entries {
+		kept = append(kept, e.Name())
+	}
+	if expected := []string{"2024-05-02 09_00_00.000.jpeg", "2024-05-02 09_30_00.000.jpeg", "notes.txt"}; !slices.Equal(kept, expected) {
+		t.Errorf("expected %v, got %v", expected, kept)
+	}
+}
+
+func TestStatsConcurrentAccess(t *testing.T) {
+	h := newTestStatsHandler()
+	ctx := context.Background()
+	done := make(chan struct{})
+
+	// The listener, the supervisor and the HTTP server use the handler at the same time, go test -race checks it
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			h.Handle(ctx, event.GameCreated(event.Text("sorc", "Game created"), "koolo", ""))
+			h.Handle(ctx, event.RunStarted(event.Text("sorc", "Starting run"), "mephisto"))
+			h.Handle(ctx, event.RunFinished(event.Text("sorc", "Run finished"), "mephisto", event.FinishedOK, 10, 10))
+		}
+	}()
+	go func() {
+		for i := 0; i < 100; i++ {
+			h.UpdateGold(i)
+			h.UpdateExperience(10, experienceByLevel[10]+i, time.Now())
+		}
+	}()
+
+	for {
+		stats := h.Stats()
+		for _, g := range stats.Games {
+			for _, r := range g.Runs {
+				_ = r.FinishedAt
+			}
+		}
+		select {
+		case <-done:
+			return
+		default:
+		}
+	}
+}
+
+func TestItemGambled(t *testing.T) {
+	h := newTestStatsHandler()
+	ctx := context.Background()
+
+	h.Handle(ctx, event.ItemGambled(event.Text("sorc", "Gambled"), data.Item{Name: "Circlet"}, 50000, 0, true))
+	h.Handle(ctx, event.ItemGambled(event.Text("sorc", "Gambled"), data.Item{Name: "Ring"}, 30000, 2000, false))
+
+	expected := GamblingStats{ItemsBought: 2, ItemsKept: 1, GoldSpent: 80000, GoldRecovered: 2000, GoldSpentOnKept: 50000}
+	if gambling := h.Stats().Gambling; gambling != expected {
+		t.Errorf("expected %+v, got %+v", expected, gambling)
+	}
+}
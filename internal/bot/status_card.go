@@ -0,0 +1,118 @@
+package bot
+
+import (
+	"errors"
+	"image"
+	"time"
+
+	"github.com/hectorgimenez/koolo/internal/context"
+)
+
+// ErrSupervisorNotRunning is returned when the requested information needs a running supervisor
+var ErrSupervisorNotRunning = errors.New("supervisor is not running")
+
+// StatusCard is a snapshot of what the supervisor is doing right now, it's meant to find out remotely why a bot looks
+// stuck. The game fields are only set when the supervisor is in game.
+type StatusCard struct {
+	Supervisor    string           `json:"supervisor"`
+	Status        SupervisorStatus `json:"status"`
+	InGame        bool             `json:"inGame"`
+	Area          string           `json:"area,omitempty"`
+	HPPercent     int              `json:"hpPercent"`
+	MPPercent     int              `json:"mpPercent"`
+	HasMerc       bool             `json:"hasMerc"`
+	MercHPPercent int              `json:"mercHpPercent"`
+	Gold          int              `json:"gold"`
+	Run           string           `json:"run,omitempty"`
+	RunStartedAt  time.Time        `json:"runStartedAt,omitempty"`
+	GameStartedAt time.Time        `json:"gameStartedAt,omitempty"`
+	LastAction    string           `json:"lastAction,omitempty"`
+	LastStep      string           `json:"lastStep,omitempty"`
+}
+
+// GameTime returns the time spent in the current game, zero when not in game
+func (c StatusCard) GameTime() time.Duration {
+	if c.GameStartedAt.IsZero() {
+		return 0
+	}
+
+	return time.Since(c.GameStartedAt).Round(time.Second)
+}
+
+// RunTime returns the time spent in the current run, zero when there is no run in progress
+func (c StatusCard) RunTime() time.Duration {
+	if c.RunStartedAt.IsZero() {
+		return 0
+	}
+
+	return time.Since(c.RunStartedAt).Round(time.Second)
+}
+
+// StatusCard builds the status card of the given supervisor from its context and stats
+func (mng *SupervisorManager) StatusCard(supervisor string) (StatusCard, error) {
+	ctx := mng.GetContext(supervisor)
+	if ctx == nil {
+		return StatusCard{}, ErrSupervisorNotRunning
+	}
+
+	stats := mng.Status(supervisor)
+	card := StatusCard{
+		Supervisor: supervisor,
+		Status:     stats.SupervisorStatus,
+		InGame:     stats.SupervisorStatus == InGame || stats.SupervisorStatus == Paused,
+	}
+
+	// Data and debug info are written by the bot goroutines, we need a snapshot
+	d, debugByPriority := ctx.Snapshot()
+
+	// The runs are executed with normal priority, the other ones are short actions like drinking potions
+	if debug, found := debugByPriority[context.PriorityNormal]; found {
+		card.LastAction = debug.LastAction
+		card.LastStep = debug.LastStep
+	}
+
+	if !card.InGame || len(stats.Games) == 0 {
+		return card, nil
+	}
+
+	currentGame := stats.Games[len(stats.Games)-1]
+	if currentGame.FinishedAt.IsZero() {
+		card.GameStartedAt = currentGame.StartedAt
+		if len(currentGame.Runs) > 0 {
+			lastRun := currentGame.Runs[len(currentGame.Runs)-1]
+			if lastRun.FinishedAt.IsZero() {
+				card.Run = lastRun.Name
+				card.RunStartedAt = lastRun.StartedAt
+			}
+		}
+	}
+
+	card.Area = d.PlayerUnit.Area.Area().Name
+	card.HPPercent = d.PlayerUnit.HPPercent()
+	card.MPPercent = d.PlayerUnit.MPPercent()
+	card.Gold = d.PlayerUnit.TotalPlayerGold()
+	for _, m := range d.Monsters {
+		if m.IsMerc() {
+			card.HasMerc = true
+			card.MercHPPercent = d.MercHPPercent()
+			break
+		}
+	}
+
+	return card, nil
+}
+
+// Screenshot captures the current game window of the given supervisor
+func (mng *SupervisorManager) Screenshot(supervisor string) (image.Image, error) {
+	ctx := mng.GetContext(supervisor)
+	if ctx == nil || ctx.GameReader == nil {
+		return nil, ErrSupervisorNotRunning
+	}
+
+	// The window size is not known until the game window has been found
+	if ctx.GameReader.GameAreaSizeX == 0 || ctx.GameReader.GameAreaSizeY == 0 {
+		return nil, errors.New("game window is not available yet")
+	}
+
+	return ctx.GameReader.Screenshot(), nil
+}
@@ -25,12 +25,14 @@ type Supervisor interface {
 	SetWindowPosition(x, y int)
 	GetData() *game.Data
 	GetContext() *ct.Context
+	GoalsProgress() []GoalProgress
 }
 
 type baseSupervisor struct {
 	bot          *Bot
 	name         string
 	statsHandler *StatsHandler
+	goalsHandler *GoalsHandler
 	cancelFn     context.CancelFunc
 }
 
@@ -38,11 +40,13 @@ func newBaseSupervisor(
 	bot *Bot,
 	name string,
 	statsHandler *StatsHandler,
+	goalsHandler *GoalsHandler,
 ) (*baseSupervisor, error) {
 	return &baseSupervisor{
 		bot:          bot,
 		name:         name,
 		statsHandler: statsHandler,
+		goalsHandler: goalsHandler,
 	}, nil
 }
 
@@ -54,6 +58,10 @@ func (s *baseSupervisor) Stats() Stats {
 	return s.statsHandler.Stats()
 }
 
+func (s *baseSupervisor) GoalsProgress() []GoalProgress {
+	return s.goalsHandler.Progress()
+}
+
 func (s *baseSupervisor) TogglePause() {
 	if s.bot.ctx.ExecutionPriority == ct.PriorityPause {
 		s.bot.ctx.MemoryInjector.Load()
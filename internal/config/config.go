@@ -42,7 +42,21 @@ type KooloCfg struct {
 	D2LoDPath             string `yaml:"D2LoDPath"`
 	D2RPath               string `yaml:"D2RPath"`
 	CentralizedPickitPath string `yaml:"centralizedPickitPath"`
-	Discord               struct {
+	MapData               struct {
+		CacheEnabled    bool   `yaml:"cacheEnabled"`
+		CacheDir        string `yaml:"cacheDir"`
+		CacheMaxEntries int    `yaml:"cacheMaxEntries"`
+		CacheMaxSizeMB  int    `yaml:"cacheMaxSizeMB"`
+		FixturesDir     string `yaml:"fixturesDir"`
+	} `yaml:"mapData"`
+	// CrashAlert sends a crash_circuit_open event when the client crashes Crashes times within Window, 0 disables it.
+	// The client is still restarted unless StopSupervisor is set.
+	CrashAlert struct {
+		Crashes        int           `yaml:"crashes"`
+		Window         time.Duration `yaml:"window"`
+		StopSupervisor bool          `yaml:"stopSupervisor"`
+	} `yaml:"crashAlert"`
+	Discord struct {
 		Enabled                      bool     `yaml:"enabled"`
 		EnableGameCreatedMessages    bool     `yaml:"enableGameCreatedMessages"`
 		EnableNewRunMessages         bool     `yaml:"enableNewRunMessages"`
@@ -53,13 +67,76 @@ type KooloCfg struct {
 		ChannelID                    string   `yaml:"channelId"`
 		Token                        string   `yaml:"token"`
 	} `yaml:"discord"`
-	Telegram struct {
-		Enabled bool   `yaml:"enabled"`
-		ChatID  int64  `yaml:"chatId"`
-		Token   string `yaml:"token"`
+	Webhook struct {
+		Enabled           bool     `yaml:"enabled"`
+		URLs              []string `yaml:"urls"`
+		Events            []string `yaml:"events"`
+		Secret            string   `yaml:"secret"`
+		IncludeScreenshot bool     `yaml:"includeScreenshot"`
+		MaxRetries        int      `yaml:"maxRetries"`
+		OutboxPath        string   `yaml:"outboxPath"`
+	} `yaml:"webhook"`
+	Email         EmailCfg         `yaml:"email"`
+	Notifications NotificationsCfg `yaml:"notifications"`
+	Telegram      struct {
+		Enabled   bool    `yaml:"enabled"`
+		ChatID    int64   `yaml:"chatId"`
+		Token     string  `yaml:"token"`
+		BotAdmins []int64 `yaml:"botAdmins"`
 	}
 }
 
+// EmailCfg is the SMTP notifier, it sends the critical events right away and a daily summary
+type EmailCfg struct {
+	Enabled bool   `yaml:"enabled"`
+	Host    string `yaml:"host"`
+	Port    int    `yaml:"port"`
+	// Security is starttls, tls or none
+	Security    string   `yaml:"security"`
+	Username    string   `yaml:"username"`
+	Password    string   `yaml:"password"`
+	From        string   `yaml:"from"`
+	To          []string `yaml:"to"`
+	Events      []string `yaml:"events"`
+	SummaryTime string   `yaml:"summaryTime"`
+}
+
+type NotificationsCfg struct {
+	// Rules are checked in order for every notifier, the first matching rule is applied. Events not matching any rule
+	// are handled by the notifier settings.
+	Rules      []NotificationRule `yaml:"rules"`
+	QuietHours struct {
+		Enabled bool `yaml:"enabled"`
+		// Start and End are "HH:MM" in local time, the range can go through midnight (23:00 - 07:00)
+		Start string `yaml:"start"`
+		End   string `yaml:"end"`
+	} `yaml:"quietHours"`
+}
+
+type NotificationRule struct {
+	Name string `yaml:"name"`
+	// Notifiers are the notifiers the rule applies to: discord, telegram, webhook. Empty means all of them
+	Notifiers []string `yaml:"notifiers"`
+	// Match conditions, empty conditions match everything
+	Events      []string `yaml:"events"`
+	Supervisors []string `yaml:"supervisors"`
+	Reasons     []string `yaml:"reasons"`
+	MinQuality  string   `yaml:"minQuality"`
+	// MinValue is the min gold a vendor pays for the item, learned when the bot sells the same item and quality. Items
+	// never sold don't match.
+	MinValue int      `yaml:"minValue"`
+	Items    []string `yaml:"items"`
+	// Target is the Discord channel ID, Telegram chat ID or webhook URL, empty uses the notifier one
+	Target   string `yaml:"target"`
+	Mute     bool   `yaml:"mute"`
+	Critical bool   `yaml:"critical"`
+	// RateLimit is the max amount of events sent every RatePeriod, the rest are dropped
+	RateLimit  int           `yaml:"rateLimit"`
+	RatePeriod time.Duration `yaml:"ratePeriod"`
+	// Aggregate collects the events during this time and sends a single summary message
+	Aggregate time.Duration `yaml:"aggregate"`
+}
+
 type Day struct {
 	DayOfWeek  int         `yaml:"dayOfWeek"`
 	TimeRanges []TimeRange `yaml:"timeRange"`
@@ -237,9 +314,30 @@ type CharacterCfg struct {
 		GamePassword     string `yaml:"gamePassword"`
 	} `yaml:"companion"`
 	Gambling struct {
-		Enabled bool        `yaml:"enabled"`
-		Items   []item.Name `yaml:"items"`
+		Enabled       bool              `yaml:"enabled"`
+		Items         []item.Name       `yaml:"items"`
+		StartGold     int               `yaml:"startGold"`
+		StopGold      int               `yaml:"stopGold"`
+		SessionBudget int               `yaml:"sessionBudget"`
+		ItemLimits    map[item.Name]int `yaml:"itemLimits"`
 	} `yaml:"gambling"`
+	Charms struct {
+		Enabled bool        `yaml:"enabled"`
+		Rules   []CharmRule `yaml:"rules"`
+	} `yaml:"charms"`
+	Goals   []Goal `yaml:"goals"`
+	Selling struct {
+		DryRun                    bool     `yaml:"dryRun"`
+		MinSellValue              int      `yaml:"minSellValue"`
+		KeepUnidentifiedQualities []string `yaml:"keepUnidentifiedQualities"`
+	} `yaml:"selling"`
+	Shopping struct {
+		Vendors        []string `yaml:"vendors"`
+		GoldBudget     int      `yaml:"goldBudget"`
+		MinGoldToKeep  int      `yaml:"minGoldToKeep"`
+		RefreshPasses  int      `yaml:"refreshPasses"`
+		LogVendorItems bool     `yaml:"logVendorItems"`
+	} `yaml:"shopping"`
 	CubeRecipes struct {
 		Enabled              bool     `yaml:"enabled"`
 		EnabledRecipes       []string `yaml:"enabledRecipes"`
@@ -252,12 +350,72 @@ type CharacterCfg struct {
 		MercDied        bool `yaml:"mercDied"`
 		EquipmentBroken bool `yaml:"equipmentBroken"`
 	} `yaml:"backtotown"`
+	Pathing struct {
+		Engine PathingEngine `yaml:"engine"`
+		Danger DangerCfg     `yaml:"danger"`
+	} `yaml:"pathing"`
 	Runtime struct {
-		Rules nip.Rules   `yaml:"-"`
-		Drops []data.Item `yaml:"-"`
+		Rules         nip.Rules           `yaml:"-"`
+		GamblingRules nip.Rules           `yaml:"-"`
+		ShoppingRules nip.Rules           `yaml:"-"`
+		SellKeepRules nip.Rules           `yaml:"-"`
+		SellRules     nip.Rules           `yaml:"-"`
+		CharmRules    []ScoredRule        `yaml:"-"`
+		GoalRules     map[string]nip.Rule `yaml:"-"`
+		Drops         []data.Item         `yaml:"-"`
 	} `yaml:"-"`
 }
 
+const (
+	PathingEngineAstar        PathingEngine = "astar"
+	PathingEngineHierarchical PathingEngine = "hierarchical"
+)
+
+type PathingEngine string
+
+// DangerCfg defines the extra path cost added around monsters, weights are the cost added to the tiles next to the
+// monster and they decrease with the distance until Radius. Ranged weight is added on top of the monster type weight.
+type DangerCfg struct {
+	Enabled  bool `yaml:"enabled"`
+	Radius   int  `yaml:"radius"`
+	Normal   int  `yaml:"normal"`
+	Champion int  `yaml:"champion"`
+	Unique   int  `yaml:"unique"`
+	Boss     int  `yaml:"boss"`
+	Ranged   int  `yaml:"ranged"`
+}
+
+type CharmRule struct {
+	Rule  string `yaml:"rule"`
+	Score int    `yaml:"score"`
+}
+
+// ScoredRule is a NIP rule with a score, used to rank items matching different rules
+type ScoredRule struct {
+	nip.Rule
+	Score int
+}
+
+const (
+	GoalActionNone  GoalAction = ""
+	GoalActionPause GoalAction = "pause"
+	GoalActionStop  GoalAction = "stop"
+)
+
+type GoalAction string
+
+// Goal is a farming target, only one of Item, Level, Gold or Runs is expected to be set
+type Goal struct {
+	Name     string     `yaml:"name"`
+	Item     string     `yaml:"item"`
+	Quantity int        `yaml:"quantity"`
+	Level    int        `yaml:"level"`
+	Gold     int        `yaml:"gold"`
+	Runs     int        `yaml:"runs"`
+	RunName  string     `yaml:"runName"`
+	Action   GoalAction `yaml:"action"`
+}
+
 type BeltColumns [4]string
 
 func (bm BeltColumns) Total(potionType data.PotionType) int {
@@ -370,6 +528,48 @@ func Load() error {
 		}
 
 		charCfg.Runtime.Rules = rules
+
+		// Gambling rules are optional, if the directory doesn't exist we will fall back to the pickit rules
+		gamblingPickitPath := getAbsPath(filepath.Join("config", entry.Name(), "gambling")) + "\\"
+		gamblingRules, err := readOptionalRules(gamblingPickitPath)
+		if err != nil {
+			return fmt.Errorf("error reading gambling directory %s: %w", gamblingPickitPath, err)
+		}
+		charCfg.Runtime.GamblingRules = gamblingRules
+
+		shoppingPickitPath := getAbsPath(filepath.Join("config", entry.Name(), "shopping")) + "\\"
+		shoppingRules, err := readOptionalRules(shoppingPickitPath)
+		if err != nil {
+			return fmt.Errorf("error reading shopping directory %s: %w", shoppingPickitPath, err)
+		}
+		charCfg.Runtime.ShoppingRules = shoppingRules
+
+		// Selling rules, items matching the keep rules will never be sold and items matching the sell rules will always be sold
+		sellKeepPath := getAbsPath(filepath.Join("config", entry.Name(), "selling", "keep")) + "\\"
+		sellKeepRules, err := readOptionalRules(sellKeepPath)
+		if err != nil {
+			return fmt.Errorf("error reading selling keep directory %s: %w", sellKeepPath, err)
+		}
+		charCfg.Runtime.SellKeepRules = sellKeepRules
+
+		sellPath := getAbsPath(filepath.Join("config", entry.Name(), "selling", "sell")) + "\\"
+		sellRules, err := readOptionalRules(sellPath)
+		if err != nil {
+			return fmt.Errorf("error reading selling sell directory %s: %w", sellPath, err)
+		}
+		charCfg.Runtime.SellRules = sellRules
+
+		charmRules, err := parseCharmRules(charCfg.Charms.Rules, charConfigPath)
+		if err != nil {
+			return fmt.Errorf("error reading charm rules from %s: %w", charConfigPath, err)
+		}
+		charCfg.Runtime.CharmRules = charmRules
+
+		goalRules, err := parseGoalRules(charCfg.Goals, charConfigPath)
+		if err != nil {
+			return fmt.Errorf("error reading goals from %s: %w", charConfigPath, err)
+		}
+		charCfg.Runtime.GoalRules = goalRules
 		Characters[entry.Name()] = &charCfg
 	}
 
@@ -381,6 +581,57 @@ func Load() error {
 	return nil
 }
 
+// readOptionalRules loads the NIP rules from the given directory, returning no rules if the directory doesn't exist
+func readOptionalRules(path string) (nip.Rules, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return nip.ReadDir(path)
+}
+
+func parseCharmRules(charmRules []CharmRule, filename string) ([]ScoredRule, error) {
+	rules := make([]ScoredRule, 0, len(charmRules))
+	for idx, cr := range charmRules {
+		rule, err := nip.NewRule(cr.Rule, filename, idx+1)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing charm rule %q: %w", cr.Rule, err)
+		}
+		rules = append(rules, ScoredRule{Rule: rule, Score: cr.Score})
+	}
+
+	return rules, nil
+}
+
+// parseGoalRules parses the NIP expression of the item goals, indexed by goal name
+func parseGoalRules(goals []Goal, filename string) (map[string]nip.Rule, error) {
+	rules := make(map[string]nip.Rule)
+	names := make(map[string]bool)
+	for idx, g := range goals {
+		if g.Name == "" {
+			return nil, fmt.Errorf("goal #%d has no name", idx+1)
+		}
+		if names[g.Name] {
+			return nil, fmt.Errorf("duplicated goal name %q", g.Name)
+		}
+		names[g.Name] = true
+		if g.Action != GoalActionNone && g.Action != GoalActionPause && g.Action != GoalActionStop {
+			return nil, fmt.Errorf("invalid action %q for goal %q", g.Action, g.Name)
+		}
+		if g.Item == "" {
+			continue
+		}
+
+		rule, err := nip.NewRule(g.Item, filename, idx+1)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing item of goal %q: %w", g.Name, err)
+		}
+		rules[g.Name] = rule
+	}
+
+	return rules, nil
+}
+
 func CreateFromTemplate(name string) error {
 	if name == "" {
 		return errors.New("name cannot be empty")
@@ -442,6 +693,27 @@ func SaveSupervisorConfig(supervisorName string, config *CharacterCfg) error {
 }
 
 func (c *CharacterCfg) Validate() {
+	if c.Gambling.StartGold <= 0 {
+		c.Gambling.StartGold = 2480000
+	}
+	if c.Gambling.StopGold <= 0 {
+		c.Gambling.StopGold = 500000
+	}
+	if c.Shopping.RefreshPasses <= 0 {
+		c.Shopping.RefreshPasses = 1
+	}
+	if c.Pathing.Engine != PathingEngineHierarchical {
+		c.Pathing.Engine = PathingEngineAstar
+	}
+	if c.Pathing.Danger.Radius <= 0 {
+		c.Pathing.Danger.Radius = 6
+	}
+	for i := range c.Goals {
+		if c.Goals[i].Item != "" && c.Goals[i].Quantity <= 0 {
+			c.Goals[i].Quantity = 1
+		}
+	}
+
 	if c.Character.Class == "nova" || c.Character.Class == "lightsorc" {
 		minThreshold := 65 // Default
 		switch c.Game.Difficulty {
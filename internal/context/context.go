@@ -10,6 +10,8 @@ import (
 
 	"github.com/hectorgimenez/d2go/pkg/data"
 	"github.com/hectorgimenez/d2go/pkg/data/area"
+	"github.com/hectorgimenez/d2go/pkg/data/item"
+	"github.com/hectorgimenez/d2go/pkg/nip"
 	"github.com/hectorgimenez/koolo/internal/config"
 	"github.com/hectorgimenez/koolo/internal/event"
 	"github.com/hectorgimenez/koolo/internal/game"
@@ -17,6 +19,9 @@ import (
 	"github.com/hectorgimenez/koolo/internal/pather"
 )
 
+// pickupMaxAge is how long the picked up items are remembered when they are not stashed
+const pickupMaxAge = time.Hour * 6
+
 var mu sync.Mutex
 var botContexts = make(map[uint64]*Status)
 
@@ -53,6 +58,18 @@ type Context struct {
 	LastBuffAt        time.Time
 	ContextDebug      map[Priority]*Debug
 	CurrentGame       *CurrentGameHelper
+	Gambling          *GamblingSession
+	PurchasePrices    map[string]int
+	// SellValues are the learned sell values by item, loaded from disk the first time they are needed
+	SellValues map[string]SellValue
+	// Pickups are the items picked up and not stashed yet, they are kept between games because the items picked up
+	// during the last run are stashed in the next game
+	Pickups map[data.UnitID]Pickup
+
+	// snapshot is a copy of Data and ContextDebug taken on the bot side, to be read from other goroutines
+	snapshotMu    sync.Mutex
+	snapshotData  game.Data
+	snapshotDebug map[Priority]Debug
 }
 
 type Debug struct {
@@ -60,10 +77,38 @@ type Debug struct {
 	LastStep   string `json:"lastStep"`
 }
 
+// GamblingSession keeps track of the gold spent gambling since the supervisor was started
+type GamblingSession struct {
+	GoldSpent    int
+	SpentPerItem map[item.Name]int
+	// PricePerItem is the highest price paid for every item, vendor prices can't be read before buying
+	PricePerItem map[item.Name]int
+}
+
+// SellValue is the gold received the last time an item was sold
+type SellValue struct {
+	Gold   int       `json:"gold"`
+	SoldAt time.Time `json:"soldAt"`
+}
+
+// Pickup is where an item was picked up
+type Pickup struct {
+	Run string
+	// RunStartedAt identifies the run together with its name
+	RunStartedAt time.Time
+	Area         area.ID
+	PickedUpAt   time.Time
+}
+
 type CurrentGameHelper struct {
 	BlacklistedItems []data.Item
 	PickedUpItems    map[int]int
-	AreaCorrection   struct {
+	CurrentRun       string
+	// CurrentRunStartedAt is the time of the RunStartedEvent of the current run
+	CurrentRunStartedAt time.Time
+	GambledItems        map[data.UnitID]nip.Rule
+	PurchasedItems      map[data.UnitID]nip.Rule
+	AreaCorrection      struct {
 		Enabled      bool
 		ExpectedArea area.ID
 	}
@@ -83,6 +128,12 @@ func NewContext(name string) *Status {
 			PriorityStop:       {},
 		},
 		CurrentGame: NewGameHelper(),
+		Gambling: &GamblingSession{
+			SpentPerItem: make(map[item.Name]int),
+			PricePerItem: make(map[item.Name]int),
+		},
+		PurchasePrices: make(map[string]int),
+		Pickups:        make(map[data.UnitID]Pickup),
 	}
 	botContexts[getGoroutineID()] = &Status{Priority: PriorityNormal, Context: ctx}
 
@@ -93,10 +144,23 @@ func NewGameHelper() *CurrentGameHelper {
 	return &CurrentGameHelper{
 		PickupItems:      true,
 		PickedUpItems:    make(map[int]int),
+		GambledItems:     make(map[data.UnitID]nip.Rule),
+		PurchasedItems:   make(map[data.UnitID]nip.Rule),
 		BlacklistedItems: []data.Item{},
 	}
 }
 
+// KeptVendorItemRule returns the rule that made us keep an item gambled or purchased from a vendor during the current game
+func (h *CurrentGameHelper) KeptVendorItemRule(unitID data.UnitID) (nip.Rule, bool) {
+	if rule, found := h.GambledItems[unitID]; found {
+		return rule, true
+	}
+
+	rule, found := h.PurchasedItems[unitID]
+
+	return rule, found
+}
+
 func Get() *Status {
 	mu.Lock()
 	defer mu.Unlock()
@@ -105,10 +169,36 @@ func Get() *Status {
 
 func (s *Status) SetLastAction(actionName string) {
 	s.Context.ContextDebug[s.Priority].LastAction = actionName
+	s.snapshotDebugEntry(s.Priority)
 }
 
 func (s *Status) SetLastStep(stepName string) {
 	s.Context.ContextDebug[s.Priority].LastStep = stepName
+	s.snapshotDebugEntry(s.Priority)
+}
+
+func (ctx *Context) snapshotDebugEntry(priority Priority) {
+	ctx.snapshotMu.Lock()
+	defer ctx.snapshotMu.Unlock()
+
+	if ctx.snapshotDebug == nil {
+		ctx.snapshotDebug = make(map[Priority]Debug)
+	}
+	ctx.snapshotDebug[priority] = *ctx.ContextDebug[priority]
+}
+
+// Snapshot returns a copy of the game data and the debug info of every priority, taken the last time they changed.
+// Unlike Data and ContextDebug, it's safe to call it from any goroutine.
+func (ctx *Context) Snapshot() (game.Data, map[Priority]Debug) {
+	ctx.snapshotMu.Lock()
+	defer ctx.snapshotMu.Unlock()
+
+	debug := make(map[Priority]Debug, len(ctx.snapshotDebug))
+	for p, d := range ctx.snapshotDebug {
+		debug[p] = d
+	}
+
+	return ctx.snapshotData, debug
 }
 
 func getGoroutineID() uint64 {
@@ -123,6 +213,11 @@ func getGoroutineID() uint64 {
 
 func (ctx *Context) RefreshGameData() {
 	*ctx.Data = ctx.GameReader.GetData()
+
+	// GetData returns new slices and maps every time, a shallow copy is enough
+	ctx.snapshotMu.Lock()
+	ctx.snapshotData = *ctx.Data
+	ctx.snapshotMu.Unlock()
 }
 
 func (ctx *Context) Detach() {
@@ -183,4 +278,11 @@ func (ctx *Context) Cleanup() {
 		ctx.Logger.Debug("Resetting picked up items map due to exceeding 200 items")
 		ctx.CurrentGame.PickedUpItems = make(map[int]int)
 	}
+
+	// Items picked up and never stashed, they were sold or dropped
+	for unitID, pickup := range ctx.Pickups {
+		if time.Since(pickup.PickedUpAt) > pickupMaxAge {
+			delete(ctx.Pickups, unitID)
+		}
+	}
 }
@@ -54,3 +54,54 @@ func Text(supervisor string, message string) BaseEvent {
 		supervisor: supervisor,
 	}
 }
+
+// Type returns the name of the event type, used by the notifiers to filter and route events. Events without any
+// specific data (Text, WithScreenshot) are "message".
+func Type(e Event) string {
+	switch e.(type) {
+	case UsedPotionEvent:
+		return "used_potion"
+	case GameCreatedEvent:
+		return "game_created"
+	case GameFinishedEvent:
+		return "game_finished"
+	case RunStartedEvent:
+		return "run_started"
+	case RunFinishedEvent:
+		return "run_finished"
+	case ItemStashedEvent:
+		return "item_stashed"
+	case ItemGambledEvent:
+		return "item_gambled"
+	case ItemBlackListedEvent:
+		return "item_blacklisted"
+	case CompanionLeaderAttackEvent:
+		return "companion_leader_attack"
+	case CompanionRequestedTPEvent:
+		return "companion_requested_tp"
+	case InteractedToEvent:
+		return "interacted_to"
+	case GamePausedEvent:
+		return "game_paused"
+	case CharmSwappedEvent:
+		return "charm_swapped"
+	case GoalReachedEvent:
+		return "goal_reached"
+	case StuckEvent:
+		return "stuck"
+	case GoalItemStashedEvent:
+		return "goal_item_stashed"
+	case GoalItemFoundEvent:
+		return "goal_item_found"
+	case StashFullEvent:
+		return "stash_full"
+	case DeathLoopEvent:
+		return "death_loop"
+	case CrashCircuitOpenEvent:
+		return "crash_circuit_open"
+	case LevelUpEvent:
+		return "level_up"
+	}
+
+	return "message"
+}
@@ -1,7 +1,11 @@
 package event
 
 import (
+	"time"
+
 	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/area"
+	"github.com/hectorgimenez/koolo/internal/config"
 )
 
 const (
@@ -60,25 +64,76 @@ type RunFinishedEvent struct {
 	BaseEvent
 	RunName string
 	Reason  FinishReason
+	// ExperienceGained and GoldGained are the difference between the start and the end of the run, gold is negative
+	// when more gold was spent (e.g. repairing) or lost by dying
+	ExperienceGained int
+	GoldGained       int
 }
 
-func RunFinished(be BaseEvent, runName string, reason FinishReason) RunFinishedEvent {
+func RunFinished(be BaseEvent, runName string, reason FinishReason, experienceGained, goldGained int) RunFinishedEvent {
 	return RunFinishedEvent{
-		BaseEvent: be,
-		RunName:   runName,
-		Reason:    reason,
+		BaseEvent:        be,
+		RunName:          runName,
+		Reason:           reason,
+		ExperienceGained: experienceGained,
+		GoldGained:       goldGained,
 	}
 }
 
 type ItemStashedEvent struct {
 	BaseEvent
-	Item data.Drop
+	Item    data.Drop
+	Gambled bool
+	// RunName and Area are where the item was picked up, empty when it wasn't picked up from the ground. The run is
+	// identified by RunStartedAt, the time of its RunStartedEvent, because it can be from a previous game.
+	RunName      string
+	RunStartedAt time.Time
+	Area         area.ID
 }
 
-func ItemStashed(be BaseEvent, drop data.Drop) ItemStashedEvent {
+func ItemStashed(be BaseEvent, drop data.Drop, gambled bool, runName string, runStartedAt time.Time, areaID area.ID) ItemStashedEvent {
 	return ItemStashedEvent{
-		BaseEvent: be,
-		Item:      drop,
+		BaseEvent:    be,
+		Item:         drop,
+		Gambled:      gambled,
+		RunName:      runName,
+		RunStartedAt: runStartedAt,
+		Area:         areaID,
+	}
+}
+
+type LevelUpEvent struct {
+	BaseEvent
+	Level int
+	// ExperiencePerHour and NextLevelETA are estimated since the supervisor was started, ETA is 0 when it's unknown
+	ExperiencePerHour float64
+	NextLevelETA      time.Duration
+}
+
+func LevelUp(be BaseEvent, level int, experiencePerHour float64, nextLevelETA time.Duration) LevelUpEvent {
+	return LevelUpEvent{
+		BaseEvent:         be,
+		Level:             level,
+		ExperiencePerHour: experiencePerHour,
+		NextLevelETA:      nextLevelETA,
+	}
+}
+
+type ItemGambledEvent struct {
+	BaseEvent
+	Item          data.Item
+	GoldSpent     int
+	GoldRecovered int
+	Kept          bool
+}
+
+func ItemGambled(be BaseEvent, itm data.Item, goldSpent, goldRecovered int, kept bool) ItemGambledEvent {
+	return ItemGambledEvent{
+		BaseEvent:     be,
+		Item:          itm,
+		GoldSpent:     goldSpent,
+		GoldRecovered: goldRecovered,
+		Kept:          kept,
 	}
 }
 
@@ -151,3 +206,121 @@ func GamePaused(be BaseEvent, paused bool) GamePausedEvent {
 		Paused:    paused,
 	}
 }
+
+type CharmSwappedEvent struct {
+	BaseEvent
+	Removed data.Item
+	Added   data.Item
+}
+
+func CharmSwapped(be BaseEvent, removed, added data.Item) CharmSwappedEvent {
+	return CharmSwappedEvent{
+		BaseEvent: be,
+		Removed:   removed,
+		Added:     added,
+	}
+}
+
+type GoalReachedEvent struct {
+	BaseEvent
+	Goal config.Goal
+}
+
+func GoalReached(be BaseEvent, goal config.Goal) GoalReachedEvent {
+	return GoalReachedEvent{
+		BaseEvent: be,
+		Goal:      goal,
+	}
+}
+
+type StuckEvent struct {
+	BaseEvent
+	Area     area.ID
+	Position data.Position
+	MapSeed  uint
+	// Recovery is the recovery strategy used after detecting the character is stuck
+	Recovery string
+}
+
+func Stuck(be BaseEvent, areaID area.ID, position data.Position, mapSeed uint, recovery string) StuckEvent {
+	return StuckEvent{
+		BaseEvent: be,
+		Area:      areaID,
+		Position:  position,
+		MapSeed:   mapSeed,
+		Recovery:  recovery,
+	}
+}
+
+// GoalItemStashedEvent is sent for every stashed item when there are goals, including the items not notified with
+// ItemStashedEvent (gems, low runes...), the item goals are counted with it
+type GoalItemStashedEvent struct {
+	BaseEvent
+	Item data.Drop
+}
+
+func GoalItemStashed(be BaseEvent, drop data.Drop) GoalItemStashedEvent {
+	return GoalItemStashedEvent{
+		BaseEvent: be,
+		Item:      drop,
+	}
+}
+
+// GoalItemFoundEvent is sent every time a stashed item matches an item goal, before the goal is reached
+type GoalItemFoundEvent struct {
+	BaseEvent
+	Goal config.Goal
+	Item data.Drop
+}
+
+func GoalItemFound(be BaseEvent, goal config.Goal, drop data.Drop) GoalItemFoundEvent {
+	return GoalItemFoundEvent{
+		BaseEvent: be,
+		Goal:      goal,
+		Item:      drop,
+	}
+}
+
+// StashFullEvent is sent when an item can't be stashed because all the stash tabs are full
+type StashFullEvent struct {
+	BaseEvent
+	Item data.Item
+}
+
+func StashFull(be BaseEvent, itm data.Item) StashFullEvent {
+	return StashFullEvent{
+		BaseEvent: be,
+		Item:      itm,
+	}
+}
+
+// DeathLoopEvent is sent when the character dies too many times in a short period, usually it needs manual attention
+type DeathLoopEvent struct {
+	BaseEvent
+	Deaths int
+	Window time.Duration
+}
+
+func DeathLoop(be BaseEvent, deaths int, window time.Duration) DeathLoopEvent {
+	return DeathLoopEvent{
+		BaseEvent: be,
+		Deaths:    deaths,
+		Window:    window,
+	}
+}
+
+// CrashCircuitOpenEvent is sent when the client crashed too many times in a short period, see the crashAlert config.
+// The crash detector stops restarting it when crashAlert.stopSupervisor is set.
+type CrashCircuitOpenEvent struct {
+	BaseEvent
+	Crashes int
+	Window  time.Duration
+}
+
+func CrashCircuitOpen(be BaseEvent, crashes int, window time.Duration) CrashCircuitOpenEvent {
+	return CrashCircuitOpenEvent{
+		BaseEvent: be,
+		Crashes:   crashes,
+		Window:    window,
+	}
+}
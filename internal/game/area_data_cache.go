@@ -0,0 +1,56 @@
+package game
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/hectorgimenez/d2go/pkg/data/area"
+	"github.com/hectorgimenez/d2go/pkg/data/difficulty"
+)
+
+// areaDataCacheSize is how many seeds keep their area data in memory, building the collision grids is the slowest part
+// of fetching the map data once it's cached on disk. Supervisors playing the same game share the entry.
+const areaDataCacheSize = 4
+
+type areaDataCacheEntry struct {
+	seed       uint
+	difficulty difficulty.Difficulty
+	areas      map[area.ID]AreaData
+}
+
+var (
+	areaDataCacheMu sync.Mutex
+	// areaDataCache is sorted by last access, the most recently used entry goes last. The area data is never modified
+	// once built, the path finder works on overlays of the grids.
+	areaDataCache []areaDataCacheEntry
+)
+
+func cachedAreaData(seed uint, diff difficulty.Difficulty) (map[area.ID]AreaData, bool) {
+	areaDataCacheMu.Lock()
+	defer areaDataCacheMu.Unlock()
+
+	idx := slices.IndexFunc(areaDataCache, func(e areaDataCacheEntry) bool {
+		return e.seed == seed && e.difficulty == diff
+	})
+	if idx == -1 {
+		return nil, false
+	}
+
+	entry := areaDataCache[idx]
+	areaDataCache = append(slices.Delete(areaDataCache, idx, idx+1), entry)
+
+	return entry.areas, true
+}
+
+func storeAreaData(seed uint, diff difficulty.Difficulty, areas map[area.ID]AreaData) {
+	areaDataCacheMu.Lock()
+	defer areaDataCacheMu.Unlock()
+
+	areaDataCache = slices.DeleteFunc(areaDataCache, func(e areaDataCacheEntry) bool {
+		return e.seed == seed && e.difficulty == diff
+	})
+	areaDataCache = append(areaDataCache, areaDataCacheEntry{seed: seed, difficulty: diff, areas: areas})
+	if len(areaDataCache) > areaDataCacheSize {
+		areaDataCache = slices.Delete(areaDataCache, 0, len(areaDataCache)-areaDataCacheSize)
+	}
+}
@@ -8,6 +8,8 @@ const (
 	CollisionTypeLowPriority
 	CollisionTypeMonster
 	CollisionTypeObject
+	// CollisionTypeDoor is a closed door or an obstacle that can be broken, paths can go through it with a cost
+	CollisionTypeDoor
 )
 
 type CollisionType uint8
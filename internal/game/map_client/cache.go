@@ -0,0 +1,214 @@
+package map_client
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data/difficulty"
+)
+
+// cacheFormatVersion must be increased every time the koolo-map.exe output format or the MapData structure changes,
+// cached entries with a different version are discarded
+const cacheFormatVersion = 1
+
+const cacheFileExtension = ".json.gz"
+
+// Cache files can be shared between supervisors, writes and evictions are serialized
+var cacheMu sync.Mutex
+
+type cacheEntry struct {
+	FormatVersion int    `json:"formatVersion"`
+	Seed          uint   `json:"seed"`
+	Difficulty    string `json:"difficulty"`
+	Levels        MapData
+}
+
+// CachedProvider stores the map data returned by another provider on disk, so games using the same seed and difficulty
+// don't need to generate it again. When the cache is full the least recently used entries are evicted.
+type CachedProvider struct {
+	provider   MapProvider
+	dir        string
+	maxEntries int
+	maxSize    int64
+	logger     *slog.Logger
+}
+
+func NewCachedProvider(provider MapProvider, dir string, maxEntries, maxSizeMB int, logger *slog.Logger) *CachedProvider {
+	return &CachedProvider{
+		provider:   provider,
+		dir:        dir,
+		maxEntries: maxEntries,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		logger:     logger,
+	}
+}
+
+func (p *CachedProvider) GetMapData(seed uint, difficulty difficulty.Difficulty) (MapData, error) {
+	mapData, err := p.load(seed, difficulty)
+	if err == nil {
+		p.logger.Debug("Map data loaded from cache", slog.Uint64("seed", uint64(seed)))
+		return mapData, nil
+	}
+	if !os.IsNotExist(err) {
+		p.logger.Debug("Discarding map data cache entry", slog.Uint64("seed", uint64(seed)), slog.Any("reason", err))
+	}
+
+	mapData, err = p.provider.GetMapData(seed, difficulty)
+	if err != nil {
+		return nil, err
+	}
+
+	// The cache is just an optimization, failing to store the entry is not a reason to stop the game
+	if err = p.store(seed, difficulty, mapData); err != nil {
+		p.logger.Warn("Error storing map data in cache", slog.Any("error", err))
+	}
+
+	return mapData, nil
+}
+
+func (p *CachedProvider) load(seed uint, difficulty difficulty.Difficulty) (MapData, error) {
+	path := p.entryPath(seed, difficulty)
+
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	entry, err := readCacheEntry(path)
+	if os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		err = entry.validate(seed, difficulty)
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return nil, err
+	}
+
+	// Modification time is used as last access time for the LRU eviction
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	return entry.Levels, nil
+}
+
+func (p *CachedProvider) store(seed uint, difficulty difficulty.Difficulty, mapData MapData) error {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	if err := os.MkdirAll(p.dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, we don't want to leave half written entries if something goes wrong
+	tmp, err := os.CreateTemp(p.dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	err = json.NewEncoder(gz).Encode(cacheEntry{
+		FormatVersion: cacheFormatVersion,
+		Seed:          seed,
+		Difficulty:    getDifficultyAsNum(difficulty),
+		Levels:        mapData,
+	})
+	if err == nil {
+		err = gz.Close()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmp.Name(), p.entryPath(seed, difficulty)); err != nil {
+		return err
+	}
+
+	return p.evict()
+}
+
+// evict removes the least recently used entries until the cache is within the configured limits
+func (p *CachedProvider) evict() error {
+	dirEntries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]os.FileInfo, 0, len(dirEntries))
+	totalSize := int64(0)
+	for _, de := range dirEntries {
+		if de.IsDir() || !strings.HasSuffix(de.Name(), cacheFileExtension) {
+			continue
+		}
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, info)
+		totalSize += info.Size()
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime().Before(entries[j].ModTime())
+	})
+
+	for len(entries) > 0 && ((p.maxEntries > 0 && len(entries) > p.maxEntries) || (p.maxSize > 0 && totalSize > p.maxSize)) {
+		if err = os.Remove(filepath.Join(p.dir, entries[0].Name())); err != nil {
+			return err
+		}
+		totalSize -= entries[0].Size()
+		entries = entries[1:]
+	}
+
+	return nil
+}
+
+func (p *CachedProvider) entryPath(seed uint, difficulty difficulty.Difficulty) string {
+	return filepath.Join(p.dir, fmt.Sprintf("%d_%s%s", seed, getDifficultyAsNum(difficulty), cacheFileExtension))
+}
+
+// readCacheEntry decodes the entry, the file is closed when it returns so it can be removed if it's not valid
+func readCacheEntry(path string) (cacheEntry, error) {
+	var entry cacheEntry
+
+	f, err := os.Open(path)
+	if err != nil {
+		return entry, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return entry, err
+	}
+	defer gz.Close()
+
+	err = json.NewDecoder(gz).Decode(&entry)
+
+	return entry, err
+}
+
+func (e cacheEntry) validate(seed uint, difficulty difficulty.Difficulty) error {
+	if e.FormatVersion != cacheFormatVersion {
+		return fmt.Errorf("format version %d doesn't match current version %d", e.FormatVersion, cacheFormatVersion)
+	}
+	if e.Seed != seed || e.Difficulty != getDifficultyAsNum(difficulty) {
+		return fmt.Errorf("entry belongs to seed %d and difficulty %s", e.Seed, e.Difficulty)
+	}
+	if len(e.Levels) == 0 {
+		return fmt.Errorf("entry doesn't contain any level")
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package map_client
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data/difficulty"
+)
+
+// countingProvider counts the calls to the wrapped provider, to know when the cache was used
+type countingProvider struct {
+	MapProvider
+	calls int
+}
+
+func (p *countingProvider) GetMapData(seed uint, difficulty difficulty.Difficulty) (MapData, error) {
+	p.calls++
+
+	return p.MapProvider.GetMapData(seed, difficulty)
+}
+
+// newFixtureProvider writes a one level fixture for every seed, in hell difficulty
+func newFixtureProvider(t *testing.T, seeds ...uint) *countingProvider {
+	dir := t.TempDir()
+	for _, seed := range seeds {
+		lvl := fmt.Sprintf(`{"type":"map","id":%d,"name":"Level %d","offset":{"x":100,"y":200},"size":{"width":2,"height":2},"map":[[1,1],[1,1]]}`, seed, seed)
+		path := filepath.Join(dir, fmt.Sprintf("%d_%s.jsonl", seed, getDifficultyAsNum(difficulty.Hell)))
+		if err := os.WriteFile(path, []byte("koolo-map v1\r\n"+lvl+"\r\n"), 0644); err != nil {
+			t.Fatalf("writing fixture: %v", err)
+		}
+	}
+
+	return &countingProvider{MapProvider: FixtureProvider{Dir: dir}}
+}
+
+func newTestCache(t *testing.T, provider MapProvider, maxEntries int) *CachedProvider {
+	return NewCachedProvider(provider, t.TempDir(), maxEntries, 0, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestCachedProviderRoundTrip(t *testing.T) {
+	provider := newFixtureProvider(t, 1)
+	cache := newTestCache(t, provider, 10)
+
+	first, err := cache.GetMapData(1, difficulty.Hell)
+	if err != nil {
+		t.Fatalf("first GetMapData: %v", err)
+	}
+	second, err := cache.GetMapData(1, difficulty.Hell)
+	if err != nil {
+		t.Fatalf("second GetMapData: %v", err)
+	}
+
+	if provider.calls != 1 {
+		t.Errorf("provider called %d times, expected the second call to be served from cache", provider.calls)
+	}
+	if len(second) != 1 || second[0].ID != first[0].ID || second[0].Name != first[0].Name ||
+		second[0].Offset != first[0].Offset || second[0].Size != first[0].Size || len(second[0].Map) != len(first[0].Map) {
+		t.Errorf("cached map data %+v doesn't match the original %+v", second, first)
+	}
+
+	// Difficulty is part of the key
+	if _, err = cache.GetMapData(1, difficulty.Normal); err == nil {
+		t.Errorf("expected an error, there is no fixture for normal difficulty")
+	}
+}
+
+func TestCachedProviderEvictsLeastRecentlyUsed(t *testing.T) {
+	provider := newFixtureProvider(t, 1, 2, 3)
+	cache := newTestCache(t, provider, 2)
+
+	for _, seed := range []uint{1, 2} {
+		if _, err := cache.GetMapData(seed, difficulty.Hell); err != nil {
+			t.Fatalf("GetMapData(%d): %v", seed, err)
+		}
+	}
+
+	// Modification times can be equal on fast file systems, let's make seed 2 the least recently used one
+	past := time.Now().Add(-time.Hour)
+	for _, seed := range []uint{1, 2} {
+		if err := os.Chtimes(cache.entryPath(seed, difficulty.Hell), past, past); err != nil {
+			t.Fatalf("changing modification time: %v", err)
+		}
+	}
+	if _, err := cache.GetMapData(1, difficulty.Hell); err != nil {
+		t.Fatalf("GetMapData(1): %v", err)
+	}
+	if _, err := cache.GetMapData(3, difficulty.Hell); err != nil {
+		t.Fatalf("GetMapData(3): %v", err)
+	}
+
+	for seed, cached := range map[uint]bool{1: true, 2: false, 3: true} {
+		_, err := os.Stat(cache.entryPath(seed, difficulty.Hell))
+		if cached && err != nil {
+			t.Errorf("seed %d should be cached: %v", seed, err)
+		}
+		if !cached && !os.IsNotExist(err) {
+			t.Errorf("seed %d should have been evicted", seed)
+		}
+	}
+	if provider.calls != 3 {
+		t.Errorf("provider called %d times, expected 3", provider.calls)
+	}
+}
+
+func TestCachedProviderDiscardsInvalidEntries(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(path string) error
+	}{
+		{
+			name: "old format version",
+			write: func(path string) error {
+				return writeTestEntry(path, cacheEntry{FormatVersion: cacheFormatVersion - 1, Seed: 1, Difficulty: getDifficultyAsNum(difficulty.Hell), Levels: MapData{{Type: "map", Map: [][]int{{1}}}}})
+			},
+		},
+		{
+			name: "another seed",
+			write: func(path string) error {
+				return writeTestEntry(path, cacheEntry{FormatVersion: cacheFormatVersion, Seed: 2, Difficulty: getDifficultyAsNum(difficulty.Hell), Levels: MapData{{Type: "map", Map: [][]int{{1}}}}})
+			},
+		},
+		{
+			name: "no levels",
+			write: func(path string) error {
+				return writeTestEntry(path, cacheEntry{FormatVersion: cacheFormatVersion, Seed: 1, Difficulty: getDifficultyAsNum(difficulty.Hell)})
+			},
+		},
+		{
+			name: "not gzipped",
+			write: func(path string) error {
+				return os.WriteFile(path, []byte("{}"), 0644)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newFixtureProvider(t, 1)
+			cache := newTestCache(t, provider, 10)
+			path := cache.entryPath(1, difficulty.Hell)
+			if err := tt.write(path); err != nil {
+				t.Fatalf("writing entry: %v", err)
+			}
+
+			mapData, err := cache.GetMapData(1, difficulty.Hell)
+			if err != nil {
+				t.Fatalf("GetMapData: %v", err)
+			}
+			if provider.calls != 1 || len(mapData) != 1 || mapData[0].ID != 1 {
+				t.Errorf("invalid entry wasn't replaced by the provider data, calls %d, data %+v", provider.calls, mapData)
+			}
+
+			entry, err := readCacheEntry(path)
+			if err != nil || entry.validate(1, difficulty.Hell) != nil {
+				t.Errorf("entry wasn't stored again, read error %v", err)
+			}
+		})
+	}
+}
+
+func writeTestEntry(path string, entry cacheEntry) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	if err = json.NewEncoder(gz).Encode(entry); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
@@ -23,19 +23,22 @@ func GetMapData(seed string, difficulty difficulty.Difficulty) (MapData, error)
 		return nil, fmt.Errorf("error fetching Map data from Diablo II: LoD 1.13c game: %w", err)
 	}
 
-	stdoutLines := strings.Split(string(stdout), "\r\n")
+	return parseMapData(stdout), nil
+}
 
+// parseMapData parses the koolo-map.exe output, one JSON encoded level per line
+func parseMapData(output []byte) MapData {
 	lvls := make([]serverLevel, 0)
-	for _, line := range stdoutLines {
+	for _, line := range strings.Split(string(output), "\n") {
 		var lvl serverLevel
-		err = json.Unmarshal([]byte(line), &lvl)
+		err := json.Unmarshal([]byte(strings.TrimSuffix(line, "\r")), &lvl)
 		// Discard empty lines or lines that don't contain level information
 		if err == nil && lvl.Type != "" && len(lvl.Map) > 0 {
 			lvls = append(lvls, lvl)
 		}
 	}
 
-	return lvls, nil
+	return lvls
 }
 
 func getDifficultyAsNum(df difficulty.Difficulty) string {
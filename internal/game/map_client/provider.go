@@ -0,0 +1,43 @@
+package map_client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/hectorgimenez/d2go/pkg/data/difficulty"
+)
+
+// MapProvider returns the map data for the given seed and difficulty
+type MapProvider interface {
+	GetMapData(seed uint, difficulty difficulty.Difficulty) (MapData, error)
+}
+
+// ToolProvider generates the map data running tools/koolo-map.exe against the Diablo II: LoD 1.13c game files
+type ToolProvider struct{}
+
+func (p ToolProvider) GetMapData(seed uint, difficulty difficulty.Difficulty) (MapData, error) {
+	return GetMapData(strconv.FormatUint(uint64(seed), 10), difficulty)
+}
+
+// FixtureProvider loads the map data from files containing a koolo-map.exe output dump, named {seed}_{difficulty}.jsonl.
+// It can be used for tests or during development on systems where the map tool can not run.
+type FixtureProvider struct {
+	Dir string
+}
+
+func (p FixtureProvider) GetMapData(seed uint, difficulty difficulty.Difficulty) (MapData, error) {
+	path := filepath.Join(p.Dir, fmt.Sprintf("%d_%s.jsonl", seed, getDifficultyAsNum(difficulty)))
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading map data fixture: %w", err)
+	}
+
+	mapData := parseMapData(content)
+	if len(mapData) == 0 {
+		return nil, fmt.Errorf("map data fixture %s doesn't contain any level", path)
+	}
+
+	return mapData, nil
+}
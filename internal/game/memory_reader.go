@@ -4,7 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"strconv"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -29,6 +29,7 @@ type MemoryReader struct {
 	GameAreaSizeY  int
 	supervisorName string
 	cachedMapData  map[area.ID]AreaData
+	mapProvider    map_client.MapProvider
 	logger         *slog.Logger
 }
 
@@ -43,6 +44,7 @@ func NewGameReader(cfg *config.CharacterCfg, supervisorName string, pid uint32,
 		HWND:           window,
 		supervisorName: supervisorName,
 		cfg:            cfg,
+		mapProvider:    NewMapProvider(logger),
 		logger:         logger,
 	}
 
@@ -58,14 +60,32 @@ func (gd *MemoryReader) MapSeed() uint {
 func (gd *MemoryReader) FetchMapData() error {
 	d := gd.GameReader.GetData()
 	gd.mapSeed, _ = gd.getMapSeed(d.PlayerUnit.Address)
+	diff := config.Characters[gd.supervisorName].Game.Difficulty
 	t := time.Now()
-	gd.logger.Debug("Fetching map data...", slog.Uint64("seed", uint64(gd.mapSeed)), slog.String("difficulty", string(config.Characters[gd.supervisorName].Game.Difficulty)))
+	gd.logger.Debug("Fetching map data...", slog.Uint64("seed", uint64(gd.mapSeed)), slog.String("difficulty", string(diff)))
 
-	mapData, err := map_client.GetMapData(strconv.Itoa(int(gd.mapSeed)), config.Characters[gd.supervisorName].Game.Difficulty)
+	if areas, found := cachedAreaData(gd.mapSeed, diff); found {
+		gd.cachedMapData = areas
+		gd.logger.Debug("Area data loaded from memory", slog.Int64("ms", time.Since(t).Milliseconds()))
+		return nil
+	}
+
+	mapData, err := gd.mapProvider.GetMapData(gd.mapSeed, diff)
 	if err != nil {
 		return fmt.Errorf("error fetching map data: %w", err)
 	}
 
+	areas := BuildAreaData(mapData)
+	storeAreaData(gd.mapSeed, diff, areas)
+
+	gd.cachedMapData = areas
+	gd.logger.Debug("Fetch completed", slog.Int64("ms", time.Since(t).Milliseconds()))
+
+	return nil
+}
+
+// BuildAreaData converts the map data to the area data used by the bot, including the collision grids
+func BuildAreaData(mapData map_client.MapData) map[area.ID]AreaData {
 	areas := make(map[area.ID]AreaData)
 	var mu sync.Mutex
 	g := errgroup.Group{}
@@ -87,6 +107,11 @@ func (gd *MemoryReader) FetchMapData() error {
 				}
 			}
 
+			// Lut Gholein map is a bit bugged, we should close this fake path to avoid pathing issues
+			if area.ID(lvl.ID) == area.LutGholein && len(resultGrid) > 13 && len(resultGrid[13]) > 210 {
+				resultGrid[13][210] = CollisionTypeNonWalkable
+			}
+
 			npcs, exits, objects, rooms := lvl.NPCsExitsAndObjects()
 			grid := NewGrid(resultGrid, lvl.Offset.X, lvl.Offset.Y)
 			mu.Lock()
@@ -107,10 +132,33 @@ func (gd *MemoryReader) FetchMapData() error {
 
 	_ = g.Wait()
 
-	gd.cachedMapData = areas
-	gd.logger.Debug("Fetch completed", slog.Int64("ms", time.Since(t).Milliseconds()))
+	return areas
+}
 
-	return nil
+// NewMapProvider returns the map provider configured in koolo.yaml
+func NewMapProvider(logger *slog.Logger) map_client.MapProvider {
+	if config.Koolo.MapData.FixturesDir != "" {
+		return map_client.FixtureProvider{Dir: config.Koolo.MapData.FixturesDir}
+	}
+
+	if !config.Koolo.MapData.CacheEnabled {
+		return map_client.ToolProvider{}
+	}
+
+	cacheDir := config.Koolo.MapData.CacheDir
+	if cacheDir == "" {
+		cacheDir = filepath.Join("cache", "maps")
+	}
+	maxEntries := config.Koolo.MapData.CacheMaxEntries
+	if maxEntries <= 0 {
+		maxEntries = 200
+	}
+	maxSizeMB := config.Koolo.MapData.CacheMaxSizeMB
+	if maxSizeMB <= 0 {
+		maxSizeMB = 512
+	}
+
+	return map_client.NewCachedProvider(map_client.ToolProvider{}, cacheDir, maxEntries, maxSizeMB, logger)
 }
 
 func (gd *MemoryReader) updateWindowPositionData() {
@@ -0,0 +1,119 @@
+package game
+
+import (
+	"math"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+)
+
+// CostOverlay holds the dynamic obstacles (monsters, objects...) on top of a static Grid, so the Grid can be shared and
+// never modified. Overlays can be reused for different grids calling Reset.
+type CostOverlay struct {
+	Grid *Grid
+	// NonWalkableAsLowPriority makes non-walkable tiles passable with the highest cost, used for teleport pathing
+	NonWalkableAsLowPriority bool
+	// cells contains CollisionType+1 for overridden tiles and 0 for the ones using the static grid value
+	cells   []uint8
+	touched []int
+	// danger contains the extra cost of entering each tile, dangerTouched the tiles with danger
+	danger        []uint16
+	dangerTouched []int
+}
+
+func NewCostOverlay(g *Grid) *CostOverlay {
+	return &CostOverlay{Grid: g}
+}
+
+// Reset removes all the overrides and attaches the overlay to the given grid, keeping the allocated buffers
+func (o *CostOverlay) Reset(g *Grid) {
+	for _, idx := range o.touched {
+		o.cells[idx] = 0
+	}
+	o.touched = o.touched[:0]
+	for _, idx := range o.dangerTouched {
+		o.danger[idx] = 0
+	}
+	o.dangerTouched = o.dangerTouched[:0]
+	o.Grid = g
+	o.NonWalkableAsLowPriority = false
+}
+
+// CollisionType returns the effective collision type for the given grid relative coordinates
+func (o *CostOverlay) CollisionType(x, y int) CollisionType {
+	if len(o.touched) > 0 {
+		if c := o.cells[y*o.Grid.Width+x]; c != 0 {
+			return CollisionType(c - 1)
+		}
+	}
+
+	ct := o.Grid.CollisionGrid[y][x]
+	if ct == CollisionTypeNonWalkable && o.NonWalkableAsLowPriority {
+		return CollisionTypeLowPriority
+	}
+
+	return ct
+}
+
+// Set overrides the collision type for the given grid relative coordinates
+func (o *CostOverlay) Set(x, y int, ct CollisionType) {
+	size := o.Grid.Width * o.Grid.Height
+	if len(o.cells) < size {
+		o.grow(size)
+	}
+
+	idx := y*o.Grid.Width + x
+	if o.cells[idx] == 0 {
+		o.touched = append(o.touched, idx)
+	}
+	o.cells[idx] = uint8(ct) + 1
+}
+
+// AddDanger increases the extra cost of entering the given grid relative coordinates
+func (o *CostOverlay) AddDanger(x, y, cost int) {
+	if cost <= 0 {
+		return
+	}
+
+	size := o.Grid.Width * o.Grid.Height
+	if len(o.danger) < size {
+		danger := make([]uint16, size)
+		for _, idx := range o.dangerTouched {
+			danger[idx] = o.danger[idx]
+		}
+		o.danger = danger
+	}
+
+	idx := y*o.Grid.Width + x
+	if o.danger[idx] == 0 {
+		o.dangerTouched = append(o.dangerTouched, idx)
+	}
+	o.danger[idx] = uint16(min(int(o.danger[idx])+cost, math.MaxUint16))
+}
+
+// Danger returns the extra cost of entering the given grid relative coordinates
+func (o *CostOverlay) Danger(x, y int) int {
+	if len(o.dangerTouched) == 0 {
+		return 0
+	}
+
+	return int(o.danger[y*o.Grid.Width+x])
+}
+
+func (o *CostOverlay) IsInside(x, y int) bool {
+	return x >= 0 && x < o.Grid.Width && y >= 0 && y < o.Grid.Height
+}
+
+// IsWalkable works like Grid.IsWalkable taking into account the overrides, p is an absolute position
+func (o *CostOverlay) IsWalkable(p data.Position) bool {
+	p = o.Grid.RelativePosition(p)
+	return o.IsInside(p.X, p.Y) && o.CollisionType(p.X, p.Y) != CollisionTypeNonWalkable
+}
+
+func (o *CostOverlay) grow(size int) {
+	cells := make([]uint8, size)
+	// Previous overrides belong to the same grid, otherwise Reset would have been called
+	for _, idx := range o.touched {
+		cells[idx] = o.cells[idx]
+	}
+	o.cells = cells
+}
@@ -0,0 +1,173 @@
+// Package maprender draws the levels from the map data offline, without the game running. It's used to review the
+// seeds where runs failed.
+package maprender
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/game"
+)
+
+var (
+	colorNonWalkable = color.RGBA{R: 20, G: 20, B: 24, A: 255}
+	colorWalkable    = color.RGBA{R: 200, G: 200, B: 200, A: 255}
+	colorLowPriority = color.RGBA{R: 150, G: 150, B: 150, A: 255}
+	colorRoom        = color.RGBA{R: 204, G: 204, B: 0, A: 255}
+	colorExit        = color.RGBA{R: 0, G: 200, B: 255, A: 255}
+	colorWaypoint    = color.RGBA{R: 0, G: 90, B: 255, A: 255}
+	colorNPC         = color.RGBA{R: 255, G: 60, B: 60, A: 255}
+	colorObject      = color.RGBA{R: 160, G: 32, B: 240, A: 255}
+	colorPath        = color.RGBA{R: 36, G: 255, B: 0, A: 255}
+)
+
+// Map is a level ready to be drawn, Path is optional and it's relative to the level grid
+type Map struct {
+	Area game.AreaData
+	Path []data.Position
+}
+
+// PNG draws the level using scale x scale pixels per tile
+func (m Map) PNG(scale int) image.Image {
+	grid := m.Area.Grid
+	img := image.NewRGBA(image.Rect(0, 0, grid.Width*scale, grid.Height*scale))
+
+	fill := func(x, y, w, h int, c color.Color) {
+		draw.Draw(img, image.Rect(x*scale, y*scale, (x+w)*scale, (y+h)*scale), &image.Uniform{C: c}, image.Point{}, draw.Src)
+	}
+	// marker draws a square bigger than a tile, centered in the given relative position
+	marker := func(p data.Position, size int, c color.Color) {
+		fill(p.X-size/2, p.Y-size/2, size, size, c)
+	}
+
+	for y := 0; y < grid.Height; y++ {
+		for x := 0; x < grid.Width; x++ {
+			switch grid.CollisionGrid[y][x] {
+			case game.CollisionTypeWalkable:
+				fill(x, y, 1, 1, colorWalkable)
+			case game.CollisionTypeLowPriority:
+				fill(x, y, 1, 1, colorLowPriority)
+			default:
+				fill(x, y, 1, 1, colorNonWalkable)
+			}
+		}
+	}
+
+	for _, r := range m.Area.Rooms {
+		pos := grid.RelativePosition(r.Position)
+		fill(pos.X, pos.Y, r.Width, 1, colorRoom)
+		fill(pos.X, pos.Y+r.Height-1, r.Width, 1, colorRoom)
+		fill(pos.X, pos.Y, 1, r.Height, colorRoom)
+		fill(pos.X+r.Width-1, pos.Y, 1, r.Height, colorRoom)
+	}
+
+	for _, p := range m.Path {
+		fill(p.X, p.Y, 1, 1, colorPath)
+	}
+
+	for _, o := range m.Area.Objects {
+		c := colorObject
+		if o.IsWaypoint() {
+			c = colorWaypoint
+		}
+		marker(grid.RelativePosition(o.Position), 5, c)
+	}
+	for _, n := range m.Area.NPCs {
+		for _, p := range n.Positions {
+			marker(grid.RelativePosition(p), 5, colorNPC)
+		}
+	}
+	for _, l := range m.Area.AdjacentLevels {
+		marker(grid.RelativePosition(l.Position), 9, colorExit)
+	}
+
+	return img
+}
+
+// SVG writes the level as SVG, one unit per tile. Unlike the PNG version the exits, waypoints, NPCs and objects are
+// labeled.
+func (m Map) SVG(w io.Writer, scale int) error {
+	grid := m.Area.Grid
+	sw := &svgWriter{w: w}
+
+	sw.printf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n", grid.Width*scale, grid.Height*scale, grid.Width, grid.Height)
+	sw.printf(`<rect width="%d" height="%d" fill="%s"/>`+"\n", grid.Width, grid.Height, hex(colorNonWalkable))
+
+	// Consecutive tiles of the same type are merged in a single rect, drawing every tile is too big
+	for y := 0; y < grid.Height; y++ {
+		for x := 0; x < grid.Width; {
+			ct := grid.CollisionGrid[y][x]
+			end := x + 1
+			for end < grid.Width && grid.CollisionGrid[y][end] == ct {
+				end++
+			}
+			switch ct {
+			case game.CollisionTypeWalkable:
+				sw.printf(`<rect x="%d" y="%d" width="%d" height="1" fill="%s"/>`+"\n", x, y, end-x, hex(colorWalkable))
+			case game.CollisionTypeLowPriority:
+				sw.printf(`<rect x="%d" y="%d" width="%d" height="1" fill="%s"/>`+"\n", x, y, end-x, hex(colorLowPriority))
+			}
+			x = end
+		}
+	}
+
+	for _, r := range m.Area.Rooms {
+		pos := grid.RelativePosition(r.Position)
+		sw.printf(`<rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="%s" stroke-width="0.5"/>`+"\n", pos.X, pos.Y, r.Width, r.Height, hex(colorRoom))
+	}
+
+	if len(m.Path) > 0 {
+		sw.printf(`<polyline fill="none" stroke="%s" stroke-width="1" points="`, hex(colorPath))
+		for _, p := range m.Path {
+			sw.printf("%.1f,%.1f ", float64(p.X)+0.5, float64(p.Y)+0.5)
+		}
+		sw.printf(`"/>` + "\n")
+	}
+
+	label := func(p data.Position, radius float64, c color.RGBA, text string) {
+		p = grid.RelativePosition(p)
+		sw.printf(`<circle cx="%d" cy="%d" r="%.1f" fill="%s"><title>%s</title></circle>`+"\n", p.X, p.Y, radius, hex(c), html.EscapeString(text))
+		sw.printf(`<text x="%d" y="%d" font-size="6" fill="%s">%s</text>`+"\n", p.X+int(radius)+1, p.Y, hex(c), html.EscapeString(text))
+	}
+	for _, o := range m.Area.Objects {
+		c := colorObject
+		if o.IsWaypoint() {
+			c = colorWaypoint
+		}
+		label(o.Position, 2, c, fmt.Sprintf("%s (%d,%d)", o.Name.Desc().Name, o.Position.X, o.Position.Y))
+	}
+	for _, n := range m.Area.NPCs {
+		for _, p := range n.Positions {
+			label(p, 2, colorNPC, fmt.Sprintf("%s (%d,%d)", n.Name, p.X, p.Y))
+		}
+	}
+	for _, l := range m.Area.AdjacentLevels {
+		label(l.Position, 4, colorExit, fmt.Sprintf("%s (%d,%d)", l.Area.Area().Name, l.Position.X, l.Position.Y))
+	}
+
+	sw.printf("</svg>\n")
+
+	return sw.err
+}
+
+// svgWriter keeps the first write error, so it only has to be checked once at the end
+type svgWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (sw *svgWriter) printf(format string, args ...any) {
+	if sw.err != nil {
+		return
+	}
+	_, sw.err = fmt.Fprintf(sw.w, format, args...)
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
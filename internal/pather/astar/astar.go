@@ -1,8 +1,8 @@
 package astar
 
 import (
-	"container/heap"
 	"math"
+	"sync"
 
 	"github.com/hectorgimenez/d2go/pkg/data"
 	"github.com/hectorgimenez/koolo/internal/game"
@@ -26,6 +26,58 @@ type Node struct {
 	Index    int
 }
 
+// searchState contains the buffers needed by a path calculation, they are pooled and reused between calls because
+// allocating them for the full area size on every call is expensive. Instead of clearing costSoFar on every call,
+// entries are only valid when their stamp matches the current generation.
+type searchState struct {
+	costSoFar []int
+	cameFrom  []int32
+	stamp     []uint32
+	gen       uint32
+	pq        PriorityQueue
+	neighbors []data.Position
+}
+
+var statePool = sync.Pool{
+	New: func() any {
+		return &searchState{
+			pq:        make(PriorityQueue, 0, 1024),
+			neighbors: make([]data.Position, 0, 8),
+		}
+	},
+}
+
+func (s *searchState) reset(size int) {
+	if len(s.stamp) < size {
+		s.costSoFar = make([]int, size)
+		s.cameFrom = make([]int32, size)
+		s.stamp = make([]uint32, size)
+		s.gen = 0
+	}
+
+	s.gen++
+	// Generation counter overflowed, old stamps could match again
+	if s.gen == 0 {
+		clear(s.stamp)
+		s.gen = 1
+	}
+	s.pq = s.pq[:0]
+}
+
+func (s *searchState) cost(idx int) int {
+	if s.stamp[idx] != s.gen {
+		return math.MaxInt32
+	}
+
+	return s.costSoFar[idx]
+}
+
+func (s *searchState) setCost(idx, cost int, from int32) {
+	s.stamp[idx] = s.gen
+	s.costSoFar[idx] = cost
+	s.cameFrom[idx] = from
+}
+
 func direction(from, to data.Position) (dx, dy int) {
 	dx = to.X - from.X
 	dy = to.Y - from.Y
@@ -33,43 +85,44 @@ func direction(from, to data.Position) (dx, dy int) {
 }
 
 func CalculatePath(g *game.Grid, start, goal data.Position) ([]data.Position, int, bool) {
-	pq := make(PriorityQueue, 0)
-	heap.Init(&pq)
-
-	// Use a 2D slice to store the cost of each node
-	costSoFar := make([][]int, g.Width)
-	cameFrom := make([][]data.Position, g.Width)
-	for i := range costSoFar {
-		costSoFar[i] = make([]int, g.Height)
-		cameFrom[i] = make([]data.Position, g.Height)
-		for j := range costSoFar[i] {
-			costSoFar[i][j] = math.MaxInt32
-		}
+	return CalculatePathWithOverlay(game.NewCostOverlay(g), start, goal)
+}
+
+// CalculatePathWithOverlay calculates the path using the static grid of the overlay plus its dynamic obstacles,
+// start and goal are relative to the grid
+func CalculatePathWithOverlay(o *game.CostOverlay, start, goal data.Position) ([]data.Position, int, bool) {
+	g := o.Grid
+	if !o.IsInside(start.X, start.Y) || !o.IsInside(goal.X, goal.Y) {
+		return nil, 0, false
 	}
 
-	startNode := &Node{Position: start, Cost: 0, Priority: heuristic(start, goal)}
-	heap.Push(&pq, startNode)
-	costSoFar[start.X][start.Y] = 0
+	s := statePool.Get().(*searchState)
+	defer statePool.Put(s)
+	s.reset(g.Width * g.Height)
 
-	neighbors := make([]data.Position, 0, 8)
+	startIdx := start.Y*g.Width + start.X
+	s.pq.push(Node{Position: start, Cost: 0, Priority: heuristic(start, goal)})
+	s.setCost(startIdx, 0, int32(startIdx))
 
-	for pq.Len() > 0 {
-		current := heap.Pop(&pq).(*Node)
+	for len(s.pq) > 0 {
+		current := s.pq.pop()
+		currentIdx := current.Y*g.Width + current.X
 
 		// Let's build the path if we reached the goal
 		if current.Position == goal {
-			var path []data.Position
-			for p := goal; p != start; p = cameFrom[p.X][p.Y] {
-				path = append([]data.Position{p}, path...)
-			}
-			path = append([]data.Position{start}, path...)
+			path := buildPath(s, g, start, goal)
 			return path, len(path), true
 		}
 
-		updateNeighbors(g, current, &neighbors)
+		updateNeighbors(g, &current, &s.neighbors)
 
-		for _, neighbor := range neighbors {
-			newCost := costSoFar[current.X][current.Y] + getCost(g.CollisionGrid[neighbor.Y][neighbor.X])
+		currentCost := s.cost(currentIdx)
+		for _, neighbor := range s.neighbors {
+			tileCost := OverlayCost(o, neighbor.X, neighbor.Y)
+			if tileCost == math.MaxInt32 {
+				continue
+			}
+			newCost := currentCost + tileCost
 
 			// Handicap for changing direction, this prevents zig-zagging around obstacles
 			//curDirX, curDirY := direction(cameFrom[current.X][current.Y], current.Position)
@@ -78,11 +131,11 @@ func CalculatePath(g *game.Grid, start, goal data.Position) ([]data.Position, in
 			//	newCost++
 			//}
 
-			if newCost < costSoFar[neighbor.X][neighbor.Y] {
-				costSoFar[neighbor.X][neighbor.Y] = newCost
+			neighborIdx := neighbor.Y*g.Width + neighbor.X
+			if newCost < s.cost(neighborIdx) {
+				s.setCost(neighborIdx, newCost, int32(currentIdx))
 				priority := newCost + int(0.5*float64(heuristic(neighbor, goal)))
-				heap.Push(&pq, &Node{Position: neighbor, Cost: newCost, Priority: priority})
-				cameFrom[neighbor.X][neighbor.Y] = current.Position
+				s.pq.push(Node{Position: neighbor, Cost: newCost, Priority: priority})
 			}
 		}
 	}
@@ -90,6 +143,23 @@ func CalculatePath(g *game.Grid, start, goal data.Position) ([]data.Position, in
 	return nil, 0, false
 }
 
+func buildPath(s *searchState, g *game.Grid, start, goal data.Position) []data.Position {
+	length := 1
+	startIdx := start.Y*g.Width + start.X
+	for idx := goal.Y*g.Width + goal.X; idx != startIdx; idx = int(s.cameFrom[idx]) {
+		length++
+	}
+
+	path := make([]data.Position, length)
+	idx := goal.Y*g.Width + goal.X
+	for i := length - 1; i >= 0; i-- {
+		path[i] = data.Position{X: idx % g.Width, Y: idx / g.Width}
+		idx = int(s.cameFrom[idx])
+	}
+
+	return path
+}
+
 // Get walkable neighbors of a given node
 func updateNeighbors(grid *game.Grid, node *Node, neighbors *[]data.Position) {
 	*neighbors = (*neighbors)[:0]
@@ -106,7 +176,8 @@ func updateNeighbors(grid *game.Grid, node *Node, neighbors *[]data.Position) {
 	}
 }
 
-func getCost(tileType game.CollisionType) int {
+// TileCost returns the cost of entering a tile of the given type, math.MaxInt32 means it can not be entered
+func TileCost(tileType game.CollisionType) int {
 	switch tileType {
 	case game.CollisionTypeWalkable:
 		return 1 // Walkable
@@ -114,6 +185,8 @@ func getCost(tileType game.CollisionType) int {
 		return 16
 	case game.CollisionTypeObject:
 		return 4 // Soft blocker
+	case game.CollisionTypeDoor:
+		return 10 // Needs to be opened
 	case game.CollisionTypeLowPriority:
 		return 20
 	default:
@@ -121,8 +194,79 @@ func getCost(tileType game.CollisionType) int {
 	}
 }
 
+// OverlayCost returns the cost of entering a tile taking into account the overlay obstacles and danger
+func OverlayCost(o *game.CostOverlay, x, y int) int {
+	cost := TileCost(o.CollisionType(x, y))
+	if cost == math.MaxInt32 {
+		return cost
+	}
+
+	return cost + o.Danger(x, y)
+}
+
 func heuristic(a, b data.Position) int {
 	dx := math.Abs(float64(a.X - b.X))
 	dy := math.Abs(float64(a.Y - b.Y))
 	return int(dx + dy + (math.Sqrt(2)-2)*math.Min(dx, dy))
 }
+
+// Distances returns the cost of the cheapest path from start to every target, math.MaxInt32 when a target can not be
+// reached. All the positions are relative to the grid. The search stops as soon as all the targets are reached.
+func Distances(o *game.CostOverlay, start data.Position, targets []data.Position) []int {
+	g := o.Grid
+	distances := make([]int, len(targets))
+	for i := range distances {
+		distances[i] = math.MaxInt32
+	}
+	if !o.IsInside(start.X, start.Y) {
+		return distances
+	}
+
+	s := statePool.Get().(*searchState)
+	defer statePool.Put(s)
+	s.reset(g.Width * g.Height)
+
+	pending := make(map[int][]int, len(targets))
+	for i, t := range targets {
+		if o.IsInside(t.X, t.Y) {
+			idx := t.Y*g.Width + t.X
+			pending[idx] = append(pending[idx], i)
+		}
+	}
+
+	startIdx := start.Y*g.Width + start.X
+	s.pq.push(Node{Position: start})
+	s.setCost(startIdx, 0, int32(startIdx))
+
+	for len(s.pq) > 0 && len(pending) > 0 {
+		current := s.pq.pop()
+		currentIdx := current.Y*g.Width + current.X
+		if current.Cost > s.cost(currentIdx) {
+			continue
+		}
+
+		if indexes, found := pending[currentIdx]; found {
+			for _, i := range indexes {
+				distances[i] = current.Cost
+			}
+			delete(pending, currentIdx)
+		}
+
+		updateNeighbors(g, &current, &s.neighbors)
+		for _, neighbor := range s.neighbors {
+			tileCost := OverlayCost(o, neighbor.X, neighbor.Y)
+			if tileCost == math.MaxInt32 {
+				continue
+			}
+
+			newCost := current.Cost + tileCost
+			neighborIdx := neighbor.Y*g.Width + neighbor.X
+			if newCost < s.cost(neighborIdx) {
+				s.setCost(neighborIdx, newCost, int32(currentIdx))
+				s.pq.push(Node{Position: neighbor, Cost: newCost, Priority: newCost})
+			}
+		}
+	}
+
+	return distances
+}
@@ -15,12 +15,93 @@ func BenchmarkAstar(b *testing.B) {
 	start := data.Position{X: 336, Y: 701}
 	goal := data.Position{X: 11, Y: 330}
 
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		CalculatePath(grid, start, goal)
 	}
 }
 
+// BenchmarkAstarGridCopy reproduces the old approach, copying the full grid to add the dynamic obstacles on every query
+func BenchmarkAstarGridCopy(b *testing.B) {
+	grid := loadGrid()
+	obstacles := testObstacles(grid)
+
+	start := data.Position{X: 336, Y: 701}
+	goal := data.Position{X: 11, Y: 330}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g := grid.Copy()
+		for _, o := range obstacles {
+			g.CollisionGrid[o.Y][o.X] = game.CollisionTypeMonster
+		}
+		CalculatePath(g, start, goal)
+	}
+}
+
+func BenchmarkAstarOverlay(b *testing.B) {
+	grid := loadGrid()
+	obstacles := testObstacles(grid)
+	overlay := game.NewCostOverlay(grid)
+
+	start := data.Position{X: 336, Y: 701}
+	goal := data.Position{X: 11, Y: 330}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		overlay.Reset(grid)
+		for _, o := range obstacles {
+			overlay.Set(o.X, o.Y, game.CollisionTypeMonster)
+		}
+		CalculatePathWithOverlay(overlay, start, goal)
+	}
+}
+
+func TestAstarOverlay(t *testing.T) {
+	grid := loadGrid()
+	original := grid.Copy()
+
+	start := data.Position{X: 336, Y: 701}
+	goal := data.Position{X: 11, Y: 330}
+
+	path, _, found := CalculatePath(grid, start, goal)
+	if !found {
+		t.Fatalf("Expected path to be found")
+	}
+
+	// Block the middle of the path, the new path should go around it
+	blocked := path[len(path)/2]
+	overlay := game.NewCostOverlay(grid)
+	overlay.Set(blocked.X, blocked.Y, game.CollisionTypeNonWalkable)
+
+	overlayPath, _, found := CalculatePathWithOverlay(overlay, start, goal)
+	if !found {
+		t.Fatalf("Expected path to be found with overlay")
+	}
+	for _, p := range overlayPath {
+		if p == blocked {
+			t.Errorf("Path goes through blocked position %v", blocked)
+		}
+	}
+
+	for y := 0; y < grid.Height; y++ {
+		for x := 0; x < grid.Width; x++ {
+			if grid.CollisionGrid[y][x] != original.CollisionGrid[y][x] {
+				t.Fatalf("Static grid was modified at %d,%d", x, y)
+			}
+		}
+	}
+
+	overlay.Reset(grid)
+	resetPath, _, _ := CalculatePathWithOverlay(overlay, start, goal)
+	if len(resetPath) != len(path) {
+		t.Errorf("Expected path length %d after reset, got %d", len(path), len(resetPath))
+	}
+}
+
 func TestAstar(t *testing.T) {
 	grid := loadGrid()
 
@@ -39,6 +120,20 @@ func TestAstar(t *testing.T) {
 	}
 }
 
+// testObstacles returns some walkable positions spread over the grid, simulating monsters
+func testObstacles(grid *game.Grid) []data.Position {
+	obstacles := make([]data.Position, 0)
+	for y := 0; y < grid.Height; y += 7 {
+		for x := 0; x < grid.Width; x += 11 {
+			if grid.CollisionGrid[y][x] == game.CollisionTypeWalkable {
+				obstacles = append(obstacles, data.Position{X: x, Y: y})
+			}
+		}
+	}
+
+	return obstacles
+}
+
 func loadGrid() *game.Grid {
 	var grid game.Grid
 	file, err := os.Open("durance_of_hate_grid.bin")
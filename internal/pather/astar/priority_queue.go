@@ -1,6 +1,8 @@
 package astar
 
-type PriorityQueue []*Node
+// PriorityQueue is a binary min-heap of nodes ordered by priority. It follows the same algorithm as container/heap,
+// but working with values avoids allocating a node and boxing it into an interface on every push.
+type PriorityQueue []Node
 
 func (pq PriorityQueue) Len() int           { return len(pq) }
 func (pq PriorityQueue) Less(i, j int) bool { return pq[i].Priority < pq[j].Priority }
@@ -9,17 +11,50 @@ func (pq PriorityQueue) Swap(i, j int) {
 	pq[i].Index = i
 	pq[j].Index = j
 }
-func (pq *PriorityQueue) Push(x interface{}) {
-	n := x.(*Node)
+
+func (pq *PriorityQueue) push(n Node) {
 	n.Index = len(*pq)
 	*pq = append(*pq, n)
+	pq.up(len(*pq) - 1)
 }
-func (pq *PriorityQueue) Pop() interface{} {
-	old := *pq
-	n := len(old)
-	node := old[n-1]
-	old[n-1] = nil
+
+func (pq *PriorityQueue) pop() Node {
+	last := len(*pq) - 1
+	pq.Swap(0, last)
+	pq.down(0, last)
+
+	node := (*pq)[last]
 	node.Index = -1
-	*pq = old[0 : n-1]
+	*pq = (*pq)[:last]
+
 	return node
 }
+
+func (pq PriorityQueue) up(j int) {
+	for {
+		i := (j - 1) / 2 // parent
+		if i == j || !pq.Less(j, i) {
+			break
+		}
+		pq.Swap(i, j)
+		j = i
+	}
+}
+
+func (pq PriorityQueue) down(i, n int) {
+	for {
+		j1 := 2*i + 1
+		if j1 >= n || j1 < 0 { // j1 < 0 after int overflow
+			break
+		}
+		j := j1 // left child
+		if j2 := j1 + 1; j2 < n && pq.Less(j2, j1) {
+			j = j2 // = 2*i + 2  // right child
+		}
+		if !pq.Less(j, i) {
+			break
+		}
+		pq.Swap(i, j)
+		i = j
+	}
+}
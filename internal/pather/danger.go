@@ -0,0 +1,82 @@
+package pather
+
+import (
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/npc"
+	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/game"
+)
+
+// addDanger adds the extra cost around the monsters to the overlay, so paths keep some distance from them
+func (pf *PathFinder) addDanger(overlay *game.CostOverlay) {
+	cfg := pf.cfg.Pathing.Danger
+	grid := overlay.Grid
+
+	for _, m := range pf.data.Monsters.Enemies() {
+		weight := dangerWeight(cfg, m)
+		if weight <= 0 {
+			continue
+		}
+
+		pos := grid.RelativePosition(m.Position)
+		for y := pos.Y - cfg.Radius; y <= pos.Y+cfg.Radius; y++ {
+			for x := pos.X - cfg.Radius; x <= pos.X+cfg.Radius; x++ {
+				if !overlay.IsInside(x, y) {
+					continue
+				}
+				// Danger decreases with the distance, tiles at Radius distance get weight/Radius
+				distance := max(abs(x-pos.X), abs(y-pos.Y))
+				overlay.AddDanger(x, y, weight*(cfg.Radius-distance+1)/cfg.Radius)
+			}
+		}
+	}
+}
+
+func dangerWeight(cfg config.DangerCfg, m data.Monster) int {
+	weight := cfg.Normal
+	switch {
+	case isBoss(m):
+		weight = cfg.Boss
+	case m.Type == data.MonsterTypeUnique || m.Type == data.MonsterTypeSuperUnique:
+		weight = cfg.Unique
+	case m.Type == data.MonsterTypeChampion || m.Type == data.MonsterTypeMinion:
+		weight = cfg.Champion
+	}
+
+	if isRanged(m) {
+		weight += cfg.Ranged
+	}
+
+	return weight
+}
+
+func isBoss(m data.Monster) bool {
+	switch m.Name {
+	case npc.Andariel, npc.Duriel, npc.Mephisto, npc.Diablo, npc.BaalCrab, npc.Nihlathak, npc.CouncilMember:
+		return true
+	}
+
+	return m.IsSealBoss()
+}
+
+// isRanged returns true for the monsters attacking from distance with missiles or spells
+func isRanged(m data.Monster) bool {
+	switch m.Name {
+	case npc.VileArcher, npc.DarkArcher, npc.BlackArcher, npc.FleshArcher, npc.DarkArcher2, npc.VileArcher2, npc.DarkArcher3,
+		npc.SkeletonArcher, npc.ReturnedArcher, npc.BoneArcher, npc.BurningDeadArcher, npc.HorrorArcher,
+		npc.BurningDeadArcher2, npc.BoneArcher2, npc.BurningDeadArcher3, npc.ReturnedArcher2, npc.HorrorArcher2, npc.HorrorArcher3,
+		npc.DarkSpearwoman, npc.VileHunter,
+		npc.FallenShaman, npc.CarverShaman, npc.DevilkinShaman, npc.DarkShaman, npc.WarpedShaman,
+		npc.CarverShaman2, npc.DevilkinShaman2, npc.DarkShaman2,
+		npc.FetishShaman, npc.FlayerShaman, npc.SoulKillerShaman, npc.StygianDollShaman,
+		npc.FlayerShaman2, npc.StygianDollShaman2, npc.SoulKillerShaman2,
+		npc.ReturnedMage, npc.BoneMage, npc.BurningDeadMage, npc.HorrorMage,
+		npc.Gloam, npc.BurningSoul, npc.BlackSoul,
+		npc.SpearCat, npc.NightSlinger, npc.HellSlinger,
+		npc.VileTemptress, npc.StygianHag, npc.BloodWitch,
+		npc.UndeadSoulKiller, npc.Cantor, npc.Heirophant, npc.NecroMage:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,42 @@
+package pather
+
+import (
+	"testing"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/npc"
+	"github.com/hectorgimenez/koolo/internal/config"
+)
+
+func TestDangerWeight(t *testing.T) {
+	cfg := config.DangerCfg{Enabled: true, Radius: 4, Normal: 1, Champion: 3, Unique: 5, Boss: 10, Ranged: 2}
+
+	tests := []struct {
+		name     string
+		cfg      config.DangerCfg
+		monster  data.Monster
+		expected int
+	}{
+		{name: "normal", cfg: cfg, monster: data.Monster{Name: npc.Zombie, Type: data.MonsterTypeNone}, expected: 1},
+		{name: "champion", cfg: cfg, monster: data.Monster{Name: npc.Zombie, Type: data.MonsterTypeChampion}, expected: 3},
+		{name: "minion", cfg: cfg, monster: data.Monster{Name: npc.Zombie, Type: data.MonsterTypeMinion}, expected: 3},
+		{name: "unique", cfg: cfg, monster: data.Monster{Name: npc.Zombie, Type: data.MonsterTypeUnique}, expected: 5},
+		{name: "super unique", cfg: cfg, monster: data.Monster{Name: npc.Zombie, Type: data.MonsterTypeSuperUnique}, expected: 5},
+		{name: "act boss", cfg: cfg, monster: data.Monster{Name: npc.Mephisto, Type: data.MonsterTypeUnique}, expected: 10},
+		// Seal bosses are super uniques, the boss weight wins
+		{name: "seal boss", cfg: cfg, monster: data.Monster{Name: npc.VenomLord, Type: data.MonsterTypeSuperUnique}, expected: 10},
+		{name: "ranged normal", cfg: cfg, monster: data.Monster{Name: npc.FallenShaman, Type: data.MonsterTypeNone}, expected: 3},
+		{name: "ranged champion", cfg: cfg, monster: data.Monster{Name: npc.SkeletonArcher, Type: data.MonsterTypeChampion}, expected: 5},
+		// A zero weight disables the danger of the monster type, ranged monsters still get the ranged weight
+		{name: "disabled normal", cfg: config.DangerCfg{Ranged: 2}, monster: data.Monster{Name: npc.Zombie}, expected: 0},
+		{name: "disabled ranged normal", cfg: config.DangerCfg{Ranged: 2}, monster: data.Monster{Name: npc.FallenShaman}, expected: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if weight := dangerWeight(tt.cfg, tt.monster); weight != tt.expected {
+				t.Errorf("expected weight %d, got %d", tt.expected, weight)
+			}
+		})
+	}
+}
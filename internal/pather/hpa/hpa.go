@@ -0,0 +1,270 @@
+package hpa
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/game"
+	"github.com/hectorgimenez/koolo/internal/pather/astar"
+)
+
+const (
+	// clusterSize is used to split the grid areas not covered by any room
+	clusterSize = 32
+	// entranceSpacing is the max distance between two entrances of the same border segment
+	entranceSpacing = 8
+	// Paths shorter than this are cheaper to calculate with plain A*
+	minHierarchicalDistance = 2 * clusterSize
+)
+
+type edge struct {
+	to   int32
+	cost int
+}
+
+type node struct {
+	cell    int
+	cluster int32
+	// inter contains the edges to the nodes of the adjacent clusters
+	inter []edge
+	// intra contains the edges to the other nodes of the same cluster, they are calculated the first time the node is expanded
+	intra     []edge
+	intraDone bool
+}
+
+// Graph is a hierarchical abstraction of a static grid. The grid is split in clusters (rooms when available), the
+// border tiles connecting two clusters are the nodes of the graph, and the paths are calculated in the abstract graph
+// first and refined later inside each cluster. It is safe for concurrent use, the intra cluster edges are calculated
+// lazily so paths are calculated one at a time.
+type Graph struct {
+	mu        sync.Mutex
+	grid      *game.Grid
+	static    *game.CostOverlay
+	clusterOf []int32
+	nodes     []node
+	nodeAt    map[int]int32
+	clusters  [][]int32
+	search    search
+}
+
+// NewGraph builds the abstract graph for the given grid, rooms are absolute positions
+func NewGraph(grid *game.Grid, rooms []data.Room) *Graph {
+	g := &Graph{
+		grid:      grid,
+		static:    game.NewCostOverlay(grid),
+		clusterOf: make([]int32, grid.Width*grid.Height),
+		nodeAt:    make(map[int]int32),
+	}
+
+	g.buildClusters(rooms)
+	g.buildEntrances()
+
+	return g
+}
+
+func (g *Graph) Grid() *game.Grid {
+	return g.grid
+}
+
+func (g *Graph) buildClusters(rooms []data.Room) {
+	for i := range g.clusterOf {
+		g.clusterOf[i] = -1
+	}
+
+	clusters := int32(0)
+	for _, r := range rooms {
+		pos := g.grid.RelativePosition(r.Position)
+		assigned := false
+		for y := max(pos.Y, 0); y < min(pos.Y+r.Height, g.grid.Height); y++ {
+			for x := max(pos.X, 0); x < min(pos.X+r.Width, g.grid.Width); x++ {
+				if idx := y*g.grid.Width + x; g.clusterOf[idx] == -1 {
+					g.clusterOf[idx] = clusters
+					assigned = true
+				}
+			}
+		}
+		if assigned {
+			clusters++
+		}
+	}
+
+	// Tiles not covered by any room are grouped in fixed size clusters
+	fallback := make(map[int]int32)
+	clustersPerRow := g.grid.Width/clusterSize + 1
+	for y := 0; y < g.grid.Height; y++ {
+		for x := 0; x < g.grid.Width; x++ {
+			idx := y*g.grid.Width + x
+			if g.clusterOf[idx] != -1 {
+				continue
+			}
+			key := (y/clusterSize)*clustersPerRow + x/clusterSize
+			id, found := fallback[key]
+			if !found {
+				id = clusters
+				fallback[key] = id
+				clusters++
+			}
+			g.clusterOf[idx] = id
+		}
+	}
+
+	g.clusters = make([][]int32, clusters)
+}
+
+type borderKey struct {
+	from, to   int32
+	horizontal bool
+	line       int
+}
+
+// buildEntrances finds the walkable tiles connecting two clusters, contiguous tiles are grouped in segments and only
+// some of them become nodes of the graph
+func (g *Graph) buildEntrances() {
+	borders := make(map[borderKey][]int)
+	width := g.grid.Width
+	for y := 0; y < g.grid.Height; y++ {
+		for x := 0; x < width; x++ {
+			idx := y*width + x
+			if !g.passable(idx) {
+				continue
+			}
+			if x+1 < width && g.clusterOf[idx] != g.clusterOf[idx+1] && g.passable(idx+1) {
+				key := borderKey{from: g.clusterOf[idx], to: g.clusterOf[idx+1], horizontal: true, line: x}
+				borders[key] = append(borders[key], y)
+			}
+			if y+1 < g.grid.Height && g.clusterOf[idx] != g.clusterOf[idx+width] && g.passable(idx+width) {
+				key := borderKey{from: g.clusterOf[idx], to: g.clusterOf[idx+width], horizontal: false, line: y}
+				borders[key] = append(borders[key], x)
+			}
+		}
+	}
+
+	// Sort the keys, so the graph is always built the same way for the same grid
+	keys := make([]borderKey, 0, len(borders))
+	for k := range borders {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.horizontal != b.horizontal {
+			return a.horizontal
+		}
+		if a.line != b.line {
+			return a.line < b.line
+		}
+		if a.from != b.from {
+			return a.from < b.from
+		}
+		return a.to < b.to
+	})
+
+	for _, k := range keys {
+		positions := borders[k]
+		cellsAt := func(p int) (int, int) {
+			if k.horizontal {
+				return p*width + k.line, p*width + k.line + 1
+			}
+			return k.line*width + p, (k.line+1)*width + p
+		}
+		// Tiles with different costs are split in different segments, otherwise the entrance could be placed in the
+		// low priority tiles close to the walls
+		crossingCost := func(p int) int {
+			a, b := cellsAt(p)
+			return g.tileCost(g.static, a) + g.tileCost(g.static, b)
+		}
+
+		start := 0
+		for i := 1; i <= len(positions); i++ {
+			if i < len(positions) && positions[i] == positions[i-1]+1 && crossingCost(positions[i]) == crossingCost(positions[i-1]) {
+				continue
+			}
+			for _, p := range segmentEntrances(positions[start:i]) {
+				g.connect(cellsAt(p))
+			}
+			start = i
+		}
+	}
+}
+
+// segmentEntrances picks the middle of short segments, and tiles spread every entranceSpacing for longer ones
+func segmentEntrances(segment []int) []int {
+	if len(segment) <= entranceSpacing {
+		return []int{segment[len(segment)/2]}
+	}
+
+	entrances := make([]int, 0, len(segment)/entranceSpacing+1)
+	for i := entranceSpacing / 2; i < len(segment); i += entranceSpacing {
+		entrances = append(entrances, segment[i])
+	}
+
+	return entrances
+}
+
+func (g *Graph) connect(a, b int) {
+	na, nb := g.node(a), g.node(b)
+	g.nodes[na].inter = append(g.nodes[na].inter, edge{to: nb, cost: g.tileCost(g.static, b)})
+	g.nodes[nb].inter = append(g.nodes[nb].inter, edge{to: na, cost: g.tileCost(g.static, a)})
+}
+
+func (g *Graph) node(cell int) int32 {
+	if id, found := g.nodeAt[cell]; found {
+		return id
+	}
+
+	id := int32(len(g.nodes))
+	cluster := g.clusterOf[cell]
+	g.nodes = append(g.nodes, node{cell: cell, cluster: cluster})
+	g.nodeAt[cell] = id
+	g.clusters[cluster] = append(g.clusters[cluster], id)
+
+	return id
+}
+
+// intraEdges returns the edges to the nodes reachable inside the same cluster, using only the static grid
+func (g *Graph) intraEdges(id int32) []edge {
+	n := &g.nodes[id]
+	if n.intraDone {
+		return n.intra
+	}
+
+	g.search.explore(g, g.static, n.cell, n.cluster, -1, false)
+	for _, other := range g.clusters[n.cluster] {
+		if other == id {
+			continue
+		}
+		if cost := g.search.cost(g.nodes[other].cell); cost != math.MaxInt32 {
+			n.intra = append(n.intra, edge{to: other, cost: cost})
+		}
+	}
+	n.intraDone = true
+
+	return n.intra
+}
+
+func (g *Graph) passable(idx int) bool {
+	return g.tileCost(g.static, idx) != math.MaxInt32
+}
+
+func (g *Graph) tileCost(o *game.CostOverlay, idx int) int {
+	return astar.OverlayCost(o, idx%g.grid.Width, idx/g.grid.Width)
+}
+
+func (g *Graph) position(idx int) data.Position {
+	return data.Position{X: idx % g.grid.Width, Y: idx / g.grid.Width}
+}
+
+// distance is the Chebyshev distance between two cells, the lowest possible cost between them
+func (g *Graph) distance(a, b int) int {
+	pa, pb := g.position(a), g.position(b)
+	return max(abs(pa.X-pb.X), abs(pa.Y-pb.Y))
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
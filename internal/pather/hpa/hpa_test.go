@@ -0,0 +1,299 @@
+package hpa
+
+import (
+	"encoding/gob"
+	"math"
+	"math/rand"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/game"
+	"github.com/hectorgimenez/koolo/internal/pather/astar"
+)
+
+const recordedGrid = "../astar/durance_of_hate_grid.bin"
+
+func TestHierarchicalEquivalence(t *testing.T) {
+	grid := loadGrid(t)
+	checkEquivalence(t, grid, NewGraph(grid, nil), 1.25)
+}
+
+func TestHierarchicalWithRooms(t *testing.T) {
+	grid := loadGrid(t)
+	rooms := testRooms(grid)
+	graph := NewGraph(grid, rooms)
+
+	// Every room is a cluster, the tiles outside the rooms are grouped in the fixed size ones
+	seen := make(map[int32]bool)
+	for _, r := range rooms {
+		pos := grid.RelativePosition(r.Position)
+		cluster := graph.clusterOf[pos.Y*grid.Width+pos.X]
+		if seen[cluster] {
+			t.Fatalf("Room at %v shares cluster %d with another room", r.Position, cluster)
+		}
+		seen[cluster] = true
+
+		for y := pos.Y; y < pos.Y+r.Height; y++ {
+			for x := pos.X; x < pos.X+r.Width; x++ {
+				if c := graph.clusterOf[y*grid.Width+x]; c != cluster {
+					t.Fatalf("Tile %d,%d of the room at %v is in cluster %d, expected %d", x, y, r.Position, c, cluster)
+				}
+			}
+		}
+	}
+	if len(graph.clusters) <= len(rooms) {
+		t.Errorf("Expected fixed size clusters for the tiles outside the rooms, got %d clusters for %d rooms", len(graph.clusters), len(rooms))
+	}
+
+	// Rooms are bigger than the fixed size clusters and have less entrances, so paths are a bit worse
+	checkEquivalence(t, grid, graph, 1.5)
+}
+
+// checkEquivalence compares the hierarchical paths with the A* ones, their cost can be up to maxRatio times the A* cost
+func checkEquivalence(t *testing.T, grid *game.Grid, graph *Graph, maxRatio float64) {
+	t.Helper()
+	overlay := game.NewCostOverlay(grid)
+
+	for _, q := range testQueries(grid, 200) {
+		expected, _, expectedFound := astar.CalculatePathWithOverlay(overlay, q[0], q[1])
+		path, distance, found := graph.CalculatePath(overlay, q[0], q[1])
+
+		if found != expectedFound {
+			t.Fatalf("Path from %v to %v: expected found %t, got %t", q[0], q[1], expectedFound, found)
+		}
+		if !found {
+			continue
+		}
+		if distance != len(path) {
+			t.Errorf("Path from %v to %v: distance %d doesn't match path length %d", q[0], q[1], distance, len(path))
+		}
+
+		validatePath(t, overlay, path, q[0], q[1])
+
+		// Hierarchical paths are not optimal, but they should be close enough
+		expectedCost, cost := pathCost(overlay, expected), pathCost(overlay, path)
+		if float64(cost) > float64(expectedCost)*maxRatio+10 {
+			t.Errorf("Path from %v to %v: cost %d is too high compared to A* cost %d", q[0], q[1], cost, expectedCost)
+		}
+	}
+}
+
+func TestHierarchicalRespectsOverlay(t *testing.T) {
+	grid := loadGrid(t)
+	graph := NewGraph(grid, nil)
+
+	start := data.Position{X: 336, Y: 701}
+	goal := data.Position{X: 11, Y: 330}
+
+	overlay := game.NewCostOverlay(grid)
+	path, _, found := graph.CalculatePath(overlay, start, goal)
+	if !found {
+		t.Fatalf("Expected path to be found")
+	}
+
+	// Block a wide band around the middle of the path, the new path must avoid it
+	blocked := path[len(path)/2]
+	for y := blocked.Y - 2; y <= blocked.Y+2; y++ {
+		for x := blocked.X - 2; x <= blocked.X+2; x++ {
+			if overlay.IsInside(x, y) {
+				overlay.Set(x, y, game.CollisionTypeNonWalkable)
+			}
+		}
+	}
+
+	path, _, found = graph.CalculatePath(overlay, start, goal)
+	_, _, expectedFound := astar.CalculatePathWithOverlay(overlay, start, goal)
+	if found != expectedFound {
+		t.Fatalf("Expected found %t, got %t", expectedFound, found)
+	}
+	if found {
+		validatePath(t, overlay, path, start, goal)
+	}
+}
+
+func TestHierarchicalConcurrentUse(t *testing.T) {
+	grid := loadGrid(t)
+	graph := NewGraph(grid, nil)
+	queries := testQueries(grid, 20)
+
+	overlay := game.NewCostOverlay(grid)
+	paths := make([][][]data.Position, 4)
+	var wg sync.WaitGroup
+	for i := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, q := range queries {
+				path, _, _ := graph.CalculatePath(overlay, q[0], q[1])
+				paths[i] = append(paths[i], path)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, q := range queries {
+		expected, _, found := graph.CalculatePath(overlay, q[0], q[1])
+		if found {
+			validatePath(t, overlay, expected, q[0], q[1])
+		}
+		for _, p := range paths {
+			if !slices.Equal(p[i], expected) {
+				t.Fatalf("Path from %v to %v calculated concurrently doesn't match the sequential one", q[0], q[1])
+			}
+		}
+	}
+}
+
+// BenchmarkAstarQueries and BenchmarkHierarchicalQueries compare both algorithms with the same random paths
+func BenchmarkAstarQueries(b *testing.B) {
+	grid := loadGrid(b)
+	overlay := game.NewCostOverlay(grid)
+	queries := testQueries(grid, 50)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, q := range queries {
+			astar.CalculatePathWithOverlay(overlay, q[0], q[1])
+		}
+	}
+}
+
+func BenchmarkHierarchicalQueries(b *testing.B) {
+	grid := loadGrid(b)
+	graph := NewGraph(grid, nil)
+	overlay := game.NewCostOverlay(grid)
+	queries := testQueries(grid, 50)
+
+	// Warm up the lazily calculated intra cluster edges, they are cached for the rest of the game
+	for _, q := range queries {
+		graph.CalculatePath(overlay, q[0], q[1])
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, q := range queries {
+			graph.CalculatePath(overlay, q[0], q[1])
+		}
+	}
+}
+
+func BenchmarkAstarLongPath(b *testing.B) {
+	grid := loadGrid(b)
+	overlay := game.NewCostOverlay(grid)
+
+	start := data.Position{X: 336, Y: 701}
+	goal := data.Position{X: 11, Y: 330}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		astar.CalculatePathWithOverlay(overlay, start, goal)
+	}
+}
+
+func BenchmarkHierarchicalLongPath(b *testing.B) {
+	grid := loadGrid(b)
+	graph := NewGraph(grid, nil)
+	overlay := game.NewCostOverlay(grid)
+
+	start := data.Position{X: 336, Y: 701}
+	goal := data.Position{X: 11, Y: 330}
+	graph.CalculatePath(overlay, start, goal)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		graph.CalculatePath(overlay, start, goal)
+	}
+}
+
+func BenchmarkHierarchicalBuild(b *testing.B) {
+	grid := loadGrid(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewGraph(grid, nil)
+	}
+}
+
+func validatePath(t *testing.T, o *game.CostOverlay, path []data.Position, start, goal data.Position) {
+	t.Helper()
+
+	if path[0] != start || path[len(path)-1] != goal {
+		t.Fatalf("Path goes from %v to %v, expected %v to %v", path[0], path[len(path)-1], start, goal)
+	}
+	for i := 1; i < len(path); i++ {
+		dx, dy := path[i].X-path[i-1].X, path[i].Y-path[i-1].Y
+		if dx < -1 || dx > 1 || dy < -1 || dy > 1 || (dx == 0 && dy == 0) {
+			t.Fatalf("Path jumps from %v to %v", path[i-1], path[i])
+		}
+		if astar.TileCost(o.CollisionType(path[i].X, path[i].Y)) == math.MaxInt32 {
+			t.Fatalf("Path goes through non walkable position %v", path[i])
+		}
+	}
+}
+
+func pathCost(o *game.CostOverlay, path []data.Position) int {
+	cost := 0
+	for _, p := range path[1:] {
+		cost += astar.TileCost(o.CollisionType(p.X, p.Y))
+	}
+
+	return cost
+}
+
+// testQueries returns random pairs of walkable positions, always the same ones for the same grid
+func testQueries(grid *game.Grid, count int) [][2]data.Position {
+	walkable := make([]data.Position, 0)
+	for y := 0; y < grid.Height; y++ {
+		for x := 0; x < grid.Width; x++ {
+			if grid.CollisionGrid[y][x] != game.CollisionTypeNonWalkable {
+				walkable = append(walkable, data.Position{X: x, Y: y})
+			}
+		}
+	}
+
+	r := rand.New(rand.NewSource(1))
+	queries := make([][2]data.Position, count)
+	for i := range queries {
+		queries[i] = [2]data.Position{walkable[r.Intn(len(walkable))], walkable[r.Intn(len(walkable))]}
+	}
+
+	return queries
+}
+
+// testRooms splits the grid in rooms of different sizes like the ones of the map data, leaving the borders uncovered.
+// Positions are absolute.
+func testRooms(grid *game.Grid) []data.Room {
+	var rooms []data.Room
+	for y := clusterSize; y+40 < grid.Height-clusterSize; y += 40 {
+		for x, width := clusterSize, 24; x+width < grid.Width-clusterSize; x, width = x+width, 64-width {
+			rooms = append(rooms, data.Room{
+				Position: data.Position{X: x + grid.OffsetX, Y: y + grid.OffsetY},
+				Width:    width,
+				Height:   40,
+			})
+		}
+	}
+
+	return rooms
+}
+
+func loadGrid(tb testing.TB) *game.Grid {
+	var grid game.Grid
+	file, err := os.Open(recordedGrid)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer file.Close()
+
+	if err = gob.NewDecoder(file).Decode(&grid); err != nil {
+		tb.Fatal(err)
+	}
+
+	return &grid
+}
@@ -0,0 +1,146 @@
+package hpa
+
+import (
+	"math"
+	"slices"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/game"
+	"github.com/hectorgimenez/koolo/internal/pather/astar"
+)
+
+// CalculatePath returns the same output as astar.CalculatePathWithOverlay, start and goal are relative to the grid.
+// Short paths, overlays for other grids and paths that can not be refined because of the dynamic obstacles are
+// calculated with plain A*.
+func (g *Graph) CalculatePath(o *game.CostOverlay, start, goal data.Position) ([]data.Position, int, bool) {
+	if o.Grid != g.grid || o.NonWalkableAsLowPriority || !o.IsInside(start.X, start.Y) || !o.IsInside(goal.X, goal.Y) {
+		return astar.CalculatePathWithOverlay(o, start, goal)
+	}
+
+	startIdx := start.Y*g.grid.Width + start.X
+	goalIdx := goal.Y*g.grid.Width + goal.X
+	if g.distance(startIdx, goalIdx) < minHierarchicalDistance || g.tileCost(o, goalIdx) == math.MaxInt32 {
+		return astar.CalculatePathWithOverlay(o, start, goal)
+	}
+
+	g.mu.Lock()
+	path, found := g.hierarchicalPath(o, startIdx, goalIdx)
+	g.mu.Unlock()
+	if found {
+		return path, len(path), true
+	}
+
+	return astar.CalculatePathWithOverlay(o, start, goal)
+}
+
+func (g *Graph) hierarchicalPath(o *game.CostOverlay, startIdx, goalIdx int) ([]data.Position, bool) {
+	startCluster, goalCluster := g.clusterOf[startIdx], g.clusterOf[goalIdx]
+	startID, goalID := int32(len(g.nodes)), int32(len(g.nodes)+1)
+	cellOf := func(id int32) int {
+		switch id {
+		case startID:
+			return startIdx
+		case goalID:
+			return goalIdx
+		}
+		return g.nodes[id].cell
+	}
+
+	// Temporary edges connecting start and goal with the nodes of their clusters
+	startEdges := make([]edge, 0)
+	g.search.explore(g, o, startIdx, startCluster, -1, false)
+	for _, id := range g.clusters[startCluster] {
+		if cost := g.search.cost(g.nodes[id].cell); cost != math.MaxInt32 {
+			startEdges = append(startEdges, edge{to: id, cost: cost})
+		}
+	}
+	if startCluster == goalCluster {
+		if cost := g.search.cost(goalIdx); cost != math.MaxInt32 {
+			startEdges = append(startEdges, edge{to: goalID, cost: cost})
+		}
+	}
+
+	goalCosts := make(map[int32]int)
+	g.search.explore(g, o, goalIdx, goalCluster, -1, true)
+	for _, id := range g.clusters[goalCluster] {
+		if cost := g.search.cost(g.nodes[id].cell); cost != math.MaxInt32 {
+			goalCosts[id] = cost
+		}
+	}
+
+	// A* over the abstract graph
+	costs := make([]int, len(g.nodes)+2)
+	for i := range costs {
+		costs[i] = math.MaxInt32
+	}
+	parents := make([]int32, len(g.nodes)+2)
+	costs[startID] = 0
+
+	var q queue
+	q.push(item{id: int(startID)})
+	for len(q) > 0 {
+		current := q.pop()
+		id := int32(current.id)
+		if id == goalID {
+			break
+		}
+		if current.dist > costs[id] {
+			continue
+		}
+
+		relax := func(e edge) {
+			newCost := costs[id] + e.cost
+			if newCost < costs[e.to] {
+				costs[e.to] = newCost
+				parents[e.to] = id
+				q.push(item{id: int(e.to), dist: newCost, priority: newCost + g.distance(cellOf(e.to), goalIdx)})
+			}
+		}
+
+		if id == startID {
+			for _, e := range startEdges {
+				relax(e)
+			}
+			continue
+		}
+
+		for _, e := range g.nodes[id].inter {
+			relax(e)
+		}
+		for _, e := range g.intraEdges(id) {
+			relax(e)
+		}
+		if cost, found := goalCosts[id]; found {
+			relax(edge{to: goalID, cost: cost})
+		}
+	}
+
+	if costs[goalID] == math.MaxInt32 {
+		return nil, false
+	}
+
+	abstractPath := []int32{goalID}
+	for id := goalID; id != startID; id = parents[id] {
+		abstractPath = append(abstractPath, parents[id])
+	}
+	slices.Reverse(abstractPath)
+
+	// Refine the abstract path, entrances are adjacent tiles and the rest of the segments are inside a single cluster
+	path := []data.Position{g.position(startIdx)}
+	for i := 1; i < len(abstractPath); i++ {
+		from, to := cellOf(abstractPath[i-1]), cellOf(abstractPath[i])
+		if from == to {
+			continue
+		}
+		if g.clusterOf[from] != g.clusterOf[to] {
+			path = append(path, g.position(to))
+			continue
+		}
+		if !g.search.explore(g, o, from, g.clusterOf[from], to, false) {
+			return nil, false
+		}
+		path = g.search.appendPath(g, path, from, to)
+	}
+
+	return path, true
+}
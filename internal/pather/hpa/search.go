@@ -0,0 +1,175 @@
+package hpa
+
+import (
+	"math"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/game"
+)
+
+var directions = []data.Position{
+	{X: 0, Y: 1},
+	{X: 1, Y: 0},
+	{X: 0, Y: -1},
+	{X: -1, Y: 0},
+	{X: 1, Y: 1},
+	{X: -1, Y: 1},
+	{X: 1, Y: -1},
+	{X: -1, Y: -1},
+}
+
+// search contains the buffers for the tile level searches, entries are only valid when their stamp matches the
+// current generation, so they don't need to be cleared between searches
+type search struct {
+	dist   []int
+	parent []int32
+	stamp  []uint32
+	gen    uint32
+	queue  queue
+}
+
+func (s *search) reset(size int) {
+	if len(s.stamp) < size {
+		s.dist = make([]int, size)
+		s.parent = make([]int32, size)
+		s.stamp = make([]uint32, size)
+		s.gen = 0
+	}
+
+	s.gen++
+	if s.gen == 0 {
+		clear(s.stamp)
+		s.gen = 1
+	}
+	s.queue = s.queue[:0]
+}
+
+func (s *search) cost(idx int) int {
+	if s.stamp[idx] != s.gen {
+		return math.MaxInt32
+	}
+
+	return s.dist[idx]
+}
+
+func (s *search) set(idx, dist, parent int) {
+	s.stamp[idx] = s.gen
+	s.dist[idx] = dist
+	s.parent[idx] = int32(parent)
+}
+
+// explore searches from start without leaving the given cluster. When goal is negative it explores the whole cluster
+// (Dijkstra), otherwise it stops when the goal is reached (A*). With reverse set, the calculated costs are the costs
+// of going from each tile to start instead of the opposite.
+func (s *search) explore(g *Graph, o *game.CostOverlay, start int, cluster int32, goal int, reverse bool) bool {
+	s.reset(len(g.clusterOf))
+	s.set(start, 0, start)
+	s.queue.push(item{id: start, dist: 0, priority: 0})
+
+	width, height := g.grid.Width, g.grid.Height
+	for len(s.queue) > 0 {
+		current := s.queue.pop()
+		if current.id == goal {
+			return true
+		}
+		// Skip outdated entries, a cheaper way to reach this tile was found after pushing it
+		if current.dist > s.cost(current.id) {
+			continue
+		}
+
+		cx, cy := current.id%width, current.id/width
+		for _, d := range directions {
+			nx, ny := cx+d.X, cy+d.Y
+			if nx < 0 || nx >= width || ny < 0 || ny >= height {
+				continue
+			}
+			neighbor := ny*width + nx
+			if g.clusterOf[neighbor] != cluster {
+				continue
+			}
+
+			tileCost := g.tileCost(o, neighbor)
+			if tileCost == math.MaxInt32 {
+				continue
+			}
+			if reverse {
+				tileCost = g.tileCost(o, current.id)
+			}
+
+			newDist := current.dist + tileCost
+			if newDist < s.cost(neighbor) {
+				s.set(neighbor, newDist, current.id)
+				priority := newDist
+				if goal >= 0 {
+					priority += g.distance(neighbor, goal)
+				}
+				s.queue.push(item{id: neighbor, dist: newDist, priority: priority})
+			}
+		}
+	}
+
+	return goal < 0
+}
+
+// appendPath appends the tiles found by the last forward search from -> to, excluding from
+func (s *search) appendPath(g *Graph, path []data.Position, from, to int) []data.Position {
+	startLen := len(path)
+	for idx := to; idx != from; idx = int(s.parent[idx]) {
+		path = append(path, g.position(idx))
+	}
+
+	// Tiles were appended from the end to the beginning
+	for i, j := startLen, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path
+}
+
+type item struct {
+	id       int
+	dist     int
+	priority int
+}
+
+// queue is a binary min-heap ordered by priority
+type queue []item
+
+func (q *queue) push(it item) {
+	*q = append(*q, it)
+	h := *q
+	for j := len(h) - 1; j > 0; {
+		i := (j - 1) / 2
+		if h[i].priority <= h[j].priority {
+			break
+		}
+		h[i], h[j] = h[j], h[i]
+		j = i
+	}
+}
+
+func (q *queue) pop() item {
+	h := *q
+	last := len(h) - 1
+	top := h[0]
+	h[0] = h[last]
+	h = h[:last]
+
+	for i := 0; ; {
+		smallest := i
+		if l := 2*i + 1; l < len(h) && h[l].priority < h[smallest].priority {
+			smallest = l
+		}
+		if r := 2*i + 2; r < len(h) && h[r].priority < h[smallest].priority {
+			smallest = r
+		}
+		if smallest == i {
+			break
+		}
+		h[i], h[smallest] = h[smallest], h[i]
+		i = smallest
+	}
+	*q = h
+
+	return top
+}
@@ -0,0 +1,121 @@
+package pather
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/item"
+	"github.com/hectorgimenez/koolo/internal/game"
+)
+
+var (
+	minimapNonWalkable = color.RGBA{R: 20, G: 20, B: 24, A: 255}
+	minimapWalkable    = color.RGBA{R: 90, G: 90, B: 96, A: 255}
+	minimapLowPriority = color.RGBA{R: 60, G: 60, B: 66, A: 255}
+	minimapPath        = color.RGBA{R: 36, G: 255, B: 0, A: 255}
+	minimapTarget      = color.RGBA{R: 0, G: 120, B: 255, A: 255}
+	minimapPlayer      = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	minimapObject      = color.RGBA{R: 160, G: 32, B: 240, A: 255}
+	minimapItem        = color.RGBA{R: 255, G: 140, B: 0, A: 255}
+	minimapMonster     = color.RGBA{R: 220, G: 30, B: 30, A: 255}
+	minimapChampion    = color.RGBA{R: 80, G: 140, B: 255, A: 255}
+	minimapUnique      = color.RGBA{R: 255, G: 215, B: 0, A: 255}
+	minimapBoss        = color.RGBA{R: 255, G: 0, B: 200, A: 255}
+)
+
+// lastPath is the last calculated path in absolute coordinates, used to show it in the minimap
+type lastPath struct {
+	path   Path
+	target data.Position
+}
+
+func (pf *PathFinder) recordPath(path Path, grid *game.Grid, target data.Position) {
+	absolute := make(Path, len(path))
+	for i, p := range path {
+		absolute[i] = data.Position{X: p.X + grid.OffsetX, Y: p.Y + grid.OffsetY}
+	}
+
+	pf.lastPathMu.Lock()
+	pf.lastPath = lastPath{path: absolute, target: target}
+	pf.lastPathMu.Unlock()
+}
+
+// RenderMinimap draws the current area around the player: collision grid, monsters colored by type, objects, items
+// on the ground and the last calculated path with its target. Every tile in radius is drawn as a scale x scale square.
+// It's called from the web server, so the game data must be a snapshot instead of the data used by the bot.
+func (pf *PathFinder) RenderMinimap(d game.Data, radius, scale int) (image.Image, error) {
+	grid := d.AreaData.Grid
+	if grid == nil {
+		return nil, errors.New("area data is not loaded")
+	}
+
+	center := d.PlayerUnit.Position
+	origin := data.Position{X: center.X - radius, Y: center.Y - radius}
+	size := radius*2 + 1
+	img := image.NewRGBA(image.Rect(0, 0, size*scale, size*scale))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: minimapNonWalkable}, image.Point{}, draw.Src)
+
+	// pos is an absolute position, tiles outside the view are ignored
+	tile := func(pos data.Position, c color.Color, padding int) {
+		x, y := (pos.X-origin.X)*scale, (pos.Y-origin.Y)*scale
+		if x < 0 || y < 0 || x >= size*scale || y >= size*scale {
+			return
+		}
+		rect := image.Rect(x-padding, y-padding, x+scale+padding, y+scale+padding)
+		draw.Draw(img, rect, &image.Uniform{C: c}, image.Point{}, draw.Src)
+	}
+
+	for y := origin.Y; y < origin.Y+size; y++ {
+		for x := origin.X; x < origin.X+size; x++ {
+			rel := grid.RelativePosition(data.Position{X: x, Y: y})
+			if rel.X < 0 || rel.Y < 0 || rel.X >= grid.Width || rel.Y >= grid.Height {
+				continue
+			}
+			switch grid.CollisionGrid[rel.Y][rel.X] {
+			case game.CollisionTypeWalkable:
+				tile(data.Position{X: x, Y: y}, minimapWalkable, 0)
+			case game.CollisionTypeLowPriority:
+				tile(data.Position{X: x, Y: y}, minimapLowPriority, 0)
+			}
+		}
+	}
+
+	pf.lastPathMu.Lock()
+	last := pf.lastPath
+	pf.lastPathMu.Unlock()
+	for _, p := range last.path {
+		tile(p, minimapPath, 0)
+	}
+
+	for _, o := range d.Objects {
+		tile(o.Position, minimapObject, scale/2)
+	}
+	for _, i := range d.Inventory.ByLocation(item.LocationGround) {
+		tile(i.Position, minimapItem, scale/2)
+	}
+	for _, m := range d.Monsters.Enemies() {
+		tile(m.Position, minimapMonsterColor(m), scale/2)
+	}
+	if len(last.path) > 0 {
+		tile(last.target, minimapTarget, scale)
+	}
+	tile(center, minimapPlayer, scale)
+
+	return img, nil
+}
+
+func minimapMonsterColor(m data.Monster) color.Color {
+	switch {
+	case isBoss(m):
+		return minimapBoss
+	case m.Type == data.MonsterTypeUnique || m.Type == data.MonsterTypeSuperUnique:
+		return minimapUnique
+	case m.Type == data.MonsterTypeChampion || m.Type == data.MonsterTypeMinion:
+		return minimapChampion
+	}
+
+	return minimapMonster
+}
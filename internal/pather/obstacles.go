@@ -0,0 +1,85 @@
+package pather
+
+import (
+	"slices"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/npc"
+	"github.com/hectorgimenez/d2go/pkg/data/object"
+	"github.com/hectorgimenez/d2go/pkg/data/stat"
+	"github.com/hectorgimenez/koolo/internal/game"
+)
+
+// breakableObstacles are the objects blocking the way that break with a click, exploding barrels are not included
+// because breaking them hurts
+var breakableObstacles = []object.Name{
+	object.Barrel,
+	object.ExpansionWildernessBarrel,
+	object.ExpansionSiegeBarrel,
+	object.Urn2,
+	object.Urn3,
+	object.LargeUrn1,
+	object.LargeUrn4,
+	object.LargeUrn5,
+	object.Crate,
+	object.ExpansionJar1,
+	object.ExpansionJar2,
+	object.ExpansionJar3,
+	object.IceCaveEvilUrn,
+}
+
+// barricades are the Act 5 barricades, they are monsters that block the way until they are destroyed
+var barricades = []npc.ID{
+	npc.BarricadeDoor,
+	npc.BarricadeDoor2,
+	npc.PrisonDoor,
+	npc.BarricadeWallRight,
+	npc.BarricadeWallLeft,
+}
+
+// IsClosedDoor returns true for the doors blocking the way, open doors are not selectable anymore
+func IsClosedDoor(o data.Object) bool {
+	return o.IsDoor() && o.Selectable
+}
+
+// IsBreakableObstacle returns true for the objects blocking the way that are not broken yet
+func IsBreakableObstacle(o data.Object) bool {
+	return o.Selectable && slices.Contains(breakableObstacles, o.Name)
+}
+
+// IsBarricade returns true for the barricades that are not destroyed yet
+func IsBarricade(m data.Monster) bool {
+	return slices.Contains(barricades, m.Name) && m.Stats[stat.Life] > 0
+}
+
+// barricadeSize is the footprint in tiles of the barricades, they are monsters so there is no object size for them
+const barricadeSize = 3
+
+// objectFootprint returns the size in tiles of the object
+func objectFootprint(o data.Object) (int, int) {
+	desc := o.Desc()
+
+	return max(desc.SizeX, 1), max(desc.SizeY, 1)
+}
+
+// markRemovableObstacle marks the obstacle as a removable one in the overlay. The footprint (sizeX x sizeY tiles around
+// the absolute position) is marked whatever its static collision, closed doors and barricades are not walkable in the
+// map data. The tiles in the padding around it are only marked when walkable, the walls next to a door are still walls.
+func markRemovableObstacle(overlay *game.CostOverlay, grid *game.Grid, pos data.Position, sizeX, sizeY, padding int) {
+	minX, maxX := pos.X-(sizeX-1)/2, pos.X+sizeX/2
+	minY, maxY := pos.Y-(sizeY-1)/2, pos.Y+sizeY/2
+
+	for y := minY - padding; y <= maxY+padding; y++ {
+		for x := minX - padding; x <= maxX+padding; x++ {
+			p := data.Position{X: x, Y: y}
+			footprint := x >= minX && x <= maxX && y >= minY && y <= maxY
+			if !footprint && !grid.IsWalkable(p) {
+				continue
+			}
+			relativePos := grid.RelativePosition(p)
+			if overlay.IsInside(relativePos.X, relativePos.Y) {
+				overlay.Set(relativePos.X, relativePos.Y, game.CollisionTypeDoor)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package pather
+
+import (
+	"testing"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/object"
+	"github.com/hectorgimenez/koolo/internal/game"
+)
+
+func TestObstacles(t *testing.T) {
+	tests := []struct {
+		name      string
+		object    data.Object
+		door      bool
+		breakable bool
+	}{
+		{name: "closed door", object: data.Object{Name: object.DoorCathedralLeft, Selectable: true}, door: true},
+		{name: "open door", object: data.Object{Name: object.DoorCathedralLeft}},
+		{name: "barrel", object: data.Object{Name: object.Barrel, Selectable: true}, breakable: true},
+		{name: "broken barrel", object: data.Object{Name: object.Barrel}},
+		{name: "exploding barrel", object: data.Object{Name: object.BarrelExploding, Selectable: true}},
+		{name: "chest", object: data.Object{Name: object.LargeChestLeft, Selectable: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if door := IsClosedDoor(tt.object); door != tt.door {
+				t.Errorf("expected closed door %t, got %t", tt.door, door)
+			}
+			if breakable := IsBreakableObstacle(tt.object); breakable != tt.breakable {
+				t.Errorf("expected breakable %t, got %t", tt.breakable, breakable)
+			}
+		})
+	}
+}
+
+func TestMarkRemovableObstacle(t *testing.T) {
+	tests := []struct {
+		name         string
+		door         data.Position
+		sizeX, sizeY int
+		// doorTiles are the relative tiles expected as door whatever their static collision
+		doorTiles [][2]int
+	}{
+		{
+			name: "door in the wall gap", door: data.Position{X: 102, Y: 102}, sizeX: 1, sizeY: 1,
+			doorTiles: [][2]int{{2, 2}},
+		},
+		{
+			name: "door on a non walkable tile", door: data.Position{X: 102, Y: 101}, sizeX: 1, sizeY: 1,
+			doorTiles: [][2]int{{2, 1}},
+		},
+		{
+			name: "door footprint over the wall", door: data.Position{X: 102, Y: 102}, sizeX: 1, sizeY: 3,
+			doorTiles: [][2]int{{2, 1}, {2, 2}, {2, 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 5x5 grid with offset 100,100, the middle column is a wall with a one tile gap at y 2
+			rows := make([][]game.CollisionType, 5)
+			for y := range rows {
+				rows[y] = make([]game.CollisionType, 5)
+				for x := range rows[y] {
+					rows[y][x] = game.CollisionTypeWalkable
+				}
+				if y != 2 {
+					rows[y][2] = game.CollisionTypeNonWalkable
+				}
+			}
+			grid := &game.Grid{OffsetX: 100, OffsetY: 100, Width: 5, Height: 5, CollisionGrid: rows}
+			overlay := game.NewCostOverlay(grid)
+
+			markRemovableObstacle(overlay, grid, tt.door, tt.sizeX, tt.sizeY, 1)
+
+			door := grid.RelativePosition(tt.door)
+			for y := 0; y < 5; y++ {
+				for x := 0; x < 5; x++ {
+					expected := rows[y][x]
+					// The walkable tiles in the padding are part of the door, the walls next to it are kept
+					if x >= door.X-1 && x <= door.X+1 && y >= door.Y-1-(tt.sizeY-1)/2 && y <= door.Y+1+tt.sizeY/2 && expected == game.CollisionTypeWalkable {
+						expected = game.CollisionTypeDoor
+					}
+					for _, d := range tt.doorTiles {
+						if d == [2]int{x, y} {
+							expected = game.CollisionTypeDoor
+						}
+					}
+					if ct := overlay.CollisionType(x, y); ct != expected {
+						t.Errorf("tile %d,%d: expected collision type %d, got %d", x, y, expected, ct)
+					}
+				}
+			}
+		})
+	}
+}
@@ -3,27 +3,71 @@ package pather
 import (
 	"fmt"
 	"math"
+	"sync"
 
 	"github.com/hectorgimenez/d2go/pkg/data"
 	"github.com/hectorgimenez/d2go/pkg/data/area"
 	"github.com/hectorgimenez/koolo/internal/config"
 	"github.com/hectorgimenez/koolo/internal/game"
 	"github.com/hectorgimenez/koolo/internal/pather/astar"
+	"github.com/hectorgimenez/koolo/internal/pather/hpa"
 )
 
+const (
+	maxCachedGraphs = 16
+	// doorRadius is the amount of walkable tiles around the door or barricade footprint considered part of it
+	doorRadius = 1
+)
+
+var overlayPool = sync.Pool{
+	New: func() any {
+		return game.NewCostOverlay(nil)
+	},
+}
+
 type PathFinder struct {
-	gr   *game.MemoryReader
-	data *game.Data
-	hid  *game.HID
-	cfg  *config.CharacterCfg
+	gr     *game.MemoryReader
+	data   *game.Data
+	hid    *game.HID
+	cfg    *config.CharacterCfg
+	merged mergedGrid
+	// graphs is only accessed from the bot goroutine
+	graphs map[*game.Grid]*hpa.Graph
+	// blocked contains absolute positions marked as non-walkable after getting stuck, only for blockedArea
+	blocked     map[data.Position]struct{}
+	blockedArea area.ID
+	// lastPath is read from the web server goroutine
+	lastPath   lastPath
+	lastPathMu sync.Mutex
+}
+
+// mergedGrid is the last grid built merging the current area grid with an adjacent one
+type mergedGrid struct {
+	origin      *game.Grid
+	destination *game.Grid
+	grid        *game.Grid
 }
 
 func NewPathFinder(gr *game.MemoryReader, data *game.Data, hid *game.HID, cfg *config.CharacterCfg) *PathFinder {
 	return &PathFinder{
-		gr:   gr,
-		data: data,
-		hid:  hid,
-		cfg:  cfg,
+		gr:     gr,
+		data:   data,
+		hid:    hid,
+		cfg:    cfg,
+		graphs: make(map[*game.Grid]*hpa.Graph),
+	}
+}
+
+// BlockPositions marks the given absolute positions as non-walkable for the current area, paths calculated later will
+// avoid them. Blocked positions are discarded when the area changes.
+func (pf *PathFinder) BlockPositions(positions ...data.Position) {
+	if pf.blockedArea != pf.data.PlayerUnit.Area {
+		pf.blocked = make(map[data.Position]struct{})
+		pf.blockedArea = pf.data.PlayerUnit.Area
+	}
+
+	for _, p := range positions {
+		pf.blocked[p] = struct{}{}
 	}
 }
 
@@ -42,77 +86,141 @@ func (pf *PathFinder) GetPath(to data.Position) (Path, int, bool) {
 }
 
 func (pf *PathFinder) GetPathFrom(from, to data.Position) (Path, int, bool) {
-	a := pf.data.AreaData
+	path, _, distance, found := pf.calculatePath(from, to, false)
 
-	// We don't want to modify the original grid
-	grid := a.Grid.Copy()
+	return path, distance, found
+}
 
-	// Special handling for Arcane Sanctuary (to allow pathing with platforms)
-	if pf.data.PlayerUnit.Area == area.ArcaneSanctuary && pf.data.CanTeleport() {
-		// Make all non-walkable tiles into low priority tiles for teleport pathing
-		for y := 0; y < len(grid.CollisionGrid); y++ {
-			for x := 0; x < len(grid.CollisionGrid[y]); x++ {
-				if grid.CollisionGrid[y][x] == game.CollisionTypeNonWalkable {
-					grid.CollisionGrid[y][x] = game.CollisionTypeLowPriority
-				}
-			}
-		}
-	}
-	// Lut Gholein map is a bit bugged, we should close this fake path to avoid pathing issues
-	if a.Area == area.LutGholein {
-		a.CollisionGrid[13][210] = game.CollisionTypeNonWalkable
-	}
+// calculatePath returns the path relative to the returned grid. When crossGaps is set non-walkable tiles can be part of
+// the path with a high cost, it's used for teleport planning.
+func (pf *PathFinder) calculatePath(from, to data.Position, crossGaps bool) (Path, *game.Grid, int, bool) {
+	a := pf.data.AreaData
 
+	grid := a.Grid
 	if !a.IsInside(to) {
 		expandedGrid, err := pf.mergeGrids(to)
 		if err != nil {
-			return nil, 0, false
+			return nil, nil, 0, false
 		}
 		grid = expandedGrid
 	}
 
+	// The static grid is shared, dynamic obstacles are added to an overlay on top of it
+	overlay := overlayPool.Get().(*game.CostOverlay)
+	defer overlayPool.Put(overlay)
+	overlay.Reset(grid)
+
+	// Special handling for Arcane Sanctuary (to allow pathing with platforms)
+	if crossGaps || (pf.data.PlayerUnit.Area == area.ArcaneSanctuary && pf.data.CanTeleport()) {
+		// Make all non-walkable tiles into low priority tiles for teleport pathing
+		overlay.NonWalkableAsLowPriority = true
+	}
+
+	target := to
 	from = grid.RelativePosition(from)
 	to = grid.RelativePosition(to)
 
 	// Add objects to the collision grid as obstacles
 	for _, o := range pf.data.AreaData.Objects {
-		if !grid.IsWalkable(o.Position) {
+		if !overlay.IsWalkable(o.Position) {
 			continue
 		}
 		relativePos := grid.RelativePosition(o.Position)
-		grid.CollisionGrid[relativePos.Y][relativePos.X] = game.CollisionTypeObject
+		overlay.Set(relativePos.X, relativePos.Y, game.CollisionTypeObject)
 		for i := -2; i <= 2; i++ {
 			for j := -2; j <= 2; j++ {
 				if i == 0 && j == 0 {
 					continue
 				}
-				if relativePos.Y+i < 0 || relativePos.Y+i >= len(grid.CollisionGrid) || relativePos.X+j < 0 || relativePos.X+j >= len(grid.CollisionGrid[relativePos.Y]) {
+				if !overlay.IsInside(relativePos.X+j, relativePos.Y+i) {
 					continue
 				}
-				if grid.CollisionGrid[relativePos.Y+i][relativePos.X+j] == game.CollisionTypeWalkable {
-					grid.CollisionGrid[relativePos.Y+i][relativePos.X+j] = game.CollisionTypeLowPriority
-
+				if overlay.CollisionType(relativePos.X+j, relativePos.Y+i) == game.CollisionTypeWalkable {
+					overlay.Set(relativePos.X+j, relativePos.Y+i, game.CollisionTypeLowPriority)
 				}
 			}
 		}
 	}
 
-	// Add monsters to the collision grid as obstacles
+	// Closed doors and breakable objects block the path even when they are not in the collision grid, paths can go
+	// through them because they will be opened or broken when reached
+	for _, o := range pf.data.Objects {
+		switch {
+		case IsClosedDoor(o):
+			sizeX, sizeY := objectFootprint(o)
+			markRemovableObstacle(overlay, grid, o.Position, sizeX, sizeY, doorRadius)
+		case IsBreakableObstacle(o):
+			sizeX, sizeY := objectFootprint(o)
+			markRemovableObstacle(overlay, grid, o.Position, sizeX, sizeY, 0)
+		}
+	}
+
+	// Add the positions where we got stuck before
+	if pf.blockedArea == pf.data.PlayerUnit.Area {
+		for p := range pf.blocked {
+			relativePos := grid.RelativePosition(p)
+			if overlay.IsInside(relativePos.X, relativePos.Y) && relativePos != from && relativePos != to {
+				overlay.Set(relativePos.X, relativePos.Y, game.CollisionTypeNonWalkable)
+			}
+		}
+	}
+
+	// Add monsters to the collision grid as obstacles, barricades are destroyed when reached like doors are opened
 	for _, m := range pf.data.Monsters {
-		if !grid.IsWalkable(m.Position) {
+		if IsBarricade(m) {
+			markRemovableObstacle(overlay, grid, m.Position, barricadeSize, barricadeSize, doorRadius)
+			continue
+		}
+		if !overlay.IsWalkable(m.Position) {
 			continue
 		}
 		relativePos := grid.RelativePosition(m.Position)
-		grid.CollisionGrid[relativePos.Y][relativePos.X] = game.CollisionTypeMonster
+		overlay.Set(relativePos.X, relativePos.Y, game.CollisionTypeMonster)
+	}
+
+	if pf.cfg.Pathing.Danger.Enabled {
+		pf.addDanger(overlay)
+	}
+
+	var path Path
+	var distance int
+	var found bool
+	if pf.cfg.Pathing.Engine == config.PathingEngineHierarchical {
+		path, distance, found = pf.hierarchicalGraph(grid).CalculatePath(overlay, from, to)
+	} else {
+		path, distance, found = astar.CalculatePathWithOverlay(overlay, from, to)
 	}
 
-	path, distance, found := astar.CalculatePath(grid, from, to)
+	if found {
+		pf.recordPath(path, grid, target)
+	}
 
 	if config.Koolo.Debug.RenderMap {
-		pf.renderMap(grid, from, to, path)
+		pf.renderMap(overlay, from, to, path)
 	}
 
-	return path, distance, found
+	return path, grid, distance, found
+}
+
+// hierarchicalGraph returns the cached hierarchical graph for the given grid, building it the first time
+func (pf *PathFinder) hierarchicalGraph(grid *game.Grid) *hpa.Graph {
+	if g, found := pf.graphs[grid]; found {
+		return g
+	}
+
+	// Grids change every game, drop the old graphs instead of keeping them forever
+	if len(pf.graphs) >= maxCachedGraphs {
+		clear(pf.graphs)
+	}
+
+	var rooms []data.Room
+	if grid == pf.data.AreaData.Grid {
+		rooms = pf.data.AreaData.Rooms
+	}
+	g := hpa.NewGraph(grid, rooms)
+	pf.graphs[grid] = g
+
+	return g
 }
 
 func (pf *PathFinder) mergeGrids(to data.Position) (*game.Grid, error) {
@@ -121,6 +229,11 @@ func (pf *PathFinder) mergeGrids(to data.Position) (*game.Grid, error) {
 		if destination.IsInside(to) {
 			origin := pf.data.AreaData
 
+			// Merging is expensive and the same pair of grids is requested many times in a row while moving
+			if pf.merged.origin == origin.Grid && pf.merged.destination == destination.Grid {
+				return pf.merged.grid, nil
+			}
+
 			endX1 := origin.OffsetX + len(origin.Grid.CollisionGrid[0])
 			endY1 := origin.OffsetY + len(origin.Grid.CollisionGrid)
 			endX2 := destination.OffsetX + len(destination.Grid.CollisionGrid[0])
@@ -144,6 +257,7 @@ func (pf *PathFinder) mergeGrids(to data.Position) (*game.Grid, error) {
 			copyGrid(resultGrid, destination.CollisionGrid, destination.OffsetX-minX, destination.OffsetY-minY)
 
 			grid := game.NewGrid(resultGrid, minX, minY)
+			pf.merged = mergedGrid{origin: origin.Grid, destination: destination.Grid, grid: grid}
 
 			return grid, nil
 		}
@@ -12,7 +12,8 @@ import (
 	"github.com/hectorgimenez/koolo/internal/game"
 )
 
-func (pf *PathFinder) renderMap(grid *game.Grid, from, to data.Position, path Path) {
+func (pf *PathFinder) renderMap(overlay *game.CostOverlay, from, to data.Position, path Path) {
+	grid := overlay.Grid
 	img := image.NewRGBA(image.Rect(0, 0, grid.Width, grid.Height))
 	draw.Draw(img, img.Bounds(), img, image.Point{}, draw.Over)
 
@@ -26,17 +27,21 @@ func (pf *PathFinder) renderMap(grid *game.Grid, from, to data.Position, path Pa
 			if pathLocs[fmt.Sprintf("%d,%d", x, y)] {
 				img.Set(x, y, color.RGBA{R: 36, G: 255, B: 0, A: 255})
 			} else {
-				switch grid.CollisionGrid[y][x] {
+				switch overlay.CollisionType(x, y) {
 				case game.CollisionTypeNonWalkable:
 					img.Set(x, y, color.Black)
 				case game.CollisionTypeWalkable:
-					img.Set(x, y, color.White)
+					// Danger is drawn from white to orange, the higher the cost the darker the color
+					danger := min(overlay.Danger(x, y)*8, 160)
+					img.Set(x, y, color.RGBA{R: 255, G: uint8(255 - danger/2), B: uint8(255 - danger), A: 255})
 				case game.CollisionTypeLowPriority:
 					img.Set(x, y, color.RGBA{R: 200, G: 200, B: 200, A: 255}) // Gray
 				case game.CollisionTypeMonster:
 					img.Set(x, y, color.RGBA{R: 255, A: 255}) // Red
 				case game.CollisionTypeObject:
 					img.Set(x, y, color.RGBA{R: 160, G: 32, B: 240, A: 255}) // Purple
+				case game.CollisionTypeDoor:
+					img.Set(x, y, color.RGBA{R: 139, G: 69, B: 19, A: 255}) // Brown
 				}
 			}
 		}
@@ -0,0 +1,225 @@
+package pather
+
+import (
+	"math"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/game"
+	"github.com/hectorgimenez/koolo/internal/pather/astar"
+)
+
+const (
+	// unreachablePenalty multiplies the straight distance between two points without a walkable path between them
+	unreachablePenalty  = 10
+	maxTwoOptIterations = 50
+)
+
+// RoomsRoute is the order to visit the rooms of an area, lengths are path distances including the path to the end
+type RoomsRoute struct {
+	Rooms []data.Room
+	// Indexes are the positions of the rooms in the list given to the planner
+	Indexes []int
+	// InitialLength is the length of the route visiting the rooms in the given order
+	InitialLength int
+	Length        int
+}
+
+// RoomsPlanner plans the order to visit rooms of the current area. The path distances between the rooms are
+// calculated once, so the route can be planned again cheaply every time some rooms are discarded.
+type RoomsPlanner struct {
+	rooms []data.Room
+	// dist contains the distances between the points: the player position, one point per room and optionally the end
+	dist   [][]int
+	hasEnd bool
+}
+
+// NewRoomsPlanner calculates the path distances between the player position, the given rooms and end (ignored if
+// it's zero)
+func (pf *PathFinder) NewRoomsPlanner(rooms []data.Room, end data.Position) *RoomsPlanner {
+	grid := pf.data.AreaData.Grid
+	hasEnd := end.X != 0 || end.Y != 0
+
+	points := make([]data.Position, 0, len(rooms)+2)
+	points = append(points, grid.RelativePosition(pf.data.PlayerUnit.Position))
+	for _, r := range rooms {
+		points = append(points, roomAnchor(grid, r))
+	}
+	if hasEnd {
+		points = append(points, grid.RelativePosition(end))
+	}
+
+	return &RoomsPlanner{rooms: rooms, dist: pathDistances(grid, points), hasEnd: hasEnd}
+}
+
+// Route sorts the pending rooms (indexes of the planner rooms) to minimize the walking distance, starting from the room
+// at index from or from the initial player position when it's -1, and finishing close to the end. Nearest neighbor is
+// used to build the route, and it's improved later with 2-opt.
+func (p *RoomsPlanner) Route(from int, pending []int) RoomsRoute {
+	if len(pending) == 0 {
+		return RoomsRoute{}
+	}
+
+	// Points of the planner distance matrix used by this route, in the same order than the sub matrix
+	points := make([]int, 0, len(pending)+2)
+	points = append(points, from+1)
+	for _, i := range pending {
+		points = append(points, i+1)
+	}
+	if p.hasEnd {
+		points = append(points, len(p.dist)-1)
+	}
+
+	dist := make([][]int, len(points))
+	for i, a := range points {
+		dist[i] = make([]int, len(points))
+		for j, b := range points {
+			dist[i][j] = p.dist[a][b]
+		}
+	}
+
+	initial := make([]int, len(points))
+	for i := range initial {
+		initial[i] = i
+	}
+
+	order := planRoute(dist, p.hasEnd)
+	route := RoomsRoute{
+		Rooms:         make([]data.Room, 0, len(pending)),
+		Indexes:       make([]int, 0, len(pending)),
+		InitialLength: routeLength(dist, initial),
+		Length:        routeLength(dist, order),
+	}
+	for _, i := range order {
+		if i > 0 && i <= len(pending) {
+			route.Rooms = append(route.Rooms, p.rooms[pending[i-1]])
+			route.Indexes = append(route.Indexes, pending[i-1])
+		}
+	}
+
+	return route
+}
+
+// roomAnchor returns the walkable tile closest to the room center (relative to the grid), or the center if there is
+// no walkable tile in the room
+func roomAnchor(grid *game.Grid, r data.Room) data.Position {
+	center := grid.RelativePosition(r.GetCenter())
+	walkable := func(x, y int) bool {
+		return x >= 0 && y >= 0 && x < grid.Width && y < grid.Height && grid.CollisionGrid[y][x] == game.CollisionTypeWalkable
+	}
+
+	for radius := 0; radius <= max(r.Width, r.Height)/2; radius++ {
+		for dy := -radius; dy <= radius; dy++ {
+			for dx := -radius; dx <= radius; dx++ {
+				if max(abs(dx), abs(dy)) != radius {
+					continue
+				}
+				if walkable(center.X+dx, center.Y+dy) {
+					return data.Position{X: center.X + dx, Y: center.Y + dy}
+				}
+			}
+		}
+	}
+
+	return center
+}
+
+// pathDistances returns the symmetric matrix of path distances between all the points, relative to the grid
+func pathDistances(grid *game.Grid, points []data.Position) [][]int {
+	overlay := overlayPool.Get().(*game.CostOverlay)
+	defer overlayPool.Put(overlay)
+	overlay.Reset(grid)
+
+	dist := make([][]int, len(points))
+	for i := range points {
+		dist[i] = make([]int, len(points))
+	}
+
+	// Path distances are almost the same in both directions, every search only needs to reach the points after its start
+	for i, p := range points[:len(points)-1] {
+		for k, d := range astar.Distances(overlay, p, points[i+1:]) {
+			j := i + 1 + k
+			if d == math.MaxInt32 {
+				d = DistanceFromPoint(points[i], points[j]) * unreachablePenalty
+			}
+			dist[i][j], dist[j][i] = d, d
+		}
+	}
+
+	return dist
+}
+
+// planRoute returns the visiting order of the points of the distance matrix, the first point is always the start and
+// the last one is fixed when hasEnd is set
+func planRoute(dist [][]int, hasEnd bool) []int {
+	last := len(dist) - 1
+	if !hasEnd {
+		last = len(dist)
+	}
+
+	// Nearest neighbor
+	visited := make([]bool, len(dist))
+	order := make([]int, 0, len(dist))
+	order = append(order, 0)
+	visited[0] = true
+	for len(order) < last {
+		current := order[len(order)-1]
+		next := -1
+		for i := 1; i < last; i++ {
+			if !visited[i] && (next == -1 || dist[current][i] < dist[current][next]) {
+				next = i
+			}
+		}
+		order = append(order, next)
+		visited[next] = true
+	}
+	if hasEnd {
+		order = append(order, last)
+	}
+
+	// 2-opt, reverse segments while the route gets shorter. The start (and the end if it's set) can not be moved
+	lastMovable := len(order) - 1
+	if hasEnd {
+		lastMovable--
+	}
+	for iteration := 0; iteration < maxTwoOptIterations; iteration++ {
+		improved := false
+		for i := 1; i < lastMovable; i++ {
+			for k := i + 1; k <= lastMovable; k++ {
+				before := dist[order[i-1]][order[i]]
+				after := dist[order[i-1]][order[k]]
+				if k+1 < len(order) {
+					before += dist[order[k]][order[k+1]]
+					after += dist[order[i]][order[k+1]]
+				}
+				if after < before {
+					for a, b := i, k; a < b; a, b = a+1, b-1 {
+						order[a], order[b] = order[b], order[a]
+					}
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+
+	return order
+}
+
+func routeLength(dist [][]int, order []int) int {
+	length := 0
+	for i := 1; i < len(order); i++ {
+		length += dist[order[i-1]][order[i]]
+	}
+
+	return length
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
@@ -0,0 +1,85 @@
+package pather
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+)
+
+// manhattanDistances returns the distance matrix of the points, walking only in the four directions
+func manhattanDistances(points []data.Position) [][]int {
+	dist := make([][]int, len(points))
+	for i, a := range points {
+		dist[i] = make([]int, len(points))
+		for j, b := range points {
+			dist[i][j] = abs(a.X-b.X) + abs(a.Y-b.Y)
+		}
+	}
+
+	return dist
+}
+
+func TestPlanRoute(t *testing.T) {
+	// The room 1 can't be reached walking, the distances to it are penalized like in pathDistances
+	unreachable := manhattanDistances([]data.Position{{X: 0}, {X: 25}, {X: 10}, {X: 20}})
+	for i := range unreachable {
+		if i != 1 {
+			unreachable[i][1] *= unreachablePenalty
+			unreachable[1][i] *= unreachablePenalty
+		}
+	}
+
+	tests := []struct {
+		name     string
+		dist     [][]int
+		hasEnd   bool
+		expected []int
+		length   int
+	}{
+		{
+			name:     "rooms in a line",
+			dist:     manhattanDistances([]data.Position{{X: 0}, {X: 30}, {X: 10}, {X: 20}}),
+			expected: []int{0, 2, 3, 1},
+			length:   30,
+		},
+		{
+			// The end is close to the start, but it's still the last point
+			name:     "fixed end",
+			dist:     manhattanDistances([]data.Position{{X: 0}, {X: 30}, {X: 10}, {X: 20}, {X: 5}}),
+			hasEnd:   true,
+			expected: []int{0, 2, 3, 1, 4},
+			length:   55,
+		},
+		{
+			name:     "unreachable room is visited last",
+			dist:     unreachable,
+			expected: []int{0, 2, 3, 1},
+			length:   70,
+		},
+		{
+			// Nearest neighbor goes 0, 3, 1, 4, 2 (length 18) crossing its own route, 2-opt untangles it
+			name:     "2-opt improves a crossing route",
+			dist:     manhattanDistances([]data.Position{{X: 3, Y: 3}, {X: 4, Y: 6}, {X: 6, Y: 0}, {X: 5, Y: 3}, {X: 2, Y: 5}}),
+			expected: []int{0, 4, 1, 3, 2},
+			length:   14,
+		},
+		{
+			name:     "only the start",
+			dist:     [][]int{{0}},
+			expected: []int{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := planRoute(tt.dist, tt.hasEnd)
+			if !slices.Equal(order, tt.expected) {
+				t.Errorf("expected order %v, got %v", tt.expected, order)
+			}
+			if length := routeLength(tt.dist, order); length != tt.length {
+				t.Errorf("expected length %d, got %d", tt.length, length)
+			}
+		})
+	}
+}
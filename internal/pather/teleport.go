@@ -0,0 +1,89 @@
+package pather
+
+import (
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/game"
+)
+
+// maxHopPathTiles limits how far in the path a single teleport hop is searched, teleport range is limited by the
+// screen size anyway
+const maxHopPathTiles = 60
+
+// GetTeleportHops returns the minimal list of teleport destinations (absolute positions) to reach the given position.
+// Teleport doesn't need line of sight, so the path is calculated allowing to cross non-walkable gaps, and the hops
+// are placed on walkable tiles visible on screen from the previous hop.
+func (pf *PathFinder) GetTeleportHops(to data.Position) ([]data.Position, bool) {
+	path, grid, _, found := pf.calculatePath(pf.data.PlayerUnit.Position, to, true)
+	if !found || len(path) == 0 {
+		return nil, false
+	}
+
+	landable := func(p data.Position) bool {
+		return grid.CollisionGrid[p.Y][p.X] != game.CollisionTypeNonWalkable
+	}
+
+	hops, found := planTeleportHops(path, landable, pf.isOnScreen)
+	if !found {
+		return nil, false
+	}
+
+	for i := range hops {
+		hops[i] = data.Position{X: hops[i].X + grid.OffsetX, Y: hops[i].Y + grid.OffsetY}
+	}
+
+	return hops, true
+}
+
+// planTeleportHops returns the minimal number of hops along the path, every hop must be landable and reachable from the
+// previous one. The last hop is the last landable tile of the path, in case the destination itself is not walkable.
+func planTeleportHops(path Path, landable func(p data.Position) bool, reachable func(from, to data.Position) bool) ([]data.Position, bool) {
+	goal := len(path) - 1
+	for goal > 0 && !landable(path[goal]) {
+		goal--
+	}
+	if goal == 0 {
+		return []data.Position{}, true
+	}
+
+	// hops[i] is the minimal number of hops needed to land on path[i], -1 if it can not be reached
+	hops := make([]int, goal+1)
+	previous := make([]int, goal+1)
+	for i := range hops {
+		hops[i] = -1
+	}
+	hops[0] = 0
+
+	for i := 0; i < goal; i++ {
+		if hops[i] < 0 {
+			continue
+		}
+		for j := i + 1; j <= goal && j <= i+maxHopPathTiles; j++ {
+			if hops[j] >= 0 && hops[j] <= hops[i]+1 {
+				continue
+			}
+			if landable(path[j]) && reachable(path[i], path[j]) {
+				hops[j] = hops[i] + 1
+				previous[j] = i
+			}
+		}
+	}
+
+	if hops[goal] < 0 {
+		return nil, false
+	}
+
+	result := make([]data.Position, hops[goal])
+	for i, idx := len(result)-1, goal; i >= 0; i, idx = i-1, previous[idx] {
+		result[i] = path[idx]
+	}
+
+	return result, true
+}
+
+// isOnScreen checks if the given position can be clicked when the character is standing on from, without overlapping
+// the HUD
+func (pf *PathFinder) isOnScreen(from, to data.Position) bool {
+	screenX, screenY := pf.gameCoordsToScreenCords(from.X, from.Y, to.X, to.Y)
+
+	return screenX >= 0 && screenY >= 0 && screenX <= pf.gr.GameAreaSizeX && screenY <= int(float32(pf.gr.GameAreaSizeY)/1.21)
+}
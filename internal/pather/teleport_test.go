@@ -0,0 +1,74 @@
+package pather
+
+import (
+	"testing"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+)
+
+func TestPlanTeleportHops(t *testing.T) {
+	tests := []struct {
+		name string
+		// path goes straight along the X axis from 0 to length-1
+		length int
+		// blocked are the X coordinates of the tiles that can not be landed on
+		blocked []int
+		// teleportRange is the max X distance between two hops
+		teleportRange int
+		found         bool
+		hops          int
+		// last is the X coordinate of the last hop when there are hops
+		last int
+	}{
+		{name: "destination in range", length: 8, teleportRange: 10, found: true, hops: 1, last: 7},
+		{name: "already at destination", length: 1, teleportRange: 10, found: true, hops: 0},
+		{name: "minimal hops", length: 26, teleportRange: 10, found: true, hops: 3, last: 25},
+		{name: "exact range multiple", length: 31, teleportRange: 10, found: true, hops: 3, last: 30},
+		{name: "destination not walkable", length: 12, blocked: []int{10, 11}, teleportRange: 20, found: true, hops: 1, last: 9},
+		{name: "nothing landable after start", length: 6, blocked: []int{1, 2, 3, 4, 5}, teleportRange: 10, found: true, hops: 0},
+		{name: "crosses non walkable gap", length: 20, blocked: []int{5, 6, 7, 8, 9, 10, 11, 12, 13, 14}, teleportRange: 12, found: true, hops: 3, last: 19},
+		{name: "gap wider than range", length: 20, blocked: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, teleportRange: 10, found: false},
+		{name: "hops limited by path tiles", length: 2*maxHopPathTiles + 10, teleportRange: 1000, found: true, hops: 3, last: 2*maxHopPathTiles + 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := make(Path, tt.length)
+			for i := range path {
+				path[i] = data.Position{X: i, Y: 0}
+			}
+			blocked := make(map[int]bool)
+			for _, x := range tt.blocked {
+				blocked[x] = true
+			}
+			landable := func(p data.Position) bool {
+				return !blocked[p.X]
+			}
+			reachable := func(from, to data.Position) bool {
+				return abs(to.X-from.X) <= tt.teleportRange
+			}
+
+			hops, found := planTeleportHops(path, landable, reachable)
+			if found != tt.found {
+				t.Fatalf("Expected found %t, got %t with hops %v", tt.found, found, hops)
+			}
+			if !found {
+				return
+			}
+			if len(hops) != tt.hops {
+				t.Fatalf("Expected %d hops, got %d: %v", tt.hops, len(hops), hops)
+			}
+			if tt.hops > 0 && hops[len(hops)-1].X != tt.last {
+				t.Errorf("Expected last hop at %d, got %v", tt.last, hops[len(hops)-1])
+			}
+
+			from := path[0]
+			for _, h := range hops {
+				if !landable(h) || !reachable(from, h) {
+					t.Errorf("Hop %v can not be reached from %v: %v", h, from, hops)
+				}
+				from = h
+			}
+		})
+	}
+}
@@ -21,55 +21,19 @@ func (pf *PathFinder) RandomMovement() {
 	utils.Sleep(50)
 }
 
-func (pf *PathFinder) DistanceFromMe(p data.Position) int {
-	return DistanceFromPoint(pf.data.PlayerUnit.Position, p)
+// RandomTeleport teleports to a random position close to the center of the screen, teleport must be already selected
+// as the right skill
+func (pf *PathFinder) RandomTeleport() {
+	midGameX := pf.gr.GameAreaSizeX / 2
+	midGameY := pf.gr.GameAreaSizeY / 2
+	x := midGameX + rand.Intn(midGameX) - (midGameX / 2)
+	y := midGameY + rand.Intn(midGameY) - (midGameY / 2)
+	pf.hid.Click(game.RightButton, x, y)
+	utils.Sleep(50)
 }
 
-func (pf *PathFinder) OptimizeRoomsTraverseOrder() []data.Room {
-	distanceMatrix := make(map[data.Room]map[data.Room]int)
-
-	for _, room1 := range pf.data.Rooms {
-		distanceMatrix[room1] = make(map[data.Room]int)
-		for _, room2 := range pf.data.Rooms {
-			if room1 != room2 {
-				distance := DistanceFromPoint(room1.GetCenter(), room2.GetCenter())
-				distanceMatrix[room1][room2] = distance
-			} else {
-				distanceMatrix[room1][room2] = 0
-			}
-		}
-	}
-
-	currentRoom := data.Room{}
-	for _, r := range pf.data.Rooms {
-		if r.IsInside(pf.data.PlayerUnit.Position) {
-			currentRoom = r
-		}
-	}
-
-	visited := make(map[data.Room]bool)
-	order := []data.Room{currentRoom}
-	visited[currentRoom] = true
-
-	for len(order) < len(pf.data.Rooms) {
-		nextRoom := data.Room{}
-		minDistance := math.MaxInt
-
-		// Find the nearest unvisited room
-		for _, room := range pf.data.Rooms {
-			if !visited[room] && distanceMatrix[currentRoom][room] < minDistance {
-				nextRoom = room
-				minDistance = distanceMatrix[currentRoom][room]
-			}
-		}
-
-		// Add the next room to the order of visit
-		order = append(order, nextRoom)
-		visited[nextRoom] = true
-		currentRoom = nextRoom
-	}
-
-	return order
+func (pf *PathFinder) DistanceFromMe(p data.Position) int {
+	return DistanceFromPoint(pf.data.PlayerUnit.Position, p)
 }
 
 func (pf *PathFinder) MoveThroughPath(p Path, walkDuration time.Duration) {
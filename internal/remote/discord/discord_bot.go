@@ -3,18 +3,16 @@ package discord
 import (
 	"context"
 	"fmt"
-	"slices"
-	"strings"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/hectorgimenez/koolo/internal/bot"
-	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/remote"
 )
 
 type Bot struct {
 	discordSession *discordgo.Session
 	channelID      string
-	manager        *bot.SupervisorManager
+	manager        remote.SupervisorManager
 }
 
 func NewBot(token, channelID string, manager *bot.SupervisorManager) (*Bot, error) {
@@ -32,39 +30,65 @@ func NewBot(token, channelID string, manager *bot.SupervisorManager) (*Bot, erro
 
 func (b *Bot) Start(ctx context.Context) error {
 	//b.discordSession.Debug = true
-	b.discordSession.AddHandler(b.onMessageCreated)
-	b.discordSession.Identify.Intents = discordgo.IntentsGuildMessages
+	b.discordSession.AddHandler(b.onInteractionCreated)
+	b.discordSession.Identify.Intents = discordgo.IntentsGuilds
 	err := b.discordSession.Open()
 	if err != nil {
 		return fmt.Errorf("error opening connection: %w", err)
 	}
 
+	// The READY packet is processed during Open, so the bot user is already known
+	if err = b.registerCommands(b.discordSession.State.User.ID); err != nil {
+		b.discordSession.Close()
+		return err
+	}
+
 	// Wait until context is finished
 	<-ctx.Done()
 
 	return b.discordSession.Close()
 }
 
-func (b *Bot) onMessageCreated(s *discordgo.Session, m *discordgo.MessageCreate) {
-	if m.Author.ID == s.State.User.ID {
-		return
+// registerCommands replaces the application commands of the guild of the configured channel. Guild commands are
+// available straight away, global ones can take a while to show up.
+func (b *Bot) registerCommands(appID string) error {
+	channel, err := b.discordSession.Channel(b.channelID)
+	if err != nil {
+		return fmt.Errorf("error getting channel %s: %w", b.channelID, err)
 	}
 
-	// Check if the message is from a bot admin
-	if !slices.Contains(config.Koolo.Discord.BotAdmins, m.Author.ID) {
-		return
+	if _, err = b.discordSession.ApplicationCommandBulkOverwrite(appID, channel.GuildID, applicationCommands()); err != nil {
+		return fmt.Errorf("error registering application commands: %w", err)
+	}
+
+	return nil
+}
+
+func applicationCommands() []*discordgo.ApplicationCommand {
+	descriptions := []struct{ name, description string }{
+		{"start", "Start a supervisor"},
+		{"stop", "Stop a supervisor"},
+		{"pause", "Pause or resume a supervisor"},
+		{"status", "Show the status of a supervisor"},
+		{"stats", "Show the stats of a supervisor"},
+		{"drops", "Show the last drops of a supervisor"},
+		{"screenshot", "Show the game window of a supervisor"},
 	}
 
-	prefix := strings.Split(m.Content, " ")[0]
-	switch prefix {
-	case "!start":
-		b.handleStartRequest(s, m)
-	case "!stop":
-		b.handleStopRequest(s, m)
-	case "!stats":
-		b.handleStatsRequest(s, m)
-	case "!status":
-		b.handleStatusRequest(s, m)
+	commands := make([]*discordgo.ApplicationCommand, 0, len(descriptions))
+	for _, d := range descriptions {
+		commands = append(commands, &discordgo.ApplicationCommand{
+			Name:        d.name,
+			Description: d.description,
+			Options: []*discordgo.ApplicationCommandOption{{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "supervisor",
+				Description:  "Supervisor name",
+				Required:     true,
+				Autocomplete: true,
+			}},
+		})
 	}
 
+	return commands
 }
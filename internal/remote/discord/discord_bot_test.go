@@ -0,0 +1,346 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/hectorgimenez/koolo/internal/bot"
+	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/event"
+	"github.com/hectorgimenez/koolo/internal/remote/remotetest"
+)
+
+const (
+	testChannel = "channel"
+	testAdmin   = "admin"
+)
+
+// apiRequest is a REST call received by the fake gateway, path doesn't include the /api/v9 prefix
+type apiRequest struct {
+	method string
+	path   string
+	body   map[string]any
+}
+
+// fakeGateway stands in for the Discord API: interactions are delivered straight to the handler, as the gateway
+// would do, and every REST call the bot makes is recorded
+type fakeGateway struct {
+	server  *httptest.Server
+	session *discordgo.Session
+
+	mu       sync.Mutex
+	requests []apiRequest
+}
+
+func newFakeGateway(t *testing.T) *fakeGateway {
+	g := &fakeGateway{}
+	g.server = httptest.NewServer(http.HandlerFunc(g.handle))
+	t.Cleanup(g.server.Close)
+
+	session, err := discordgo.New("Bot token")
+	if err != nil {
+		t.Fatalf("creating session: %v", err)
+	}
+	target, _ := url.Parse(g.server.URL)
+	session.Client = &http.Client{Transport: redirectTransport{target: target}}
+	g.session = session
+
+	return g
+}
+
+// redirectTransport sends the requests for discord.com to the fake gateway
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (rt redirectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r.URL.Scheme = rt.target.Scheme
+	r.URL.Host = rt.target.Host
+
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func (g *fakeGateway) handle(w http.ResponseWriter, r *http.Request) {
+	path := "/" + strings.SplitN(strings.TrimPrefix(r.URL.Path, "/api/"), "/", 2)[1]
+
+	// Lists (the commands) are stored as "items"
+	var decoded any
+	body := make(map[string]any)
+	if err := r.ParseMultipartForm(1 << 20); err == nil {
+		json.Unmarshal([]byte(r.FormValue("payload_json")), &decoded)
+		body["files"] = len(r.MultipartForm.File)
+	} else if errors.Is(err, http.ErrNotMultipart) {
+		raw, _ := io.ReadAll(r.Body)
+		json.Unmarshal(raw, &decoded)
+	}
+	switch v := decoded.(type) {
+	case map[string]any:
+		for k, value := range v {
+			body[k] = value
+		}
+	case []any:
+		body["items"] = v
+	}
+
+	g.mu.Lock()
+	g.requests = append(g.requests, apiRequest{method: r.Method, path: path, body: body})
+	g.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && path == "/channels/"+testChannel:
+		json.NewEncoder(w).Encode(discordgo.Channel{ID: testChannel, GuildID: "guild"})
+	case strings.HasSuffix(path, "/commands"):
+		w.Write([]byte("[]"))
+	case strings.HasSuffix(path, "/callback") || r.Method == http.MethodDelete:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		json.NewEncoder(w).Encode(discordgo.Message{ID: "message", ChannelID: testChannel})
+	}
+}
+
+func (g *fakeGateway) sent() []apiRequest {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return append([]apiRequest{}, g.requests...)
+}
+
+func newTestBot(t *testing.T) (*Bot, *fakeGateway, *remotetest.FakeManager) {
+	config.Koolo = &config.KooloCfg{}
+	config.Koolo.Discord.BotAdmins = []string{testAdmin}
+	config.Koolo.Discord.EnableDiscordChickenMessages = true
+
+	gateway := newFakeGateway(t)
+	manager := &remotetest.FakeManager{Stats: map[string]bot.Stats{
+		"sorc":    {SupervisorStatus: bot.InGame, StartedAt: time.Now()},
+		"hammer":  {SupervisorStatus: bot.Paused, StartedAt: time.Now()},
+		"offline": {},
+	}}
+
+	return &Bot{discordSession: gateway.session, channelID: testChannel, manager: manager}, gateway, manager
+}
+
+func interaction(user string, it discordgo.InteractionType, data discordgo.InteractionData) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:     "interaction",
+		AppID:  "app",
+		Token:  "token",
+		Type:   it,
+		Member: &discordgo.Member{User: &discordgo.User{ID: user}},
+		Data:   data,
+	}}
+}
+
+func command(user, name, supervisor string) *discordgo.InteractionCreate {
+	return interaction(user, discordgo.InteractionApplicationCommand, discordgo.ApplicationCommandInteractionData{
+		Name: name,
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "supervisor", Type: discordgo.ApplicationCommandOptionString, Value: supervisor},
+		},
+	})
+}
+
+// checkRequests compares the method, path and the given body fields of the recorded requests
+func checkRequests(t *testing.T, sent []apiRequest, expected []apiRequest) {
+	t.Helper()
+
+	if len(sent) != len(expected) {
+		t.Fatalf("expected %d requests, got %d: %+v", len(expected), len(sent), sent)
+	}
+	for i, req := range sent {
+		if req.method != expected[i].method || req.path != expected[i].path {
+			t.Errorf("request %d: expected %s %s, got %s %s", i, expected[i].method, expected[i].path, req.method, req.path)
+		}
+		for k, v := range expected[i].body {
+			got, _ := json.Marshal(req.body[k])
+			want, _ := json.Marshal(v)
+			if string(got) != string(want) {
+				t.Errorf("request %d: expected %s %s, got %s", i, k, want, got)
+			}
+		}
+	}
+}
+
+func TestRegisterCommands(t *testing.T) {
+	b, gateway, _ := newTestBot(t)
+
+	if err := b.registerCommands("app"); err != nil {
+		t.Fatalf("registering commands: %v", err)
+	}
+
+	sent := gateway.sent()
+	if len(sent) != 2 || sent[1].method != http.MethodPut || sent[1].path != "/applications/app/guilds/guild/commands" {
+		t.Fatalf("expected the guild commands to be overwritten, got %+v", sent)
+	}
+
+	var names []string
+	for _, item := range sent[1].body["items"].([]any) {
+		cmd := item.(map[string]any)
+		names = append(names, cmd["name"].(string))
+		option := cmd["options"].([]any)[0].(map[string]any)
+		if option["autocomplete"] != true || option["required"] != true {
+			t.Errorf("%s: supervisor option must be required and autocompleted", cmd["name"])
+		}
+	}
+	if strings.Join(names, ",") != "start,stop,pause,status,stats,drops,screenshot" {
+		t.Errorf("unexpected commands %v", names)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	b, gateway, manager := newTestBot(t)
+	s := gateway.session
+
+	b.onInteractionCreated(s, command("someone", "stop", "sorc"))
+	b.onInteractionCreated(s, command(testAdmin, "stop", "sorc"))
+	b.onInteractionCreated(s, command(testAdmin, "pause", "missing"))
+	b.onInteractionCreated(s, command(testAdmin, "pause", "hammer"))
+	b.onInteractionCreated(s, command(testAdmin, "start", "offline"))
+	b.onInteractionCreated(s, command(testAdmin, "start", "hammer"))
+
+	if strings.Join(manager.Stopped, ",") != "sorc" || strings.Join(manager.Paused, ",") != "hammer" || strings.Join(manager.Started, ",") != "offline" {
+		t.Errorf("unexpected manager calls, stopped %v, paused %v, started %v", manager.Stopped, manager.Paused, manager.Started)
+	}
+
+	callback := "/interactions/interaction/token/callback"
+	original := "/webhooks/app/token/messages/@original"
+	checkRequests(t, gateway.sent(), []apiRequest{
+		{method: http.MethodPost, path: callback, body: map[string]any{"type": 4, "data": map[string]any{"content": "You are not allowed to use this command.", "flags": 64, "components": nil, "embeds": nil, "tts": false}}},
+		{method: http.MethodPost, path: callback, body: map[string]any{"type": 4, "data": map[string]any{"content": "Supervisor 'sorc' has been stopped.", "components": nil, "embeds": nil, "tts": false}}},
+		{method: http.MethodPost, path: callback, body: map[string]any{"type": 4, "data": map[string]any{"content": "Supervisor 'missing' not found.", "flags": 64, "components": nil, "embeds": nil, "tts": false}}},
+		{method: http.MethodPost, path: callback, body: map[string]any{"type": 4, "data": map[string]any{"content": "Supervisor 'hammer' has been resumed.", "components": nil, "embeds": nil, "tts": false}}},
+		// Start is deferred and the response edited once the supervisor is started
+		{method: http.MethodPost, path: callback, body: map[string]any{"type": 5}},
+		{method: http.MethodPatch, path: original, body: map[string]any{"content": "Supervisor 'offline' has been started."}},
+		// Errors of deferred commands are sent as an ephemeral follow-up
+		{method: http.MethodPost, path: callback, body: map[string]any{"type": 5}},
+		{method: http.MethodDelete, path: original},
+		{method: http.MethodPost, path: "/webhooks/app/token", body: map[string]any{"content": "Supervisor 'hammer' is already running.", "flags": 64}},
+	})
+}
+
+func TestAutocomplete(t *testing.T) {
+	b, gateway, _ := newTestBot(t)
+
+	b.onInteractionCreated(gateway.session, interaction(testAdmin, discordgo.InteractionApplicationCommandAutocomplete, discordgo.ApplicationCommandInteractionData{
+		Name: "stats",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "supervisor", Type: discordgo.ApplicationCommandOptionString, Value: "O", Focused: true},
+		},
+	}))
+
+	checkRequests(t, gateway.sent(), []apiRequest{
+		{method: http.MethodPost, path: "/interactions/interaction/token/callback", body: map[string]any{
+			"type": 8,
+			"data": map[string]any{
+				"choices":    []map[string]any{{"name": "offline", "value": "offline"}, {"name": "sorc", "value": "sorc"}},
+				"components": nil, "content": "", "embeds": nil, "tts": false,
+			},
+		}},
+	})
+}
+
+func TestGameFinishedButtons(t *testing.T) {
+	b, gateway, manager := newTestBot(t)
+
+	err := b.Handle(context.Background(), event.GameFinished(event.Text("sorc", "Game finished, chicken"), event.FinishedChicken))
+	if err != nil {
+		t.Fatalf("handling event: %v", err)
+	}
+
+	sent := gateway.sent()
+	if len(sent) != 1 || sent[0].path != "/channels/"+testChannel+"/messages" {
+		t.Fatalf("expected a channel message, got %+v", sent)
+	}
+	var customIDs []string
+	for _, row := range sent[0].body["components"].([]any) {
+		for _, button := range row.(map[string]any)["components"].([]any) {
+			customIDs = append(customIDs, button.(map[string]any)["custom_id"].(string))
+		}
+	}
+	if strings.Join(customIDs, ",") != "restart:sorc,pause:sorc,screenshot:sorc" {
+		t.Fatalf("unexpected buttons %v", customIDs)
+	}
+
+	// Pressing the buttons runs the same actions as the commands
+	press := func(customID string) {
+		b.onInteractionCreated(gateway.session, interaction(testAdmin, discordgo.InteractionMessageComponent, discordgo.MessageComponentInteractionData{CustomID: customID}))
+	}
+	press("restart:sorc")
+	press("screenshot:sorc")
+
+	if strings.Join(manager.Stopped, ",") != "sorc" || strings.Join(manager.Started, ",") != "sorc" {
+		t.Errorf("restart must stop and start the supervisor, stopped %v, started %v", manager.Stopped, manager.Started)
+	}
+
+	callback := "/interactions/interaction/token/callback"
+	original := "/webhooks/app/token/messages/@original"
+	checkRequests(t, gateway.sent()[1:], []apiRequest{
+		{method: http.MethodPost, path: callback, body: map[string]any{"type": 5}},
+		{method: http.MethodPatch, path: original, body: map[string]any{"content": "Supervisor 'sorc' has been restarted."}},
+		{method: http.MethodPost, path: callback, body: map[string]any{"type": 5}},
+		{method: http.MethodDelete, path: original},
+		{method: http.MethodPost, path: "/webhooks/app/token", body: map[string]any{"content": "Screenshot for 'sorc' could not be taken: supervisor is not running", "flags": 64}},
+	})
+}
+
+func TestStatusCard(t *testing.T) {
+	b, gateway, manager := newTestBot(t)
+	manager.Cards = map[string]bot.StatusCard{
+		"sorc": {
+			Supervisor:    "sorc",
+			Status:        bot.InGame,
+			InGame:        true,
+			Area:          "Chaos Sanctuary",
+			HPPercent:     30,
+			MPPercent:     90,
+			Gold:          5000,
+			GameStartedAt: time.Now().Add(-time.Minute * 2),
+			LastAction:    "ClearArea",
+		},
+	}
+
+	b.onInteractionCreated(gateway.session, command(testAdmin, "status", "sorc"))
+
+	checkRequests(t, gateway.sent(), []apiRequest{
+		{method: http.MethodPost, path: "/interactions/interaction/token/callback", body: map[string]any{
+			"type": 4,
+			"data": map[string]any{
+				"components": nil, "content": "", "tts": false,
+				"embeds": []map[string]any{{
+					"title": "sorc is In game",
+					"fields": []map[string]any{
+						{"name": "Area", "value": "Chaos Sanctuary", "inline": true},
+						{"name": "Run", "value": "-", "inline": true},
+						{"name": "Game time", "value": "2m0s", "inline": true},
+						{"name": "HP", "value": "30%", "inline": true},
+						{"name": "MP", "value": "90%", "inline": true},
+						{"name": "Merc HP", "value": "-", "inline": true},
+						{"name": "Gold", "value": "5000", "inline": true},
+						{"name": "Last action", "value": "`ClearArea`"},
+					},
+				}},
+			},
+		}},
+	})
+}
+
+func TestStatusCardEmbedWithoutArea(t *testing.T) {
+	embed := statusCardEmbed(bot.StatusCard{Supervisor: "sorc", Status: bot.InGame, InGame: true, GameStartedAt: time.Now()})
+
+	for _, f := range embed.Fields {
+		if f.Value == "" {
+			t.Errorf("field %s is empty, Discord rejects embeds with empty field values", f.Name)
+		}
+	}
+}
@@ -1,181 +1,362 @@
 package discord
 
 import (
+	"bytes"
 	"fmt"
+	"image/jpeg"
 	"slices"
 	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/hectorgimenez/koolo/internal/bot"
+	"github.com/hectorgimenez/koolo/internal/config"
 )
 
+const (
+	// maxDrops is the amount of drops listed by the drops command, newest first
+	maxDrops = 10
+	// maxAutocompleteChoices is the limit of choices Discord accepts in an autocomplete response
+	maxAutocompleteChoices = 25
+)
+
+type action struct {
+	handler func(b *Bot, supervisor string) *discordgo.InteractionResponseData
+	// slow actions can take longer than the 3 seconds Discord waits for the response, so it's deferred
+	slow bool
+}
+
+// actions are the application commands and the message buttons (restart), buttons use "action:supervisor" as custom ID
+var actions = map[string]action{
+	"start":      {handler: (*Bot).handleStartRequest, slow: true},
+	"restart":    {handler: (*Bot).handleRestartRequest, slow: true},
+	"stop":       {handler: (*Bot).handleStopRequest},
+	"pause":      {handler: (*Bot).handlePauseRequest},
+	"status":     {handler: (*Bot).handleStatusRequest},
+	"stats":      {handler: (*Bot).handleStatsRequest},
+	"drops":      {handler: (*Bot).handleDropsRequest},
+	"screenshot": {handler: (*Bot).handleScreenshotRequest, slow: true},
+}
+
+func (b *Bot) onInteractionCreated(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var name, supervisor string
+	switch i.Type {
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		b.handleAutocomplete(s, i)
+		return
+	case discordgo.InteractionApplicationCommand:
+		cmd := i.ApplicationCommandData()
+		name = cmd.Name
+		for _, opt := range cmd.Options {
+			if opt.Name == "supervisor" {
+				supervisor = opt.StringValue()
+			}
+		}
+	case discordgo.InteractionMessageComponent:
+		name, supervisor, _ = strings.Cut(i.MessageComponentData().CustomID, ":")
+	default:
+		return
+	}
+
+	act, found := actions[name]
+	if !found {
+		return
+	}
+
+	// Check if the interaction is from a bot admin
+	if !slices.Contains(config.Koolo.Discord.BotAdmins, interactionUserID(i)) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: ephemeral("You are not allowed to use this command."),
+		})
+		return
+	}
+
+	if !b.supervisorExists(supervisor) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: ephemeral("Supervisor '%s' not found.", supervisor),
+		})
+		return
+	}
+
+	if !act.slow {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: act.handler(b, supervisor),
+		})
+		return
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredChannelMessageWithSource}); err != nil {
+		return
+	}
+
+	data := act.handler(b, supervisor)
+	if data.Flags&discordgo.MessageFlagsEphemeral != 0 {
+		// A deferred response can't be turned into an ephemeral one, the "thinking" message is replaced by a follow-up
+		s.InteractionResponseDelete(i.Interaction)
+		s.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{Content: data.Content, Flags: data.Flags})
+		return
+	}
+
+	edit := &discordgo.WebhookEdit{Files: data.Files}
+	if data.Content != "" {
+		edit.Content = &data.Content
+	}
+	if len(data.Embeds) > 0 {
+		edit.Embeds = &data.Embeds
+	}
+	s.InteractionResponseEdit(i.Interaction, edit)
+}
+
+func (b *Bot) handleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var typed string
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Focused {
+			typed = strings.ToLower(opt.StringValue())
+		}
+	}
+
+	supervisors := b.manager.AvailableSupervisors()
+	slices.Sort(supervisors)
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, min(len(supervisors), maxAutocompleteChoices))
+	for _, supervisor := range supervisors {
+		if len(choices) == maxAutocompleteChoices {
+			break
+		}
+		if strings.Contains(strings.ToLower(supervisor), typed) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: supervisor, Value: supervisor})
+		}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	})
+}
+
+// interactionUserID returns who triggered the interaction, Member is only set in guilds and User in direct messages
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+
+	return ""
+}
+
+// ephemeral builds a response only visible to the user that triggered the interaction, used for errors
+func ephemeral(format string, args ...any) *discordgo.InteractionResponseData {
+	return &discordgo.InteractionResponseData{
+		Content: fmt.Sprintf(format, args...),
+		Flags:   discordgo.MessageFlagsEphemeral,
+	}
+}
+
+func reply(format string, args ...any) *discordgo.InteractionResponseData {
+	return &discordgo.InteractionResponseData{Content: fmt.Sprintf(format, args...)}
+}
+
 func (b *Bot) supervisorExists(supervisor string) bool {
 	supervisors := b.manager.AvailableSupervisors()
 	return slices.Contains(supervisors, supervisor)
 }
 
-func (b *Bot) handleStartRequest(s *discordgo.Session, m *discordgo.MessageCreate) {
+func (b *Bot) isRunning(supervisor string) bool {
+	status := b.manager.Status(supervisor).SupervisorStatus
+	return status != bot.NotStarted && status != ""
+}
 
-	// Start supervisor(s) provided as in the stop command
-	words := strings.Fields(m.Content)
+func (b *Bot) handleStartRequest(supervisor string) *discordgo.InteractionResponseData {
+	if b.isRunning(supervisor) {
+		return ephemeral("Supervisor '%s' is already running.", supervisor)
+	}
 
-	if len(words) > 1 {
-		// Iterate through the supervisors specified
-		for _, supervisor := range words[1:] {
+	if err := b.manager.Start(supervisor, false); err != nil {
+		return ephemeral("Supervisor '%s' could not be started: %s", supervisor, err)
+	}
 
-			if !b.supervisorExists(supervisor) {
-				s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Supervisor '%s' not found.", supervisor))
-				continue
-			}
+	return reply("Supervisor '%s' has been started.", supervisor)
+}
+
+func (b *Bot) handleRestartRequest(supervisor string) *discordgo.InteractionResponseData {
+	if b.isRunning(supervisor) {
+		b.manager.Stop(supervisor)
+	}
 
-			// Attempt to start the specified supervisor
-			b.manager.Start(supervisor, false)
+	if err := b.manager.Start(supervisor, false); err != nil {
+		return ephemeral("Supervisor '%s' could not be restarted: %s", supervisor, err)
+	}
 
-			// Wait for the supervisor to start
-			time.Sleep(1 * time.Second)
+	return reply("Supervisor '%s' has been restarted.", supervisor)
+}
 
-			// Send a confirmation message
-			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Supervisor '%s' has been started.", supervisor))
-		}
-	} else {
-		// If no supervisors were specified, send a usage message
-		s.ChannelMessageSend(m.ChannelID, "Usage: !start <supervisor1> [supervisor2] ...")
+func (b *Bot) handleStopRequest(supervisor string) *discordgo.InteractionResponseData {
+	if !b.isRunning(supervisor) {
+		return ephemeral("Supervisor '%s' is not running.", supervisor)
 	}
+
+	b.manager.Stop(supervisor)
+
+	return reply("Supervisor '%s' has been stopped.", supervisor)
 }
 
-func (b *Bot) handleStopRequest(s *discordgo.Session, m *discordgo.MessageCreate) {
+func (b *Bot) handlePauseRequest(supervisor string) *discordgo.InteractionResponseData {
+	if !b.isRunning(supervisor) {
+		return ephemeral("Supervisor '%s' is not running.", supervisor)
+	}
 
-	// Split the message content into words
-	words := strings.Fields(m.Content)
+	wasPaused := b.manager.Status(supervisor).SupervisorStatus == bot.Paused
+	b.manager.TogglePause(supervisor)
+	if wasPaused {
+		return reply("Supervisor '%s' has been resumed.", supervisor)
+	}
 
-	// Check if there are any supervisors specified after "!stop"
-	if len(words) > 1 {
-		// Iterate through the supervisors specified
-		for _, supervisor := range words[1:] {
+	return reply("Supervisor '%s' has been paused.", supervisor)
+}
 
-			if !b.supervisorExists(supervisor) {
-				s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Supervisor '%s' not found.", supervisor))
-				continue
-			}
+func (b *Bot) handleStatusRequest(supervisor string) *discordgo.InteractionResponseData {
+	if !b.isRunning(supervisor) {
+		return reply("Supervisor '%s' is offline.", supervisor)
+	}
 
-			// Check if the supervisor is running
-			if b.manager.Status(supervisor).SupervisorStatus == bot.NotStarted || b.manager.Status(supervisor).SupervisorStatus == "" {
-				s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Supervisor '%s' is not running.", supervisor))
-				continue
-			}
+	card, err := b.manager.StatusCard(supervisor)
+	if err != nil {
+		return ephemeral("Supervisor '%s' status could not be read: %s", supervisor, err)
+	}
 
-			// Attempt to stop the specified supervisor
-			b.manager.Stop(supervisor)
+	embed := statusCardEmbed(card)
+	embed.Description = b.manager.Status(supervisor).Details
 
-			// Wait for the supervisor to stop
-			time.Sleep(1 * time.Second)
+	return &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}}
+}
+
+// statusCardEmbed returns the status card as an embed, the game fields are skipped when not in game
+func statusCardEmbed(card bot.StatusCard) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:  fmt.Sprintf("%s is %s", card.Supervisor, card.Status),
+		Fields: []*discordgo.MessageEmbedField{},
+	}
 
-			// Send a confirmation message
-			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Supervisor '%s' has been stopped.", supervisor))
+	if card.InGame {
+		run := "-"
+		if card.Run != "" {
+			run = fmt.Sprintf("%s (%s)", card.Run, card.RunTime())
+		}
+		// Discord rejects the embeds with empty field values
+		areaName := "-"
+		if card.Area != "" {
+			areaName = card.Area
+		}
+		merc := "-"
+		if card.HasMerc {
+			merc = fmt.Sprintf("%d%%", card.MercHPPercent)
 		}
-	} else {
-		// If no supervisors were specified, send a usage message
-		s.ChannelMessageSend(m.ChannelID, "Usage: !stop <supervisor1> [supervisor2] ...")
+
+		embed.Fields = append(embed.Fields,
+			&discordgo.MessageEmbedField{Name: "Area", Value: areaName, Inline: true},
+			&discordgo.MessageEmbedField{Name: "Run", Value: run, Inline: true},
+			&discordgo.MessageEmbedField{Name: "Game time", Value: card.GameTime().String(), Inline: true},
+			&discordgo.MessageEmbedField{Name: "HP", Value: fmt.Sprintf("%d%%", card.HPPercent), Inline: true},
+			&discordgo.MessageEmbedField{Name: "MP", Value: fmt.Sprintf("%d%%", card.MPPercent), Inline: true},
+			&discordgo.MessageEmbedField{Name: "Merc HP", Value: merc, Inline: true},
+			&discordgo.MessageEmbedField{Name: "Gold", Value: fmt.Sprintf("%d", card.Gold), Inline: true},
+		)
 	}
-}
 
-func (b *Bot) handleStatusRequest(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if card.LastAction != "" {
+		action := fmt.Sprintf("`%s`", card.LastAction)
+		if card.LastStep != "" {
+			action += fmt.Sprintf(" / `%s`", card.LastStep)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Last action", Value: action})
+	}
 
-	// Split the message content into words
-	words := strings.Fields(m.Content)
+	return embed
+}
 
-	if len(words) > 1 {
-		for _, supervisor := range words[1:] {
-			if !b.supervisorExists(supervisor) {
-				s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Supervisor '%s' not found.", supervisor))
-				continue
-			}
+func (b *Bot) handleStatsRequest(supervisor string) *discordgo.InteractionResponseData {
+	stats := b.manager.GetSupervisorStats(supervisor)
 
-			status := b.manager.Status(supervisor)
-			if status.SupervisorStatus == bot.NotStarted || status.SupervisorStatus == "" {
-				s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Supervisor '%s' is offline.", supervisor))
-				continue
-			}
+	// Fix for the status not being started
+	supStatus, uptime := "Offline", "-"
+	if b.isRunning(supervisor) {
+		supStatus = string(b.manager.Status(supervisor).SupervisorStatus)
+		uptime = time.Since(b.manager.Status(supervisor).StartedAt).Round(time.Second).String()
+	}
 
-			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Supervisor '%s' is %s", supervisor, status.SupervisorStatus))
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("Stats for %s", supervisor),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Status", Value: supStatus, Inline: true},
+			{Name: "Uptime", Value: uptime, Inline: true},
+			{Name: "Games", Value: fmt.Sprintf("%d", stats.TotalGames()), Inline: true},
+			{Name: "Drops", Value: fmt.Sprintf("%d", len(stats.Drops)), Inline: true},
+			{Name: "Deaths", Value: fmt.Sprintf("%d", stats.TotalDeaths()), Inline: true},
+			{Name: "Chickens", Value: fmt.Sprintf("%d", stats.TotalChickens()), Inline: true},
+			{Name: "Errors", Value: fmt.Sprintf("%d", stats.TotalErrors()), Inline: true},
+		},
+	}
+	if exp := stats.ExperienceProgress(); exp.Tracked {
+		eta := "-"
+		if exp.NextLevelETAKnown {
+			eta = exp.NextLevelETA.Round(time.Minute).String()
 		}
-	} else {
-		// If no supervisors were specified, send a usage message
-		s.ChannelMessageSend(m.ChannelID, "Usage: !status <supervisor1> [supervisor2] ...")
+		embed.Fields = append(embed.Fields,
+			&discordgo.MessageEmbedField{Name: "Level", Value: fmt.Sprintf("%d (%.1f%%)", exp.Level, exp.NextLevelPercent), Inline: true},
+			&discordgo.MessageEmbedField{Name: "XP/h", Value: fmt.Sprintf("%.0f", exp.PerHour), Inline: true},
+			&discordgo.MessageEmbedField{Name: "Next level in", Value: eta, Inline: true},
+		)
 	}
+
+	return &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}}
 }
 
-func (b *Bot) handleStatsRequest(s *discordgo.Session, m *discordgo.MessageCreate) {
+func (b *Bot) handleDropsRequest(supervisor string) *discordgo.InteractionResponseData {
+	drops := b.manager.GetSupervisorStats(supervisor).Drops
+	if len(drops) == 0 {
+		return reply("No drops for '%s' yet.", supervisor)
+	}
 
-	// Split the message content into words
-	words := strings.Fields(m.Content)
+	lines := make([]string, 0, maxDrops)
+	for i := len(drops) - 1; i >= max(0, len(drops)-maxDrops); i-- {
+		item := drops[i].Item
+		name := item.IdentifiedName
+		if name == "" {
+			name = string(item.Name)
+		}
+		line := fmt.Sprintf("**%s** (%s)", name, item.Quality.ToString())
+		if drops[i].Rule != "" {
+			line += fmt.Sprintf("\n`%s`", drops[i].Rule)
+		}
+		lines = append(lines, line)
+	}
 
-	// Check if there are any supervisors specified after "!stats"
-	if len(words) > 1 {
-		// Iterate through the supervisors specified
-		for _, supervisor := range words[1:] {
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Drops for %s", supervisor),
+		Description: strings.Join(lines, "\n"),
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d drops in total", len(drops))},
+	}
 
-			if !b.supervisorExists(supervisor) {
-				s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Supervisor '%s' not found.", supervisor))
-				continue
-			}
+	return &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}}
+}
 
-			// Fix for the status not being started
-			supStatus := string(b.manager.Status(supervisor).SupervisorStatus)
-			if supStatus == string(bot.NotStarted) || supStatus == "" {
-				supStatus = "Offline"
-			}
-			// Create the embed
-			embed := &discordgo.MessageEmbed{
-				Title: fmt.Sprintf("Stats for %s", supervisor),
-				Fields: []*discordgo.MessageEmbedField{
-					{
-						Name:   "Status",
-						Value:  supStatus,
-						Inline: true,
-					},
-					{
-						Name:   "Uptime",
-						Value:  time.Since(b.manager.Status(supervisor).StartedAt).String(),
-						Inline: true,
-					},
-
-					// Runs data
-
-					{
-						Name:   "Games",
-						Value:  fmt.Sprintf("%d", b.manager.GetSupervisorStats(supervisor).TotalGames()),
-						Inline: true,
-					},
-					{
-						Name:   "Drops",
-						Value:  fmt.Sprintf("%d", len(b.manager.GetSupervisorStats(supervisor).Drops)),
-						Inline: true,
-					},
-					{
-						Name:   "Deaths",
-						Value:  fmt.Sprintf("%d", b.manager.GetSupervisorStats(supervisor).TotalDeaths()),
-						Inline: true,
-					},
-					{
-						Name:   "Chickens",
-						Value:  fmt.Sprintf("%d", b.manager.GetSupervisorStats(supervisor).TotalChickens()),
-						Inline: true,
-					},
-					{
-						Name:   "Errors",
-						Value:  fmt.Sprintf("%d", b.manager.GetSupervisorStats(supervisor).TotalErrors()),
-						Inline: true,
-					},
-				},
-			}
+func (b *Bot) handleScreenshotRequest(supervisor string) *discordgo.InteractionResponseData {
+	img, err := b.manager.Screenshot(supervisor)
+	if err != nil {
+		return ephemeral("Screenshot for '%s' could not be taken: %s", supervisor, err)
+	}
 
-			// Send the embed to the channel
-			s.ChannelMessageSendEmbed(m.ChannelID, embed)
-		}
-	} else {
-		// If no supervisors were specified, send a usage message
-		s.ChannelMessageSend(m.ChannelID, "Usage: !stats <supervisor1> [supervisor2] ...")
+	buf := new(bytes.Buffer)
+	if err = jpeg.Encode(buf, img, &jpeg.Options{Quality: 80}); err != nil {
+		return ephemeral("Screenshot for '%s' could not be taken: %s", supervisor, err)
+	}
+
+	return &discordgo.InteractionResponseData{
+		Content: supervisor,
+		Files:   []*discordgo.File{{Name: "Screenshot.jpeg", ContentType: "image/jpeg", Reader: buf}},
 	}
 }
@@ -13,34 +13,57 @@ import (
 
 func (b *Bot) Handle(_ context.Context, e event.Event) error {
 	if b.shouldPublish(e) {
+		return b.send(b.channelID, e)
+	}
 
-		switch evt := e.(type) {
-		case event.GameCreatedEvent:
-			message := fmt.Sprintf("%s\nGame: %s\nPassword: %s", evt.Message(), evt.Name, evt.Password)
-			_, err := b.discordSession.ChannelMessageSend(b.channelID, message)
-			return err
-		case event.GameFinishedEvent, event.RunStartedEvent, event.RunFinishedEvent:
-			_, err := b.discordSession.ChannelMessageSend(b.channelID, e.Message())
-			return err
-		default:
-			break
-		}
+	return nil
+}
 
-		buf := new(bytes.Buffer)
-		err := jpeg.Encode(buf, e.Image(), &jpeg.Options{Quality: 80})
-		if err != nil {
-			return err
-		}
+// Deliver sends the event routed by a notification rule, target is the channel ID (empty for the configured one)
+func (b *Bot) Deliver(_ context.Context, e event.Event, target string) error {
+	if target == "" {
+		target = b.channelID
+	}
+
+	return b.send(target, e)
+}
 
-		_, err = b.discordSession.ChannelMessageSendComplex(b.channelID, &discordgo.MessageSend{
-			File:    &discordgo.File{Name: "Screenshot.jpeg", ContentType: "image/jpeg", Reader: buf},
-			Content: e.Message(),
+func (b *Bot) send(channelID string, e event.Event) error {
+	switch evt := e.(type) {
+	case event.GameCreatedEvent:
+		message := fmt.Sprintf("%s\nGame: %s\nPassword: %s", evt.Message(), evt.Name, evt.Password)
+		_, err := b.discordSession.ChannelMessageSend(channelID, message)
+		return err
+	case event.GameFinishedEvent:
+		_, err := b.discordSession.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+			Content:    e.Message(),
+			Components: actionButtons(evt.Supervisor()),
 		})
+		return err
+	case event.RunStartedEvent, event.RunFinishedEvent, event.GoalReachedEvent, event.LevelUpEvent:
+		_, err := b.discordSession.ChannelMessageSend(channelID, e.Message())
+		return err
+	default:
+		break
+	}
 
+	if e.Image() == nil {
+		_, err := b.discordSession.ChannelMessageSend(channelID, e.Message())
 		return err
 	}
 
-	return nil
+	buf := new(bytes.Buffer)
+	err := jpeg.Encode(buf, e.Image(), &jpeg.Options{Quality: 80})
+	if err != nil {
+		return err
+	}
+
+	_, err = b.discordSession.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		File:    &discordgo.File{Name: "Screenshot.jpeg", ContentType: "image/jpeg", Reader: buf},
+		Content: e.Message(),
+	})
+
+	return err
 }
 
 func (b *Bot) shouldPublish(e event.Event) bool {
@@ -63,9 +86,27 @@ func (b *Bot) shouldPublish(e event.Event) bool {
 		return config.Koolo.Discord.EnableNewRunMessages
 	case event.RunFinishedEvent:
 		return config.Koolo.Discord.EnableRunFinishMessages
+	case event.GoalReachedEvent:
+		// Goals are always published, the user wants to know when the farming target is reached
+		return true
+	case event.LevelUpEvent:
+		// Level ups are rare and useful to tune the leveling runs, they are always published
+		return true
 	default:
 		break
 	}
 
 	return e.Image() != nil
 }
+
+// actionButtons are attached to the game finished messages (errors, chickens and deaths), they are handled as the
+// application commands with the same name
+func actionButtons(supervisor string) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Restart", Style: discordgo.PrimaryButton, CustomID: "restart:" + supervisor},
+			discordgo.Button{Label: "Pause", Style: discordgo.SecondaryButton, CustomID: "pause:" + supervisor},
+			discordgo.Button{Label: "Show screenshot", Style: discordgo.SecondaryButton, CustomID: "screenshot:" + supervisor},
+		}},
+	}
+}
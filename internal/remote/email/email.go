@@ -0,0 +1,358 @@
+// Package email sends the critical events by email as soon as they happen, and a daily summary of every supervisor
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hectorgimenez/koolo/internal/bot"
+	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/event"
+)
+
+const (
+	securityStartTLS = "starttls"
+	securityTLS      = "tls"
+	securityNone     = "none"
+
+	sendTimeout = time.Second * 10
+	// maxQueuedEmails is the max amount of events waiting to be sent, the oldest ones are dropped
+	maxQueuedEmails = 100
+)
+
+// criticalEvents are sent when no events are configured, they usually need someone to take a look at the bot
+var criticalEvents = []string{"death_loop", "crash_circuit_open", "stash_full", "goal_item_found"}
+
+// supervisorManager is the part of bot.SupervisorManager used by the daily summary
+type supervisorManager interface {
+	AvailableSupervisors() []string
+	GetSupervisorStats(supervisor string) bot.Stats
+}
+
+// queuedEmail is an event received from the listener, it's sent by Start
+type queuedEmail struct {
+	event event.Event
+	to    []string
+}
+
+// Notifier sends the events by email. The listener only queues the events, they are sent from Start so a slow SMTP
+// server doesn't block the other event handlers.
+type Notifier struct {
+	cfg         config.EmailCfg
+	events      []string
+	summaryTime time.Duration
+	summary     bool
+	tlsConfig   *tls.Config
+	manager     supervisorManager
+	logger      *slog.Logger
+	now         func() time.Time
+	wake        chan struct{}
+
+	queueMu sync.Mutex
+	queue   []queuedEmail
+
+	marksMu sync.Mutex
+	marks   map[string]summaryMark
+}
+
+func NewNotifier(cfg config.EmailCfg, manager *bot.SupervisorManager, logger *slog.Logger) (*Notifier, error) {
+	return newNotifier(cfg, manager, logger)
+}
+
+func newNotifier(cfg config.EmailCfg, manager supervisorManager, logger *slog.Logger) (*Notifier, error) {
+	if cfg.Host == "" || cfg.From == "" || len(cfg.To) == 0 {
+		return nil, errors.New("email host, from and to are required")
+	}
+
+	switch cfg.Security {
+	case "":
+		cfg.Security = securityStartTLS
+	case securityStartTLS, securityTLS, securityNone:
+	default:
+		return nil, fmt.Errorf("unknown email security %s, expected starttls, tls or none", cfg.Security)
+	}
+
+	if cfg.Port == 0 {
+		switch cfg.Security {
+		case securityStartTLS:
+			cfg.Port = 587
+		case securityTLS:
+			cfg.Port = 465
+		default:
+			cfg.Port = 25
+		}
+	}
+
+	n := &Notifier{
+		cfg:       cfg,
+		events:    cfg.Events,
+		tlsConfig: &tls.Config{ServerName: cfg.Host},
+		manager:   manager,
+		logger:    logger,
+		now:       time.Now,
+		wake:      make(chan struct{}, 1),
+		marks:     make(map[string]summaryMark),
+	}
+	if len(n.events) == 0 {
+		n.events = criticalEvents
+	}
+
+	if cfg.SummaryTime != "" {
+		t, err := time.Parse("15:04", cfg.SummaryTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid email summary time: %w", err)
+		}
+		n.summaryTime = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+		n.summary = true
+	}
+
+	return n, nil
+}
+
+func (n *Notifier) Handle(_ context.Context, e event.Event) error {
+	if !slices.Contains(n.events, event.Type(e)) {
+		return nil
+	}
+
+	n.enqueue(queuedEmail{event: e, to: n.cfg.To})
+
+	return nil
+}
+
+// Deliver sends the event routed by a notification rule, target is a comma separated list of addresses (empty for the
+// configured ones)
+func (n *Notifier) Deliver(_ context.Context, e event.Event, target string) error {
+	to := n.cfg.To
+	if target != "" {
+		to = nil
+		for _, address := range strings.Split(target, ",") {
+			if address = strings.TrimSpace(address); address != "" {
+				to = append(to, address)
+			}
+		}
+	}
+
+	n.enqueue(queuedEmail{event: e, to: to})
+
+	return nil
+}
+
+// enqueue adds the event to the queue and wakes up the sender, it's called from the listener goroutine so it must be
+// fast
+func (n *Notifier) enqueue(qe queuedEmail) {
+	n.queueMu.Lock()
+	n.queue = append(n.queue, qe)
+	if dropped := len(n.queue) - maxQueuedEmails; dropped > 0 {
+		n.queue = n.queue[dropped:]
+		n.logger.Warn("Email queue is full, dropping the oldest events", slog.Int("dropped", dropped))
+	}
+	n.queueMu.Unlock()
+
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// sendQueued sends the queued events in order, the ones failing are logged and dropped
+func (n *Notifier) sendQueued(ctx context.Context) {
+	n.queueMu.Lock()
+	queue := n.queue
+	n.queue = nil
+	n.queueMu.Unlock()
+
+	for _, qe := range queue {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := n.sendEvent(qe.event, qe.to); err != nil {
+			n.logger.Error("Error sending email", slog.String("supervisor", qe.event.Supervisor()), slog.String("event", event.Type(qe.event)), slog.Any("error", err))
+		}
+	}
+}
+
+func (n *Notifier) sendEvent(e event.Event, to []string) error {
+	title := eventTitle(e)
+	occurredAt := e.OccurredAt().Format("2006-01-02 15:04:05")
+
+	text := fmt.Sprintf("%s\n\nSupervisor: %s\nEvent: %s\nTime: %s\n", e.Message(), e.Supervisor(), event.Type(e), occurredAt)
+	body := fmt.Sprintf(
+		`<html><body style="font-family: sans-serif"><h3>%s</h3><p>%s</p><p>Supervisor: <b>%s</b><br>Event: %s<br>Time: %s</p></body></html>`,
+		html.EscapeString(title), html.EscapeString(e.Message()), html.EscapeString(e.Supervisor()), event.Type(e), occurredAt,
+	)
+
+	return n.send(message{
+		from:    n.cfg.From,
+		to:      to,
+		subject: fmt.Sprintf("[Koolo] %s: %s", e.Supervisor(), title),
+		text:    text,
+		html:    body,
+		image:   e.Image(),
+	})
+}
+
+func eventTitle(e event.Event) string {
+	switch evt := e.(type) {
+	case event.DeathLoopEvent:
+		return fmt.Sprintf("Died %d times in %s", evt.Deaths, evt.Window)
+	case event.CrashCircuitOpenEvent:
+		return fmt.Sprintf("Client crashed %d times in %s", evt.Crashes, evt.Window)
+	case event.StashFullEvent:
+		return "Stash is full"
+	case event.GoalItemFoundEvent:
+		return "Goal item found: " + evt.Goal.Name
+	case event.GoalReachedEvent:
+		return "Goal reached: " + evt.Goal.Name
+	case event.GameFinishedEvent:
+		return fmt.Sprintf("Game finished (%s)", evt.Reason)
+	case event.StuckEvent:
+		return "Stuck in " + evt.Area.Area().Name
+	case event.LevelUpEvent:
+		return fmt.Sprintf("Reached level %d", evt.Level)
+	}
+
+	return strings.ReplaceAll(event.Type(e), "_", " ")
+}
+
+// Start sends the queued events, and the daily summary at the configured time, until the context is finished
+func (n *Notifier) Start(ctx context.Context) error {
+	for {
+		n.sendQueued(ctx)
+
+		var summary <-chan time.Time
+		if n.summary {
+			summary = time.After(n.untilSummary())
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-n.wake:
+		case <-summary:
+			if err := n.sendSummary(); err != nil {
+				n.logger.Error("Error sending email summary", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// untilSummary returns the time until the next summary, today or tomorrow if the time already passed
+func (n *Notifier) untilSummary() time.Duration {
+	now := n.now()
+	next := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Add(n.summaryTime)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next.Sub(now)
+}
+
+// sendSummary sends the summary of every supervisor since the previous one
+func (n *Notifier) sendSummary() error {
+	n.marksMu.Lock()
+	supervisors := n.manager.AvailableSupervisors()
+	slices.Sort(supervisors)
+
+	data := summaryData{Date: n.now().Format("2006-01-02")}
+	for _, name := range supervisors {
+		summary, mark := buildSummary(name, n.manager.GetSupervisorStats(name), n.marks[name])
+		n.marks[name] = mark
+		if summary.Games > 0 || summary.TotalDrops > 0 {
+			data.Supervisors = append(data.Supervisors, summary)
+		}
+	}
+	n.marksMu.Unlock()
+
+	text, body := new(bytes.Buffer), new(bytes.Buffer)
+	if err := summaryText.Execute(text, data); err != nil {
+		return err
+	}
+	if err := summaryHTML.Execute(body, data); err != nil {
+		return err
+	}
+
+	return n.send(message{
+		from:    n.cfg.From,
+		to:      n.cfg.To,
+		subject: "[Koolo] Daily summary " + data.Date,
+		text:    text.String(),
+		html:    body.String(),
+	})
+}
+
+func (n *Notifier) send(m message) error {
+	content, err := m.bytes()
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(n.cfg.Host, strconv.Itoa(n.cfg.Port))
+	dialer := &net.Dialer{Timeout: sendTimeout}
+	var conn net.Conn
+	if n.cfg.Security == securityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, n.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("error connecting to %s: %w", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(sendTimeout))
+
+	c, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if n.cfg.Security == securityStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("the SMTP server doesn't support STARTTLS")
+		}
+		if err = c.StartTLS(n.tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	// PlainAuth refuses to send the password over an unencrypted connection, except to localhost
+	if n.cfg.Username != "" {
+		if err = c.Auth(smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err = c.Mail(n.cfg.From); err != nil {
+		return err
+	}
+	for _, to := range m.to {
+		if err = c.Rcpt(to); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", to, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(content); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
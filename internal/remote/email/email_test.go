@@ -0,0 +1,466 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"image"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/item"
+	"github.com/hectorgimenez/koolo/internal/bot"
+	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/event"
+)
+
+// receivedMail is a message accepted by the stand-in SMTP server
+type receivedMail struct {
+	auth    string
+	from    string
+	to      []string
+	data    string
+	secured bool
+}
+
+// smtpServer is a minimal SMTP server, enough for net/smtp. STARTTLS is offered when tlsConfig is set.
+type smtpServer struct {
+	t         *testing.T
+	ln        net.Listener
+	tlsConfig *tls.Config
+	mu        sync.Mutex
+	mails     []receivedMail
+}
+
+func newSMTPServer(t *testing.T, tlsConfig *tls.Config) *smtpServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	s := &smtpServer{t: t, ln: ln, tlsConfig: tlsConfig}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *smtpServer) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpServer) received() []receivedMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]receivedMail{}, s.mails...)
+}
+
+// waitReceived waits until the server received the given amount of emails
+func (s *smtpServer) waitReceived(t *testing.T, count int) []receivedMail {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second * 5)
+	for len(s.received()) < count {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d emails, received %d", count, len(s.received()))
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	return s.received()
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer func() { conn.Close() }()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		io.WriteString(conn, line+"\r\n")
+	}
+
+	var m receivedMail
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			if s.tlsConfig != nil && !m.secured {
+				reply("250-STARTTLS")
+			}
+			reply("250 AUTH PLAIN")
+		case "STARTTLS":
+			reply("220 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err = tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, r, m.secured = tlsConn, bufio.NewReader(tlsConn), true
+		case "AUTH":
+			_, encoded, _ := strings.Cut(arg, " ")
+			auth, _ := base64.StdEncoding.DecodeString(encoded)
+			m.auth = string(auth)
+			reply("235 Authentication successful")
+		case "MAIL":
+			m.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			reply("250 OK")
+		case "RCPT":
+			m.to = append(m.to, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var content strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				content.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			m.data = content.String()
+			s.mu.Lock()
+			s.mails = append(s.mails, m)
+			s.mu.Unlock()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+type fakeManager struct {
+	stats map[string]bot.Stats
+}
+
+func (m *fakeManager) AvailableSupervisors() []string {
+	names := make([]string, 0, len(m.stats))
+	for name := range m.stats {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func (m *fakeManager) GetSupervisorStats(supervisor string) bot.Stats {
+	return m.stats[supervisor]
+}
+
+func newTestNotifier(t *testing.T, server *smtpServer, cfg config.EmailCfg, manager *fakeManager) *Notifier {
+	t.Helper()
+
+	cfg.Host = "127.0.0.1"
+	cfg.Port = server.port()
+	cfg.From = "koolo@example.com"
+	if len(cfg.To) == 0 {
+		cfg.To = []string{"me@example.com"}
+	}
+	if manager == nil {
+		manager = &fakeManager{}
+	}
+
+	n, err := newNotifier(cfg, manager, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("creating notifier: %v", err)
+	}
+
+	return n
+}
+
+// start runs the notifier until the returned function is called, it waits for Start to return
+func start(n *Notifier) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		n.Start(ctx)
+		close(done)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// parsedMail is the decoded content of a received message
+type parsedMail struct {
+	header      mail.Header
+	text        string
+	html        string
+	attachments []string
+}
+
+func parseMail(t *testing.T, raw string) parsedMail {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+
+	parsed := parsedMail{header: msg.Header}
+	var walk func(contentType string, body io.Reader)
+	walk = func(contentType string, body io.Reader) {
+		mediaType, params, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			t.Fatalf("invalid content type %q: %v", contentType, err)
+		}
+		if !strings.HasPrefix(mediaType, "multipart/") {
+			t.Fatalf("expected a multipart body, got %s", mediaType)
+		}
+
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				t.Fatalf("reading part: %v", err)
+			}
+
+			partType := part.Header.Get("Content-Type")
+			switch {
+			case strings.HasPrefix(partType, "multipart/"):
+				walk(partType, part)
+			case strings.HasPrefix(partType, "text/plain"):
+				parsed.text = readText(part)
+			case strings.HasPrefix(partType, "text/html"):
+				parsed.html = readText(part)
+			default:
+				parsed.attachments = append(parsed.attachments, partType+" "+part.FileName())
+			}
+		}
+	}
+	walk(msg.Header.Get("Content-Type"), msg.Body)
+
+	return parsed
+}
+
+// readText decodes a text part, line breaks are CRLF in emails
+func readText(part io.Reader) string {
+	content, _ := io.ReadAll(quotedprintable.NewReader(part))
+
+	return strings.ReplaceAll(string(content), "\r\n", "\n")
+}
+
+func TestCriticalEventsOnly(t *testing.T) {
+	server := newSMTPServer(t, nil)
+	n := newTestNotifier(t, server, config.EmailCfg{Security: securityNone, Username: "user", Password: "secret"}, nil)
+
+	events := []event.Event{
+		event.GameCreated(event.Text("sorc", "New game created"), "koolo-1", ""),
+		event.StashFull(event.WithScreenshot("sorc", "Stash is full, Shako could not be stashed", image.NewRGBA(image.Rect(0, 0, 10, 10))), data.Item{Name: "Shako"}),
+		event.DeathLoop(event.Text("hammer", "Character died 3 times in 30m0s"), 3, time.Minute*30),
+	}
+	for _, e := range events {
+		if err := n.Handle(context.Background(), e); err != nil {
+			t.Fatalf("handling %T: %v", e, err)
+		}
+	}
+
+	// The events are queued by the listener and sent by Start
+	if len(server.received()) != 0 {
+		t.Fatalf("nothing must be sent before the notifier is started")
+	}
+	stop := start(n)
+	server.waitReceived(t, 2)
+	stop()
+
+	mails := server.received()
+	if len(mails) != 2 {
+		t.Fatalf("expected 2 emails for the critical events, got %d", len(mails))
+	}
+	if mails[0].auth != "\x00user\x00secret" || mails[0].from != "koolo@example.com" || strings.Join(mails[0].to, ",") != "me@example.com" {
+		t.Errorf("unexpected envelope, auth %q, from %s, to %v", mails[0].auth, mails[0].from, mails[0].to)
+	}
+
+	stashFull := parseMail(t, mails[0].data)
+	subject, _ := new(mime.WordDecoder).DecodeHeader(stashFull.header.Get("Subject"))
+	if subject != "[Koolo] sorc: Stash is full" {
+		t.Errorf("unexpected subject %q", subject)
+	}
+	if !strings.HasPrefix(stashFull.text, "Stash is full, Shako could not be stashed\n\nSupervisor: sorc\nEvent: stash_full\n") {
+		t.Errorf("unexpected text body %q", stashFull.text)
+	}
+	if !strings.Contains(stashFull.html, "<h3>Stash is full</h3>") {
+		t.Errorf("unexpected html body %q", stashFull.html)
+	}
+	if strings.Join(stashFull.attachments, ",") != "image/jpeg screenshot.jpeg" {
+		t.Errorf("expected the screenshot attached, got %v", stashFull.attachments)
+	}
+
+	deathLoop := parseMail(t, mails[1].data)
+	subject, _ = new(mime.WordDecoder).DecodeHeader(deathLoop.header.Get("Subject"))
+	if subject != "[Koolo] hammer: Died 3 times in 30m0s" || len(deathLoop.attachments) != 0 {
+		t.Errorf("unexpected death loop email %q, attachments %v", subject, deathLoop.attachments)
+	}
+}
+
+func TestStartTLSAndDeliverTarget(t *testing.T) {
+	// The httptest certificate is valid for 127.0.0.1
+	tlsServer := httptest.NewTLSServer(nil)
+	defer tlsServer.Close()
+	server := newSMTPServer(t, tlsServer.TLS)
+
+	n := newTestNotifier(t, server, config.EmailCfg{Security: securityStartTLS, Username: "user", Password: "secret"}, nil)
+	roots := x509.NewCertPool()
+	roots.AddCert(tlsServer.Certificate())
+	n.tlsConfig.RootCAs = roots
+
+	stop := start(n)
+	err := n.Deliver(context.Background(), event.Text("sorc", "Routed"), "a@example.com, b@example.com")
+	if err != nil {
+		t.Fatalf("delivering: %v", err)
+	}
+
+	mails := server.waitReceived(t, 1)
+	stop()
+	if len(mails) != 1 || !mails[0].secured || strings.Join(mails[0].to, ",") != "a@example.com,b@example.com" {
+		t.Fatalf("expected a single email over TLS to the target addresses, got %+v", mails)
+	}
+
+	// A server without STARTTLS is refused instead of sending the password in clear
+	plain := newSMTPServer(t, nil)
+	n = newTestNotifier(t, plain, config.EmailCfg{Security: securityStartTLS}, nil)
+	if err = n.sendEvent(event.Text("sorc", "Routed"), n.cfg.To); err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("expected a STARTTLS error, got %v", err)
+	}
+	if len(plain.received()) != 0 {
+		t.Errorf("nothing must be sent without STARTTLS")
+	}
+}
+
+func TestDailySummary(t *testing.T) {
+	server := newSMTPServer(t, nil)
+	startedAt := time.Now().Add(-time.Hour * 5)
+	now := time.Now()
+	stats := bot.Stats{
+		StartedAt:    startedAt,
+		StartingGold: 1000,
+		CurrentGold:  51000,
+		GoldTracked:  true,
+		Games: []bot.GameStats{
+			{StartedAt: now, FinishedAt: now, Runs: []bot.RunStats{
+				{Name: "mephisto", Reason: event.FinishedOK},
+				{Name: "andariel", Reason: event.FinishedOK},
+			}},
+			{StartedAt: now, FinishedAt: now, Runs: []bot.RunStats{
+				{Name: "mephisto", Reason: event.FinishedChicken},
+			}},
+			{StartedAt: now, FinishedAt: now, Runs: []bot.RunStats{
+				{Name: "andariel", Reason: event.FinishedOK},
+				{Name: "mephisto", Reason: event.FinishedDied},
+			}},
+			// In progress, left for the next summary
+			{StartedAt: now, Runs: []bot.RunStats{{Name: "mephisto"}}},
+		},
+		Drops: []data.Drop{
+			{Item: data.Item{Name: "Ring", Quality: item.QualityRare}},
+			{Item: data.Item{Name: "Shako", Quality: item.QualityUnique}},
+			{Item: data.Item{Name: "Amulet", Quality: item.QualityRare}},
+		},
+	}
+	manager := &fakeManager{stats: map[string]bot.Stats{"sorc": stats, "idle": {StartedAt: startedAt}}}
+	n := newTestNotifier(t, server, config.EmailCfg{Security: securityNone, SummaryTime: "21:00"}, manager)
+
+	if err := n.sendSummary(); err != nil {
+		t.Fatalf("sending summary: %v", err)
+	}
+
+	// The game in progress finishes, the next summary only has that one
+	stats.Games[3].FinishedAt = time.Now()
+	stats.Games[3].Runs[0].Reason = event.FinishedOK
+	stats.CurrentGold = 61000
+	manager.stats["sorc"] = stats
+	if err := n.sendSummary(); err != nil {
+		t.Fatalf("sending summary: %v", err)
+	}
+
+	mails := server.received()
+	if len(mails) != 2 {
+		t.Fatalf("expected 2 summaries, got %d", len(mails))
+	}
+
+	first := parseMail(t, mails[0].data)
+	expected := "Koolo daily summary - " + now.Format("2006-01-02") + `
+
+sorc
+  Games: 3
+  Runs: 5 (60.0% successful)
+    andariel: 2/2
+    mephisto: 1/3
+  Deaths: 1, chickens: 1, errors: 0
+  Drops: 3, 1 Unique, 2 Rare
+  Gold earned: 50000
+`
+	if first.text != expected {
+		t.Errorf("unexpected summary:\n%s\nexpected:\n%s", first.text, expected)
+	}
+	if !strings.Contains(first.html, "<h3>sorc</h3>") || strings.Contains(first.html, "idle") {
+		t.Errorf("unexpected html summary %q", first.html)
+	}
+
+	second := parseMail(t, mails[1].data)
+	if !strings.Contains(second.text, "Games: 1\n  Runs: 1 (100.0% successful)\n    mephisto: 1/1\n") || !strings.Contains(second.text, "Drops: 0\n  Gold earned: 10000") {
+		t.Errorf("the second summary must only include what happened since the first one:\n%s", second.text)
+	}
+}
+
+func TestUntilSummary(t *testing.T) {
+	n := newTestNotifier(t, newSMTPServer(t, nil), config.EmailCfg{Security: securityNone, SummaryTime: "21:00"}, nil)
+
+	for now, expected := range map[string]time.Duration{
+		"2024-05-01 20:30": time.Minute * 30,
+		"2024-05-01 21:00": time.Hour * 24,
+		"2024-05-01 22:00": time.Hour * 23,
+	} {
+		n.now = func() time.Time {
+			t, _ := time.ParseInLocation("2006-01-02 15:04", now, time.Local)
+			return t
+		}
+		if got := n.untilSummary(); got != expected {
+			t.Errorf("%s: expected %s, got %s", now, expected, got)
+		}
+	}
+
+	if _, err := newNotifier(config.EmailCfg{Host: "localhost", From: "a@b", To: []string{"c@d"}, Security: "ssl"}, &fakeManager{}, nil); err == nil {
+		t.Errorf("unknown security must be rejected")
+	}
+	if _, err := newNotifier(config.EmailCfg{Host: "localhost", From: "a@b", To: []string{"c@d"}, SummaryTime: "9pm"}, &fakeManager{}, nil); err == nil {
+		t.Errorf("invalid summary time must be rejected")
+	}
+}
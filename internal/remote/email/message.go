@@ -0,0 +1,128 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// message is an email with a text and an HTML body, clients show the best one they support
+type message struct {
+	from    string
+	to      []string
+	subject string
+	text    string
+	html    string
+	image   image.Image
+}
+
+// bytes returns the message in RFC 5322 format, the image (if any) is attached as JPEG
+func (m message) bytes() ([]byte, error) {
+	body := new(bytes.Buffer)
+	alternative := multipart.NewWriter(body)
+	if err := writeText(alternative, "text/plain", m.text); err != nil {
+		return nil, err
+	}
+	if err := writeText(alternative, "text/html", m.html); err != nil {
+		return nil, err
+	}
+	if err := alternative.Close(); err != nil {
+		return nil, err
+	}
+	contentType := "multipart/alternative; boundary=" + alternative.Boundary()
+
+	// With an attachment the alternative bodies are the first part of a mixed message
+	if m.image != nil {
+		mixedBody := new(bytes.Buffer)
+		mixed := multipart.NewWriter(mixedBody)
+		part, err := mixed.CreatePart(textproto.MIMEHeader{"Content-Type": {contentType}})
+		if err != nil {
+			return nil, err
+		}
+		if _, err = body.WriteTo(part); err != nil {
+			return nil, err
+		}
+		if err = writeImage(mixed, m.image); err != nil {
+			return nil, err
+		}
+		if err = mixed.Close(); err != nil {
+			return nil, err
+		}
+		body, contentType = mixedBody, "multipart/mixed; boundary="+mixed.Boundary()
+	}
+
+	msg := new(bytes.Buffer)
+	fmt.Fprintf(msg, "From: %s\r\n", m.from)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(m.to, ", "))
+	fmt.Fprintf(msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.subject))
+	fmt.Fprintf(msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(msg, "Message-ID: <%s@koolo>\r\n", randomID())
+	fmt.Fprintf(msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(msg, "Content-Type: %s\r\n\r\n", contentType)
+	body.WriteTo(msg)
+
+	return msg.Bytes(), nil
+}
+
+func writeText(w *multipart.Writer, contentType, content string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType + "; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+
+	qp := quotedprintable.NewWriter(part)
+	if _, err = io.WriteString(qp, content); err != nil {
+		return err
+	}
+
+	return qp.Close()
+}
+
+func writeImage(w *multipart.Writer, img image.Image) error {
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, img, &jpeg.Options{Quality: 80}); err != nil {
+		return err
+	}
+
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"image/jpeg"},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {`attachment; filename="screenshot.jpeg"`},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Lines can't be longer than 76 characters
+	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
+	for len(encoded) > 76 {
+		if _, err = io.WriteString(part, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = io.WriteString(part, encoded+"\r\n")
+
+	return err
+}
+
+func randomID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
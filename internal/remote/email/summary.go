@@ -0,0 +1,165 @@
+package email
+
+import (
+	htmltemplate "html/template"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data/item"
+	"github.com/hectorgimenez/koolo/internal/bot"
+	"github.com/hectorgimenez/koolo/internal/event"
+)
+
+// summaryMark is what was already included in the previous summary of a supervisor, the next one starts from there
+type summaryMark struct {
+	startedAt time.Time
+	games     int
+	drops     int
+	gold      int
+	goldSet   bool
+}
+
+type runSummary struct {
+	Name       string
+	Total      int
+	Successful int
+}
+
+type qualitySummary struct {
+	Quality string
+	Count   int
+}
+
+type supervisorSummary struct {
+	Name           string
+	Games          int
+	Runs           []runSummary
+	TotalRuns      int
+	SuccessfulRuns int
+	Deaths         int
+	Chickens       int
+	Errors         int
+	Drops          []qualitySummary
+	TotalDrops     int
+	GoldEarned     int
+}
+
+func (s supervisorSummary) SuccessRate() float64 {
+	if s.TotalRuns == 0 {
+		return 0
+	}
+
+	return float64(s.SuccessfulRuns) / float64(s.TotalRuns) * 100
+}
+
+// buildSummary summarizes the finished games and the drops since the given mark, and returns the mark for the next one.
+// Stats are reset when the supervisor is restarted, in that case everything since the restart is included.
+func buildSummary(name string, stats bot.Stats, mark summaryMark) (supervisorSummary, summaryMark) {
+	if !mark.startedAt.Equal(stats.StartedAt) {
+		mark = summaryMark{startedAt: stats.StartedAt}
+	}
+	if !mark.goldSet && stats.GoldTracked {
+		mark.gold, mark.goldSet = stats.StartingGold, true
+	}
+
+	// The game in progress is left for the next summary
+	finished := min(mark.games, len(stats.Games))
+	for finished < len(stats.Games) && !stats.Games[finished].FinishedAt.IsZero() {
+		finished++
+	}
+
+	summary := supervisorSummary{Name: name}
+	runs := make(map[string]*runSummary)
+	for _, g := range stats.Games[min(mark.games, finished):finished] {
+		summary.Games++
+		for _, r := range g.Runs {
+			rs, found := runs[r.Name]
+			if !found {
+				rs = &runSummary{Name: r.Name}
+				runs[r.Name] = rs
+			}
+			rs.Total++
+			summary.TotalRuns++
+
+			switch r.Reason {
+			case event.FinishedOK:
+				rs.Successful++
+				summary.SuccessfulRuns++
+			case event.FinishedDied:
+				summary.Deaths++
+			case event.FinishedChicken, event.FinishedMercChicken:
+				summary.Chickens++
+			case event.FinishedError:
+				summary.Errors++
+			}
+		}
+	}
+	for _, rs := range runs {
+		summary.Runs = append(summary.Runs, *rs)
+	}
+	slices.SortFunc(summary.Runs, func(a, b runSummary) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	// Best qualities first
+	byQuality := make(map[item.Quality]int)
+	for _, d := range stats.Drops[min(mark.drops, len(stats.Drops)):] {
+		byQuality[d.Item.Quality]++
+		summary.TotalDrops++
+	}
+	for q := item.QualityCrafted; q >= item.QualityLowQuality; q-- {
+		if byQuality[q] > 0 {
+			summary.Drops = append(summary.Drops, qualitySummary{Quality: q.ToString(), Count: byQuality[q]})
+		}
+	}
+
+	next := summaryMark{startedAt: stats.StartedAt, games: finished, drops: len(stats.Drops), gold: mark.gold, goldSet: mark.goldSet}
+	if mark.goldSet {
+		summary.GoldEarned = stats.CurrentGold - mark.gold
+		next.gold = stats.CurrentGold
+	}
+
+	return summary, next
+}
+
+type summaryData struct {
+	Date        string
+	Supervisors []supervisorSummary
+}
+
+var summaryText = template.Must(template.New("summary").Parse(`Koolo daily summary - {{.Date}}
+{{range .Supervisors}}
+{{.Name}}
+  Games: {{.Games}}
+  Runs: {{.TotalRuns}} ({{printf "%.1f" .SuccessRate}}% successful)
+{{- range .Runs}}
+    {{.Name}}: {{.Successful}}/{{.Total}}
+{{- end}}
+  Deaths: {{.Deaths}}, chickens: {{.Chickens}}, errors: {{.Errors}}
+  Drops: {{.TotalDrops}}{{range .Drops}}, {{.Count}} {{.Quality}}{{end}}
+  Gold earned: {{.GoldEarned}}
+{{else}}
+No games were played.
+{{end}}`))
+
+var summaryHTML = htmltemplate.Must(htmltemplate.New("summary").Parse(`<html><body style="font-family: sans-serif">
+<h2>Koolo daily summary - {{.Date}}</h2>
+{{range .Supervisors}}
+<h3>{{.Name}}</h3>
+<table cellpadding="4">
+<tr><td>Games</td><td><b>{{.Games}}</b></td></tr>
+<tr><td>Runs</td><td><b>{{.TotalRuns}}</b> ({{printf "%.1f" .SuccessRate}}% successful)</td></tr>
+{{range .Runs}}<tr><td>&nbsp;&nbsp;{{.Name}}</td><td>{{.Successful}}/{{.Total}}</td></tr>
+{{end}}<tr><td>Deaths</td><td>{{.Deaths}}</td></tr>
+<tr><td>Chickens</td><td>{{.Chickens}}</td></tr>
+<tr><td>Errors</td><td>{{.Errors}}</td></tr>
+<tr><td>Drops</td><td><b>{{.TotalDrops}}</b>{{range .Drops}}, {{.Count}} {{.Quality}}{{end}}</td></tr>
+<tr><td>Gold earned</td><td><b>{{.GoldEarned}}</b></td></tr>
+</table>
+{{else}}
+<p>No games were played.</p>
+{{end}}
+</body></html>
+`))
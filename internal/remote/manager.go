@@ -0,0 +1,20 @@
+// Package remote contains what is shared by the integrations controlling the supervisors remotely
+package remote
+
+import (
+	"image"
+
+	"github.com/hectorgimenez/koolo/internal/bot"
+)
+
+// SupervisorManager is the part of bot.SupervisorManager used by the remote commands, tests use a fake one
+type SupervisorManager interface {
+	AvailableSupervisors() []string
+	Start(supervisorName string, attachToExisting bool, pidHwnd ...uint32) error
+	Stop(supervisor string)
+	TogglePause(supervisor string)
+	Status(characterName string) bot.Stats
+	GetSupervisorStats(supervisor string) bot.Stats
+	StatusCard(supervisor string) (bot.StatusCard, error)
+	Screenshot(supervisor string) (image.Image, error)
+}
@@ -0,0 +1,65 @@
+// Package remotetest contains the fakes used to test the remote integrations
+package remotetest
+
+import (
+	"image"
+
+	"github.com/hectorgimenez/koolo/internal/bot"
+)
+
+// FakeManager implements remote.SupervisorManager, supervisors are the ones in Stats and only the ones with a status
+// card are in game. Calls changing the supervisors are recorded.
+type FakeManager struct {
+	Stats   map[string]bot.Stats
+	Cards   map[string]bot.StatusCard
+	Started []string
+	Stopped []string
+	Paused  []string
+}
+
+func (m *FakeManager) AvailableSupervisors() []string {
+	names := make([]string, 0, len(m.Stats))
+	for name := range m.Stats {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func (m *FakeManager) Start(supervisorName string, _ bool, _ ...uint32) error {
+	m.Started = append(m.Started, supervisorName)
+	return nil
+}
+
+func (m *FakeManager) Stop(supervisor string) {
+	m.Stopped = append(m.Stopped, supervisor)
+}
+
+func (m *FakeManager) TogglePause(supervisor string) {
+	m.Paused = append(m.Paused, supervisor)
+}
+
+func (m *FakeManager) Status(characterName string) bot.Stats {
+	return m.Stats[characterName]
+}
+
+func (m *FakeManager) GetSupervisorStats(supervisor string) bot.Stats {
+	return m.Stats[supervisor]
+}
+
+func (m *FakeManager) StatusCard(supervisor string) (bot.StatusCard, error) {
+	card, found := m.Cards[supervisor]
+	if !found {
+		return bot.StatusCard{}, bot.ErrSupervisorNotRunning
+	}
+
+	return card, nil
+}
+
+func (m *FakeManager) Screenshot(supervisor string) (image.Image, error) {
+	if _, found := m.Cards[supervisor]; !found {
+		return nil, bot.ErrSupervisorNotRunning
+	}
+
+	return image.NewRGBA(image.Rect(0, 0, 10, 10)), nil
+}
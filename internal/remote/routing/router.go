@@ -0,0 +1,299 @@
+// Package routing decides which notifier gets every event and where it's sent, based on the notification rules shared
+// by all the notifiers. It also applies the rate limits, the aggregation and the quiet hours.
+package routing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/item"
+	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/event"
+)
+
+// DeliverFunc sends the event to the given target (channel, chat, URL...), empty target means the notifier default one.
+// Unlike the notifier Handle it doesn't apply the notifier own filters, the rule already decided to send it.
+type DeliverFunc func(ctx context.Context, e event.Event, target string) error
+
+// ItemValueFunc returns the gold a vendor pays to the supervisor for the item, false if it's unknown
+type ItemValueFunc func(supervisor string, itm data.Item) (int, bool)
+
+type notifier struct {
+	name    string
+	handle  event.Handler
+	deliver DeliverFunc
+}
+
+type Router struct {
+	rules      []config.NotificationRule
+	quietStart time.Duration
+	quietEnd   time.Duration
+	quiet      bool
+	notifiers  []notifier
+	logger     *slog.Logger
+	now        func() time.Time
+	itemValue  ItemValueFunc
+
+	mu sync.Mutex
+	// sent are the delivery times by rule and notifier, used for the rate limits
+	sent map[string][]time.Time
+	// aggregations are the events being collected by rule, notifier and supervisor
+	aggregations map[string][]event.Event
+}
+
+func NewRouter(cfg config.NotificationsCfg, logger *slog.Logger) (*Router, error) {
+	r := &Router{
+		rules:        cfg.Rules,
+		logger:       logger,
+		now:          time.Now,
+		itemValue:    func(string, data.Item) (int, bool) { return 0, false },
+		sent:         make(map[string][]time.Time),
+		aggregations: make(map[string][]event.Event),
+	}
+
+	for i, rule := range cfg.Rules {
+		if rule.MinQuality != "" && parseQuality(rule.MinQuality) == 0 {
+			return nil, fmt.Errorf("notification rule %d (%s): unknown quality %s", i, rule.Name, rule.MinQuality)
+		}
+		if rule.MinValue < 0 {
+			return nil, fmt.Errorf("notification rule %d (%s): minValue can't be negative", i, rule.Name)
+		}
+		if rule.RateLimit > 0 && rule.RatePeriod <= 0 {
+			return nil, fmt.Errorf("notification rule %d (%s): rateLimit requires ratePeriod", i, rule.Name)
+		}
+	}
+
+	if cfg.QuietHours.Enabled {
+		var err error
+		if r.quietStart, err = parseClock(cfg.QuietHours.Start); err != nil {
+			return nil, fmt.Errorf("invalid quiet hours start: %w", err)
+		}
+		if r.quietEnd, err = parseClock(cfg.QuietHours.End); err != nil {
+			return nil, fmt.Errorf("invalid quiet hours end: %w", err)
+		}
+		r.quiet = true
+	}
+
+	return r, nil
+}
+
+// SetItemValue sets where the item values used by the minValue conditions come from, without it they never match
+func (r *Router) SetItemValue(itemValue ItemValueFunc) {
+	r.itemValue = itemValue
+}
+
+// Register adds a notifier, handle is used for the events not matching any rule
+func (r *Router) Register(name string, handle event.Handler, deliver DeliverFunc) {
+	r.notifiers = append(r.notifiers, notifier{name: name, handle: handle, deliver: deliver})
+}
+
+// Handle routes the event to every registered notifier, it's registered in the event listener instead of the notifiers
+func (r *Router) Handle(ctx context.Context, e event.Event) error {
+	var errs []string
+	for _, n := range r.notifiers {
+		if err := r.route(ctx, n, e); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", n.name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("error sending notifications: %s", strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (r *Router) route(ctx context.Context, n notifier, e event.Event) error {
+	idx := slices.IndexFunc(r.rules, func(rule config.NotificationRule) bool {
+		return r.matches(rule, n.name, e)
+	})
+
+	if idx == -1 {
+		if r.quietNow() {
+			return nil
+		}
+		return n.handle(ctx, e)
+	}
+
+	rule := r.rules[idx]
+	if rule.Mute || (!rule.Critical && r.quietNow()) {
+		return nil
+	}
+
+	key := fmt.Sprintf("%d:%s", idx, n.name)
+	if !r.allow(key, rule) {
+		r.logger.Debug("Notification rate limited", slog.String("rule", rule.Name), slog.String("notifier", n.name), slog.String("event", event.Type(e)))
+		return nil
+	}
+
+	if rule.Aggregate > 0 {
+		r.aggregate(key+":"+e.Supervisor(), rule, n, e)
+		return nil
+	}
+
+	return n.deliver(ctx, e, rule.Target)
+}
+
+// allow checks and registers the delivery against the rule rate limit
+func (r *Router) allow(key string, rule config.NotificationRule) bool {
+	if rule.RateLimit <= 0 {
+		return true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	sent := slices.DeleteFunc(r.sent[key], func(t time.Time) bool {
+		return now.Sub(t) >= rule.RatePeriod
+	})
+	if len(sent) >= rule.RateLimit {
+		r.sent[key] = sent
+		return false
+	}
+	r.sent[key] = append(sent, now)
+
+	return true
+}
+
+// aggregate collects the event, the first one starts the aggregation window and the summary is sent when it finishes
+func (r *Router) aggregate(key string, rule config.NotificationRule, n notifier, e event.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if events, found := r.aggregations[key]; found {
+		r.aggregations[key] = append(events, e)
+		return
+	}
+
+	r.aggregations[key] = []event.Event{e}
+	time.AfterFunc(rule.Aggregate, func() {
+		r.mu.Lock()
+		events := r.aggregations[key]
+		delete(r.aggregations, key)
+		r.mu.Unlock()
+
+		if err := n.deliver(context.Background(), summary(events, rule.Aggregate), rule.Target); err != nil {
+			r.logger.Error("error sending aggregated notification", slog.String("notifier", n.name), slog.Any("error", err))
+		}
+	})
+}
+
+// summary returns the only event if there is one, or a text event like "5 chicken (game_finished) in 10m0s"
+func summary(events []event.Event, window time.Duration) event.Event {
+	if len(events) == 1 {
+		return events[0]
+	}
+
+	last := events[len(events)-1]
+	label := event.Type(last)
+	if reason := finishReason(last); reason != "" {
+		label = fmt.Sprintf("%s (%s)", reason, label)
+	}
+
+	return event.Text(last.Supervisor(), fmt.Sprintf("%d %s in %s, last one: %s", len(events), label, window, last.Message()))
+}
+
+func (r *Router) quietNow() bool {
+	if !r.quiet {
+		return false
+	}
+
+	now := r.now()
+	clock := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute
+	if r.quietStart <= r.quietEnd {
+		return clock >= r.quietStart && clock < r.quietEnd
+	}
+
+	// The range goes through midnight
+	return clock >= r.quietStart || clock < r.quietEnd
+}
+
+func (r *Router) matches(rule config.NotificationRule, notifierName string, e event.Event) bool {
+	if len(rule.Notifiers) > 0 && !slices.Contains(rule.Notifiers, notifierName) {
+		return false
+	}
+	if len(rule.Events) > 0 && !slices.Contains(rule.Events, event.Type(e)) {
+		return false
+	}
+	if len(rule.Supervisors) > 0 && !slices.Contains(rule.Supervisors, e.Supervisor()) {
+		return false
+	}
+	if len(rule.Reasons) > 0 && !slices.Contains(rule.Reasons, finishReason(e)) {
+		return false
+	}
+
+	if rule.MinQuality == "" && rule.MinValue == 0 && len(rule.Items) == 0 {
+		return true
+	}
+
+	itm, found := eventItem(e)
+	if !found {
+		return false
+	}
+	if rule.MinQuality != "" && itm.Quality < parseQuality(rule.MinQuality) {
+		return false
+	}
+	if rule.MinValue > 0 {
+		if value, found := r.itemValue(e.Supervisor(), itm); !found || value < rule.MinValue {
+			return false
+		}
+	}
+	if len(rule.Items) > 0 && !slices.ContainsFunc(rule.Items, func(name string) bool {
+		return strings.EqualFold(name, string(itm.Name)) || strings.EqualFold(name, itm.IdentifiedName)
+	}) {
+		return false
+	}
+
+	return true
+}
+
+func finishReason(e event.Event) string {
+	switch evt := e.(type) {
+	case event.GameFinishedEvent:
+		return string(evt.Reason)
+	case event.RunFinishedEvent:
+		return string(evt.Reason)
+	}
+
+	return ""
+}
+
+func eventItem(e event.Event) (data.Item, bool) {
+	switch evt := e.(type) {
+	case event.ItemStashedEvent:
+		return evt.Item.Item, true
+	case event.ItemBlackListedEvent:
+		return evt.Item.Item, true
+	case event.ItemGambledEvent:
+		return evt.Item, true
+	}
+
+	return data.Item{}, false
+}
+
+// parseQuality returns the quality by name (magic, rare, unique...), 0 if it's unknown
+func parseQuality(name string) item.Quality {
+	for q := item.QualityLowQuality; q <= item.QualityCrafted; q++ {
+		if strings.EqualFold(q.ToString(), name) {
+			return q
+		}
+	}
+
+	return 0
+}
+
+func parseClock(clock string) (time.Duration, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -0,0 +1,276 @@
+package routing
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/item"
+	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/event"
+)
+
+// recorder is a notifier keeping what the router sent to it, "handle" for the events not matching any rule and
+// "deliver:<target>" for the delivered ones
+type recorder struct {
+	mu     sync.Mutex
+	sent   []string
+	events []event.Event
+}
+
+func (rc *recorder) handle(_ context.Context, e event.Event) error {
+	rc.record("handle", e)
+	return nil
+}
+
+func (rc *recorder) deliver(_ context.Context, e event.Event, target string) error {
+	rc.record("deliver:"+target, e)
+	return nil
+}
+
+func (rc *recorder) record(result string, e event.Event) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.sent = append(rc.sent, result)
+	rc.events = append(rc.events, e)
+}
+
+// last returns the result of the last event and resets the recorder, empty if the event was dropped
+func (rc *recorder) last() string {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if len(rc.sent) == 0 {
+		return ""
+	}
+	result := rc.sent[len(rc.sent)-1]
+	rc.sent, rc.events = nil, nil
+
+	return result
+}
+
+func newTestRouter(t *testing.T, cfg config.NotificationsCfg, notifierName string) (*Router, *recorder) {
+	t.Helper()
+
+	r, err := NewRouter(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	rc := &recorder{}
+	r.Register(notifierName, rc.handle, rc.deliver)
+
+	return r, rc
+}
+
+func stashed(supervisor string, name item.Name, quality item.Quality) event.Event {
+	return event.ItemStashed(event.Text(supervisor, "Item stashed"), data.Drop{Item: data.Item{Name: name, Quality: quality}}, false, "", time.Time{}, 0)
+}
+
+func gameFinished(supervisor string, reason event.FinishReason) event.Event {
+	return event.GameFinished(event.Text(supervisor, "Game finished"), reason)
+}
+
+func TestRuleOrder(t *testing.T) {
+	cfg := config.NotificationsCfg{Rules: []config.NotificationRule{
+		{Name: "mute telegram stuck", Notifiers: []string{"telegram"}, Events: []string{"stuck"}, Mute: true},
+		{Name: "uniques", Events: []string{"item_stashed"}, MinQuality: "unique", Target: "uniques"},
+		{Name: "valuable", Events: []string{"item_stashed"}, MinValue: 1000, Target: "valuable"},
+		{Name: "items", Events: []string{"item_stashed"}, Items: []string{"ring", "amulet"}, Target: "items"},
+		{Name: "sorc chickens", Supervisors: []string{"sorc"}, Reasons: []string{"chicken"}, Target: "chickens"},
+	}}
+	values := map[item.Name]int{"Ring": 5000, "Amulet": 500}
+	stuck := event.Stuck(event.Text("sorc", "Stuck"), 0, data.Position{}, 0, "")
+
+	tests := []struct {
+		name     string
+		notifier string
+		event    event.Event
+		expected string
+	}{
+		{name: "first matching rule wins", notifier: "discord", event: stashed("sorc", "Ring", item.QualityUnique), expected: "deliver:uniques"},
+		{name: "min value", notifier: "discord", event: stashed("sorc", "Ring", item.QualityRare), expected: "deliver:valuable"},
+		{name: "below min value", notifier: "discord", event: stashed("sorc", "Amulet", item.QualityRare), expected: "deliver:items"},
+		{name: "unknown value", notifier: "discord", event: stashed("sorc", "Jewel", item.QualityRare), expected: "handle"},
+		{name: "muted for the notifier", notifier: "telegram", event: stuck, expected: ""},
+		{name: "rule for other notifiers", notifier: "discord", event: stuck, expected: "handle"},
+		{name: "supervisor and reason", notifier: "discord", event: gameFinished("sorc", event.FinishedChicken), expected: "deliver:chickens"},
+		{name: "other supervisor", notifier: "discord", event: gameFinished("pala", event.FinishedChicken), expected: "handle"},
+		{name: "other reason", notifier: "discord", event: gameFinished("sorc", event.FinishedDied), expected: "handle"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, rc := newTestRouter(t, cfg, tt.notifier)
+			r.SetItemValue(func(_ string, itm data.Item) (int, bool) {
+				value, found := values[itm.Name]
+				return value, found
+			})
+
+			if err := r.Handle(context.Background(), tt.event); err != nil {
+				t.Fatalf("Handle: %v", err)
+			}
+			if got := rc.last(); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	cfg := config.NotificationsCfg{Rules: []config.NotificationRule{
+		{Name: "chickens", Reasons: []string{"chicken"}, RateLimit: 2, RatePeriod: time.Minute * 10},
+	}}
+	r, rc := newTestRouter(t, cfg, "discord")
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		after time.Duration
+		sent  bool
+	}{
+		{after: 0, sent: true},
+		{after: time.Minute, sent: true},
+		{after: time.Minute * 2, sent: false},
+		{after: time.Minute*9 + time.Second*59, sent: false},
+		// The first one is out of the window
+		{after: time.Minute * 10, sent: true},
+		{after: time.Minute*10 + time.Second*30, sent: false},
+		{after: time.Minute * 11, sent: true},
+	}
+
+	for _, tt := range tests {
+		r.now = func() time.Time { return start.Add(tt.after) }
+		if err := r.Handle(context.Background(), gameFinished("sorc", event.FinishedChicken)); err != nil {
+			t.Fatalf("Handle: %v", err)
+		}
+		if sent := rc.last() != ""; sent != tt.sent {
+			t.Errorf("after %s: expected sent %t, got %t", tt.after, tt.sent, sent)
+		}
+	}
+}
+
+func TestAggregation(t *testing.T) {
+	cfg := config.NotificationsCfg{Rules: []config.NotificationRule{
+		{Name: "death loop", Events: []string{"game_finished"}, Aggregate: time.Millisecond * 50, Target: "deaths"},
+	}}
+	r, rc := newTestRouter(t, cfg, "discord")
+
+	for _, e := range []event.Event{
+		gameFinished("sorc", event.FinishedChicken),
+		gameFinished("pala", event.FinishedDied),
+		gameFinished("sorc", event.FinishedDied),
+		event.GameFinished(event.Text("sorc", "Game 3 finished"), event.FinishedChicken),
+	} {
+		if err := r.Handle(context.Background(), e); err != nil {
+			t.Fatalf("Handle: %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		rc.mu.Lock()
+		sent := len(rc.sent)
+		rc.mu.Unlock()
+		if sent == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected a summary for every supervisor, got %d", sent)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	messages := make(map[string]string)
+	for i, e := range rc.events {
+		if rc.sent[i] != "deliver:deaths" {
+			t.Errorf("expected the summary in the rule target, got %s", rc.sent[i])
+		}
+		messages[e.Supervisor()] = e.Message()
+	}
+
+	if expected := "3 chicken (game_finished) in 50ms, last one: Game 3 finished"; messages["sorc"] != expected {
+		t.Errorf("expected summary %q, got %q", expected, messages["sorc"])
+	}
+	// A single event is sent as it is
+	if expected := "Game finished"; messages["pala"] != expected {
+		t.Errorf("expected message %q, got %q", expected, messages["pala"])
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	tests := []struct {
+		start string
+		end   string
+		clock string
+		quiet bool
+	}{
+		{start: "23:00", end: "07:00", clock: "22:59", quiet: false},
+		{start: "23:00", end: "07:00", clock: "23:00", quiet: true},
+		{start: "23:00", end: "07:00", clock: "00:30", quiet: true},
+		{start: "23:00", end: "07:00", clock: "06:59", quiet: true},
+		{start: "23:00", end: "07:00", clock: "07:00", quiet: false},
+		{start: "13:00", end: "14:00", clock: "12:59", quiet: false},
+		{start: "13:00", end: "14:00", clock: "13:30", quiet: true},
+		{start: "13:00", end: "14:00", clock: "14:00", quiet: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.start+"-"+tt.end+" at "+tt.clock, func(t *testing.T) {
+			cfg := config.NotificationsCfg{Rules: []config.NotificationRule{
+				{Name: "deaths", Reasons: []string{"death"}, Critical: true},
+				{Name: "chickens", Reasons: []string{"chicken"}},
+			}}
+			cfg.QuietHours.Enabled = true
+			cfg.QuietHours.Start = tt.start
+			cfg.QuietHours.End = tt.end
+			r, rc := newTestRouter(t, cfg, "discord")
+
+			clock, _ := time.Parse("15:04", tt.clock)
+			r.now = func() time.Time {
+				return time.Date(2024, 1, 1, clock.Hour(), clock.Minute(), 0, 0, time.Local)
+			}
+
+			for _, e := range []struct {
+				name     string
+				event    event.Event
+				critical bool
+			}{
+				{name: "critical rule", event: gameFinished("sorc", event.FinishedDied), critical: true},
+				{name: "rule", event: gameFinished("sorc", event.FinishedChicken)},
+				{name: "no rule", event: gameFinished("sorc", event.FinishedOK)},
+			} {
+				if err := r.Handle(context.Background(), e.event); err != nil {
+					t.Fatalf("Handle: %v", err)
+				}
+				expected := e.critical || !tt.quiet
+				if sent := rc.last() != ""; sent != expected {
+					t.Errorf("%s: expected sent %t, got %t", e.name, expected, sent)
+				}
+			}
+		})
+	}
+}
+
+func TestNewRouterValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		rule config.NotificationRule
+	}{
+		{name: "unknown quality", rule: config.NotificationRule{MinQuality: "legendary"}},
+		{name: "negative value", rule: config.NotificationRule{MinValue: -1}},
+		{name: "rate limit without period", rule: config.NotificationRule{RateLimit: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NotificationsCfg{Rules: []config.NotificationRule{tt.rule}}
+			if _, err := NewRouter(cfg, slog.Default()); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
@@ -3,31 +3,41 @@ package telegram
 import (
 	"context"
 	"log/slog"
-	"strings"
+	"slices"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/hectorgimenez/koolo/internal/bot"
+	"github.com/hectorgimenez/koolo/internal/remote"
 )
 
 type Bot struct {
-	bot    *tgbotapi.BotAPI
-	chatID int64
-	logger *slog.Logger
+	bot     *tgbotapi.BotAPI
+	chatID  int64
+	admins  []int64
+	manager remote.SupervisorManager
+	logger  *slog.Logger
 }
 
-func NewBot(token string, chatID int64, logger *slog.Logger) (*Bot, error) {
-	bot, err := tgbotapi.NewBotAPI(token)
+func NewBot(token string, chatID int64, admins []int64, manager *bot.SupervisorManager, logger *slog.Logger) (*Bot, error) {
+	api, err := tgbotapi.NewBotAPI(token)
 	if err != nil {
 		return nil, err
 	}
 
+	return newBot(api, chatID, admins, manager, logger), nil
+}
+
+func newBot(api *tgbotapi.BotAPI, chatID int64, admins []int64, manager remote.SupervisorManager, logger *slog.Logger) *Bot {
 	return &Bot{
-		bot:    bot,
-		chatID: chatID,
-		logger: logger,
-	}, nil
+		bot:     api,
+		chatID:  chatID,
+		admins:  admins,
+		manager: manager,
+		logger:  logger,
+	}
 }
 
-func (b *Bot) Start(_ context.Context) error {
+func (b *Bot) Start(ctx context.Context) error {
 	offset, err := b.getLatestOffset()
 	if err != nil {
 		return err
@@ -36,22 +46,39 @@ func (b *Bot) Start(_ context.Context) error {
 	u := tgbotapi.NewUpdate(offset)
 	u.Timeout = 5
 	updates := b.bot.GetUpdatesChan(u)
-	for update := range updates {
-		if update.Message != nil && update.Message.Chat.ID == b.chatID { // If we got a message
-			switch strings.ToLower(update.Message.Text) {
-			case "stats":
-				if err := b.publishStats(); err != nil {
-					b.logger.Error("error sending telegram message", slog.Any("error", err))
-				}
-			case "start":
-				// TODO: Implement
-			case "stop":
-				//b.sueprvisor.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			b.bot.StopReceivingUpdates()
+			return nil
+		case update, ok := <-updates:
+			if !ok {
+				return nil
 			}
+			b.handleUpdate(update)
+		}
+	}
+}
+
+func (b *Bot) handleUpdate(update tgbotapi.Update) {
+	switch {
+	case update.Message != nil:
+		if update.Message.Chat == nil || update.Message.Chat.ID != b.chatID || !b.isAdmin(update.Message.From) {
+			return
+		}
+		b.handleMessage(update.Message)
+	case update.CallbackQuery != nil:
+		query := update.CallbackQuery
+		if query.Message == nil || query.Message.Chat == nil || query.Message.Chat.ID != b.chatID || !b.isAdmin(query.From) {
+			return
 		}
+		b.handleCallback(query)
 	}
+}
 
-	return nil
+// isAdmin checks if the user is allowed to use the bot commands, only the configured bot admins are
+func (b *Bot) isAdmin(user *tgbotapi.User) bool {
+	return user != nil && slices.Contains(b.admins, user.ID)
 }
 
 func (b *Bot) getLatestOffset() (int, error) {
@@ -69,27 +96,15 @@ func (b *Bot) getLatestOffset() (int, error) {
 	return offset, nil
 }
 
-func (b *Bot) publishStats() error {
-	return nil
-	//msg := "Run | Items | Deaths | Chickens | Merc Chickens | Errors | Healing Pots | Mana Pots | Reju Pots | Merc Pots \n"
-	//for run, st := range stat.Status.RunStats {
-	//	msg += fmt.Sprintf(
-	//		"%s | %d | %d | %d | %d | %d | %d | %d| %d | %d | %d \n",
-	//		run,
-	//		len(st.ItemsFound),
-	//		st.Kills,
-	//		st.Deaths,
-	//		st.Chickens,
-	//		st.MerChicken,
-	//		st.Errors,
-	//		st.HealingPotionsUsed,
-	//		st.ManaPotionsUsed,
-	//		st.RejuvPotionsUsed,
-	//		st.MercHealingPotionsUsed,
-	//	)
-	//}
-	//
-	//_, err := b.bot.Send(tgbotapi.NewMessage(b.chatID, msg))
-	//
-	//return err
+// send sends an HTML formatted message to the configured chat, errors are only logged
+func (b *Bot) send(text string, keyboard *tgbotapi.InlineKeyboardMarkup) {
+	msg := tgbotapi.NewMessage(b.chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	if keyboard != nil {
+		msg.ReplyMarkup = keyboard
+	}
+
+	if _, err := b.bot.Send(msg); err != nil {
+		b.logger.Error("error sending telegram message", slog.Any("error", err))
+	}
 }
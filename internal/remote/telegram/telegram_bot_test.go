@@ -0,0 +1,369 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/item"
+	"github.com/hectorgimenez/koolo/internal/bot"
+	"github.com/hectorgimenez/koolo/internal/event"
+	"github.com/hectorgimenez/koolo/internal/remote/remotetest"
+)
+
+const (
+	testChatID = 1000
+	testAdmin  = 42
+)
+
+// apiRequest is a request received by the stand-in Bot API server
+type apiRequest struct {
+	method string
+	params map[string]string
+}
+
+// fakeBotAPI mimics the Telegram Bot API: every method answers ok, and getUpdates serves the queued updates once
+type fakeBotAPI struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	requests []apiRequest
+	updates  []tgbotapi.Update
+}
+
+func newFakeBotAPI(t *testing.T) *fakeBotAPI {
+	api := &fakeBotAPI{}
+	api.server = httptest.NewServer(http.HandlerFunc(api.handle))
+	t.Cleanup(api.server.Close)
+
+	return api
+}
+
+func (f *fakeBotAPI) handle(w http.ResponseWriter, r *http.Request) {
+	// Paths are /bot{token}/{method}
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	if err := r.ParseMultipartForm(1 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	params := make(map[string]string)
+	for k := range r.Form {
+		params[k] = r.Form.Get(k)
+	}
+	if r.MultipartForm != nil {
+		for k := range r.MultipartForm.File {
+			params[k] = "file"
+		}
+	}
+
+	var result any = true
+	switch method {
+	case "getMe":
+		result = tgbotapi.User{ID: 1, IsBot: true, UserName: "koolo_bot"}
+	case "getUpdates":
+		f.mu.Lock()
+		updates := f.updates
+		f.updates = nil
+		f.mu.Unlock()
+		if len(updates) == 0 {
+			// Long polling, don't answer straight away when there is nothing new
+			time.Sleep(time.Millisecond * 50)
+		}
+		result = append([]tgbotapi.Update{}, updates...)
+	case "sendMessage", "sendPhoto":
+		result = tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: testChatID}}
+	}
+
+	if method != "getMe" && method != "getUpdates" {
+		f.mu.Lock()
+		f.requests = append(f.requests, apiRequest{method: method, params: params})
+		f.mu.Unlock()
+	}
+
+	raw, _ := json.Marshal(result)
+	json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: true, Result: raw})
+}
+
+func (f *fakeBotAPI) sent() []apiRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]apiRequest{}, f.requests...)
+}
+
+func newTestBot(t *testing.T) (*Bot, *fakeBotAPI, *remotetest.FakeManager) {
+	fake := newFakeBotAPI(t)
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint("token", fake.server.URL+"/bot%s/%s")
+	if err != nil {
+		t.Fatalf("creating bot api: %v", err)
+	}
+
+	manager := &remotetest.FakeManager{Stats: map[string]bot.Stats{
+		"sorc":    {SupervisorStatus: bot.InGame, StartedAt: time.Now()},
+		"hammer":  {SupervisorStatus: bot.Paused, StartedAt: time.Now()},
+		"offline": {},
+	}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	return newBot(api, testChatID, []int64{testAdmin}, manager, logger), fake, manager
+}
+
+func message(from int64, text string) tgbotapi.Update {
+	return tgbotapi.Update{Message: &tgbotapi.Message{
+		From: &tgbotapi.User{ID: from},
+		Chat: &tgbotapi.Chat{ID: testChatID},
+		Text: text,
+	}}
+}
+
+func TestCommandsOnlyFromAdmins(t *testing.T) {
+	b, fake, manager := newTestBot(t)
+
+	b.handleUpdate(message(7, "/stop sorc"))
+	b.handleUpdate(tgbotapi.Update{Message: &tgbotapi.Message{
+		From: &tgbotapi.User{ID: testAdmin},
+		Chat: &tgbotapi.Chat{ID: 5},
+		Text: "/stop sorc",
+	}})
+
+	if len(manager.Stopped) != 0 || len(fake.sent()) != 0 {
+		t.Fatalf("commands from other users or chats must be ignored, stopped %v, sent %v", manager.Stopped, fake.sent())
+	}
+}
+
+func TestStartStopPauseMultipleSupervisors(t *testing.T) {
+	b, fake, manager := newTestBot(t)
+
+	b.handleUpdate(message(testAdmin, "/start offline sorc"))
+	b.handleUpdate(message(testAdmin, "/stop sorc missing"))
+	b.handleUpdate(message(testAdmin, "pause hammer sorc"))
+
+	if strings.Join(manager.Started, ",") != "offline" {
+		t.Errorf("started %v, expected only offline", manager.Started)
+	}
+	if strings.Join(manager.Stopped, ",") != "sorc" {
+		t.Errorf("stopped %v, expected sorc", manager.Stopped)
+	}
+	if strings.Join(manager.Paused, ",") != "hammer,sorc" {
+		t.Errorf("paused %v, expected hammer,sorc", manager.Paused)
+	}
+
+	expected := []string{
+		"Supervisor <b>offline</b> has been started.",
+		"Supervisor <b>sorc</b> is already running.",
+		"Supervisor <b>sorc</b> has been stopped.",
+		"Supervisor <b>missing</b> not found.",
+		"Supervisor <b>hammer</b> has been resumed.",
+		"Supervisor <b>sorc</b> has been paused.",
+	}
+	sent := fake.sent()
+	if len(sent) != len(expected) {
+		t.Fatalf("expected %d messages, got %d: %v", len(expected), len(sent), sent)
+	}
+	for i, req := range sent {
+		if req.params["text"] != expected[i] || req.params["parse_mode"] != tgbotapi.ModeHTML {
+			t.Errorf("message %d: expected %q, got %q (%s)", i, expected[i], req.params["text"], req.params["parse_mode"])
+		}
+	}
+}
+
+func TestSupervisorKeyboard(t *testing.T) {
+	b, fake, _ := newTestBot(t)
+
+	b.handleUpdate(message(testAdmin, "/stats@koolo_bot"))
+
+	sent := fake.sent()
+	if len(sent) != 1 {
+		t.Fatalf("expected the keyboard message, got %v", sent)
+	}
+	var markup tgbotapi.InlineKeyboardMarkup
+	if err := json.Unmarshal([]byte(sent[0].params["reply_markup"]), &markup); err != nil {
+		t.Fatalf("invalid reply_markup %q: %v", sent[0].params["reply_markup"], err)
+	}
+	var buttons []string
+	for _, row := range markup.InlineKeyboard {
+		for _, button := range row {
+			buttons = append(buttons, *button.CallbackData)
+		}
+	}
+	if strings.Join(buttons, ",") != "stats:hammer,stats:offline,stats:sorc" {
+		t.Fatalf("unexpected buttons %v", buttons)
+	}
+
+	// Pressing a button from someone else does nothing
+	callback := func(from int64) tgbotapi.Update {
+		return tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+			ID:      "cb",
+			From:    &tgbotapi.User{ID: from},
+			Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: testChatID}},
+			Data:    "stats:sorc",
+		}}
+	}
+	b.handleUpdate(callback(7))
+	b.handleUpdate(callback(testAdmin))
+
+	sent = fake.sent()[1:]
+	if len(sent) != 2 || sent[0].method != "answerCallbackQuery" || sent[0].params["callback_query_id"] != "cb" {
+		t.Fatalf("expected the callback to be answered, got %v", sent)
+	}
+	if !strings.HasPrefix(sent[1].params["text"], "<b>Stats for sorc</b>\nStatus: In game") {
+		t.Errorf("unexpected stats message %q", sent[1].params["text"])
+	}
+}
+
+func TestDropsAndScreenshot(t *testing.T) {
+	b, fake, manager := newTestBot(t)
+	manager.Stats["sorc"] = bot.Stats{SupervisorStatus: bot.InGame, Drops: []data.Drop{
+		{Item: data.Item{Name: "Ring", Quality: item.QualityRare}},
+		{Item: data.Item{Name: "Amulet", IdentifiedName: "Mara's Kaleidoscope", Quality: item.QualityUnique}, Rule: `[name] == amulet && [quality] == unique`},
+	}}
+
+	b.handleUpdate(message(testAdmin, "/drops sorc offline"))
+	b.handleUpdate(message(testAdmin, "/screenshot sorc"))
+
+	expected := []string{
+		"<b>Drops for sorc</b> (2 total)\n• <b>Mara&#39;s Kaleidoscope</b> (Unique)\n  <code>[name] == amulet &amp;&amp; [quality] == unique</code>\n• <b>Ring</b> (Rare)",
+		"No drops for <b>offline</b> yet.",
+		"Screenshot for <b>sorc</b> could not be taken: supervisor is not running",
+	}
+	sent := fake.sent()
+	if len(sent) != len(expected) {
+		t.Fatalf("expected %d messages, got %v", len(expected), sent)
+	}
+	for i, req := range sent {
+		if req.params["text"] != expected[i] {
+			t.Errorf("message %d: expected %q, got %q", i, expected[i], req.params["text"])
+		}
+	}
+}
+
+func TestStatusCardAndScreenshot(t *testing.T) {
+	b, fake, manager := newTestBot(t)
+	manager.Stats["sorc"] = bot.Stats{SupervisorStatus: bot.InGame, Details: "Running mephisto"}
+	manager.Cards = map[string]bot.StatusCard{
+		"sorc": {
+			Supervisor:    "sorc",
+			Status:        bot.InGame,
+			InGame:        true,
+			Area:          "Durance of Hate Level 3",
+			HPPercent:     80,
+			MPPercent:     45,
+			HasMerc:       true,
+			MercHPPercent: 100,
+			Gold:          123456,
+			Run:           "mephisto",
+			RunStartedAt:  time.Now().Add(-time.Minute),
+			GameStartedAt: time.Now().Add(-time.Minute * 3),
+			LastAction:    "MoveTo",
+			LastStep:      "<path>",
+		},
+		"hammer": {Supervisor: "hammer", Status: bot.Paused, LastAction: "Stash"},
+	}
+
+	b.handleUpdate(message(testAdmin, "/status sorc hammer offline"))
+	b.handleUpdate(message(testAdmin, "/screenshot sorc"))
+
+	expected := []apiRequest{
+		{method: "sendMessage", params: map[string]string{"text": "Supervisor <b>sorc</b> is <i>In game</i>\nArea: <b>Durance of Hate Level 3</b>\nRun: <i>mephisto</i> (1m0s)\nGame time: 3m0s\nHP 80% · MP 45% · Merc 100%\nGold: 123456\nAction: <code>MoveTo</code> / <code>&lt;path&gt;</code>\nRunning mephisto"}},
+		{method: "sendMessage", params: map[string]string{"text": "Supervisor <b>hammer</b> is <i>Paused</i>\nAction: <code>Stash</code>"}},
+		{method: "sendMessage", params: map[string]string{"text": "Supervisor <b>offline</b> is offline."}},
+		{method: "sendPhoto", params: map[string]string{"caption": "<b>sorc</b>", "photo": "file"}},
+	}
+	sent := fake.sent()
+	if len(sent) != len(expected) {
+		t.Fatalf("expected %d requests, got %v", len(expected), sent)
+	}
+	for i, req := range sent {
+		if req.method != expected[i].method {
+			t.Errorf("request %d: expected %s, got %s", i, expected[i].method, req.method)
+		}
+		for k, v := range expected[i].params {
+			if req.params[k] != v {
+				t.Errorf("request %d: expected %s %q, got %q", i, k, v, req.params[k])
+			}
+		}
+	}
+}
+
+func TestHandleEvents(t *testing.T) {
+	b, fake, _ := newTestBot(t)
+
+	events := []event.Event{
+		event.UsedPotion(event.Text("sorc", "used potion"), data.HealingPotion, false),
+		event.GameFinished(event.Text("sorc", "Game finished"), event.FinishedChicken),
+		event.RunFinished(event.Text("sorc", "Finished run"), "mephisto", event.FinishedOK, 0, 0),
+		event.GameCreated(event.Text("sorc", "New game created"), "koolo-1", "<pass>"),
+		event.Text("sorc", "Something happened"),
+		event.WithScreenshot("sorc", "Error", image.NewRGBA(image.Rect(0, 0, 10, 10))),
+	}
+	for _, e := range events {
+		if err := b.Handle(context.Background(), e); err != nil {
+			t.Fatalf("handling %T: %v", e, err)
+		}
+	}
+
+	expected := []apiRequest{
+		{method: "sendMessage", params: map[string]string{"text": "<b>sorc</b> game finished: <b>Chicken</b>\nGame finished"}},
+		{method: "sendMessage", params: map[string]string{"text": "<b>sorc</b> finished run <i>mephisto</i>: <b>OK</b>"}},
+		{method: "sendMessage", params: map[string]string{"text": "<b>sorc</b> created a game\nGame: <code>koolo-1</code>\nPassword: <code>&lt;pass&gt;</code>"}},
+		{method: "sendMessage", params: map[string]string{"text": "<b>sorc</b>: Something happened"}},
+		{method: "sendPhoto", params: map[string]string{"caption": "<b>sorc</b>: Error", "photo": "file"}},
+	}
+	sent := fake.sent()
+	if len(sent) != len(expected) {
+		t.Fatalf("expected %d requests, got %v", len(expected), sent)
+	}
+	for i, req := range sent {
+		if req.method != expected[i].method {
+			t.Errorf("request %d: expected %s, got %s", i, expected[i].method, req.method)
+		}
+		for k, v := range expected[i].params {
+			if req.params[k] != v {
+				t.Errorf("request %d: expected %s %q, got %q", i, k, v, req.params[k])
+			}
+		}
+	}
+}
+
+func TestStartPollsUpdates(t *testing.T) {
+	b, fake, manager := newTestBot(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- b.Start(ctx)
+	}()
+
+	// Queued after the initial offset request, otherwise it would be discarded as an old message
+	time.Sleep(time.Millisecond * 100)
+	update := message(testAdmin, "/stop sorc")
+	update.UpdateID = 1
+	fake.mu.Lock()
+	fake.updates = append(fake.updates, update)
+	fake.mu.Unlock()
+
+	deadline := time.Now().Add(time.Second * 2)
+	for len(fake.sent()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Fatalf("Start returned %v", err)
+	}
+	if strings.Join(manager.Stopped, ",") != "sorc" {
+		t.Fatalf("expected sorc to be stopped from a polled update, stopped %v", manager.Stopped)
+	}
+}
@@ -0,0 +1,277 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/bot"
+)
+
+// maxDrops is the amount of drops listed by the drops command, newest first
+const maxDrops = 10
+
+var commands = []string{"start", "stop", "pause", "status", "stats", "drops", "screenshot"}
+
+// handleMessage parses commands like "/stop char1 char2", the slash is optional. When no supervisor is given, a
+// keyboard to select one of them is sent back.
+func (b *Bot) handleMessage(m *tgbotapi.Message) {
+	words := strings.Fields(m.Text)
+	if len(words) == 0 {
+		return
+	}
+
+	command := strings.ToLower(strings.TrimPrefix(words[0], "/"))
+	// Commands sent in groups can include the bot name: /stats@koolo_bot
+	command, _, _ = strings.Cut(command, "@")
+	if !slices.Contains(commands, command) {
+		if strings.HasPrefix(words[0], "/") {
+			b.send("Available commands: /"+strings.Join(commands, ", /"), nil)
+		}
+		return
+	}
+
+	if len(words) == 1 {
+		b.sendSupervisorKeyboard(command)
+		return
+	}
+
+	for _, supervisor := range words[1:] {
+		b.runCommand(command, supervisor)
+	}
+}
+
+// handleCallback handles the buttons of the supervisor keyboard, the callback data is "command:supervisor"
+func (b *Bot) handleCallback(query *tgbotapi.CallbackQuery) {
+	// Telegram shows a loading indicator in the button until the callback is answered
+	if _, err := b.bot.Request(tgbotapi.NewCallback(query.ID, "")); err != nil {
+		b.logger.Warn("error answering telegram callback", slog.Any("error", err))
+	}
+
+	command, supervisor, found := strings.Cut(query.Data, ":")
+	if !found || !slices.Contains(commands, command) {
+		return
+	}
+
+	b.runCommand(command, supervisor)
+}
+
+func (b *Bot) sendSupervisorKeyboard(command string) {
+	supervisors := b.manager.AvailableSupervisors()
+	if len(supervisors) == 0 {
+		b.send("There are no supervisors configured.", nil)
+		return
+	}
+	slices.Sort(supervisors)
+
+	// Two buttons per row, long lists are easier to read than a single column
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i := 0; i < len(supervisors); i += 2 {
+		var row []tgbotapi.InlineKeyboardButton
+		for _, supervisor := range supervisors[i:min(i+2, len(supervisors))] {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(supervisor, command+":"+supervisor))
+		}
+		rows = append(rows, row)
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+
+	b.send(fmt.Sprintf("Select the supervisor for <b>/%s</b>:", command), &keyboard)
+}
+
+func (b *Bot) runCommand(command, supervisor string) {
+	if !slices.Contains(b.manager.AvailableSupervisors(), supervisor) {
+		b.send(fmt.Sprintf("Supervisor <b>%s</b> not found.", html.EscapeString(supervisor)), nil)
+		return
+	}
+
+	switch command {
+	case "start":
+		b.handleStartRequest(supervisor)
+	case "stop":
+		b.handleStopRequest(supervisor)
+	case "pause":
+		b.handlePauseRequest(supervisor)
+	case "status":
+		b.handleStatusRequest(supervisor)
+	case "stats":
+		b.handleStatsRequest(supervisor)
+	case "drops":
+		b.handleDropsRequest(supervisor)
+	case "screenshot":
+		b.handleScreenshotRequest(supervisor)
+	}
+}
+
+func (b *Bot) isRunning(supervisor string) bool {
+	status := b.manager.Status(supervisor).SupervisorStatus
+	return status != bot.NotStarted && status != ""
+}
+
+func (b *Bot) handleStartRequest(supervisor string) {
+	name := html.EscapeString(supervisor)
+	if b.isRunning(supervisor) {
+		b.send(fmt.Sprintf("Supervisor <b>%s</b> is already running.", name), nil)
+		return
+	}
+
+	if err := b.manager.Start(supervisor, false); err != nil {
+		b.send(fmt.Sprintf("Supervisor <b>%s</b> could not be started: %s", name, html.EscapeString(err.Error())), nil)
+		return
+	}
+
+	b.send(fmt.Sprintf("Supervisor <b>%s</b> has been started.", name), nil)
+}
+
+func (b *Bot) handleStopRequest(supervisor string) {
+	name := html.EscapeString(supervisor)
+	if !b.isRunning(supervisor) {
+		b.send(fmt.Sprintf("Supervisor <b>%s</b> is not running.", name), nil)
+		return
+	}
+
+	b.manager.Stop(supervisor)
+	b.send(fmt.Sprintf("Supervisor <b>%s</b> has been stopped.", name), nil)
+}
+
+func (b *Bot) handlePauseRequest(supervisor string) {
+	name := html.EscapeString(supervisor)
+	if !b.isRunning(supervisor) {
+		b.send(fmt.Sprintf("Supervisor <b>%s</b> is not running.", name), nil)
+		return
+	}
+
+	wasPaused := b.manager.Status(supervisor).SupervisorStatus == bot.Paused
+	b.manager.TogglePause(supervisor)
+	if wasPaused {
+		b.send(fmt.Sprintf("Supervisor <b>%s</b> has been resumed.", name), nil)
+		return
+	}
+
+	b.send(fmt.Sprintf("Supervisor <b>%s</b> has been paused.", name), nil)
+}
+
+func (b *Bot) handleStatusRequest(supervisor string) {
+	name := html.EscapeString(supervisor)
+	if !b.isRunning(supervisor) {
+		b.send(fmt.Sprintf("Supervisor <b>%s</b> is offline.", name), nil)
+		return
+	}
+
+	card, err := b.manager.StatusCard(supervisor)
+	if err != nil {
+		b.send(fmt.Sprintf("Supervisor <b>%s</b> status could not be read: %s", name, html.EscapeString(err.Error())), nil)
+		return
+	}
+
+	msg := formatStatusCard(card)
+	if details := b.manager.Status(supervisor).Details; details != "" {
+		msg += "\n" + html.EscapeString(details)
+	}
+
+	b.send(msg, nil)
+}
+
+func (b *Bot) handleStatsRequest(supervisor string) {
+	stats := b.manager.GetSupervisorStats(supervisor)
+
+	status, uptime := "Offline", "-"
+	if b.isRunning(supervisor) {
+		status = string(b.manager.Status(supervisor).SupervisorStatus)
+		uptime = time.Since(b.manager.Status(supervisor).StartedAt).Round(time.Second).String()
+	}
+
+	msg := fmt.Sprintf(
+		"<b>Stats for %s</b>\nStatus: %s\nUptime: %s\nGames: %d\nDrops: %d\nDeaths: %d\nChickens: %d\nErrors: %d",
+		html.EscapeString(supervisor),
+		html.EscapeString(status),
+		uptime,
+		stats.TotalGames(),
+		len(stats.Drops),
+		stats.TotalDeaths(),
+		stats.TotalChickens(),
+		stats.TotalErrors(),
+	)
+	if exp := stats.ExperienceProgress(); exp.Tracked {
+		msg += fmt.Sprintf("\nLevel: %d (%.1f%%)\nXP/h: %.0f", exp.Level, exp.NextLevelPercent, exp.PerHour)
+		if exp.NextLevelETAKnown {
+			msg += fmt.Sprintf("\nNext level in: %s", exp.NextLevelETA.Round(time.Minute))
+		}
+	}
+
+	b.send(msg, nil)
+}
+
+func (b *Bot) handleDropsRequest(supervisor string) {
+	name := html.EscapeString(supervisor)
+	drops := b.manager.GetSupervisorStats(supervisor).Drops
+	if len(drops) == 0 {
+		b.send(fmt.Sprintf("No drops for <b>%s</b> yet.", name), nil)
+		return
+	}
+
+	msg := fmt.Sprintf("<b>Drops for %s</b> (%d total)", name, len(drops))
+	for i := len(drops) - 1; i >= max(0, len(drops)-maxDrops); i-- {
+		msg += "\n• " + formatDrop(drops[i])
+	}
+
+	b.send(msg, nil)
+}
+
+func (b *Bot) handleScreenshotRequest(supervisor string) {
+	img, err := b.manager.Screenshot(supervisor)
+	if err != nil {
+		b.send(fmt.Sprintf("Screenshot for <b>%s</b> could not be taken: %s", html.EscapeString(supervisor), html.EscapeString(err.Error())), nil)
+		return
+	}
+
+	if err = b.sendImage(b.chatID, fmt.Sprintf("<b>%s</b>", html.EscapeString(supervisor)), img); err != nil {
+		b.logger.Error("error sending telegram screenshot", slog.Any("error", err))
+	}
+}
+
+// formatStatusCard returns the status card as a compact HTML message, the game lines are skipped when not in game
+func formatStatusCard(card bot.StatusCard) string {
+	msg := fmt.Sprintf("Supervisor <b>%s</b> is <i>%s</i>", html.EscapeString(card.Supervisor), html.EscapeString(string(card.Status)))
+	if card.InGame {
+		msg += fmt.Sprintf("\nArea: <b>%s</b>", html.EscapeString(card.Area))
+		if card.Run != "" {
+			msg += fmt.Sprintf("\nRun: <i>%s</i> (%s)", html.EscapeString(card.Run), card.RunTime())
+		}
+		msg += fmt.Sprintf("\nGame time: %s", card.GameTime())
+
+		health := fmt.Sprintf("HP %d%% · MP %d%%", card.HPPercent, card.MPPercent)
+		if card.HasMerc {
+			health += fmt.Sprintf(" · Merc %d%%", card.MercHPPercent)
+		}
+		msg += "\n" + health
+		msg += fmt.Sprintf("\nGold: %d", card.Gold)
+	}
+	if card.LastAction != "" {
+		msg += fmt.Sprintf("\nAction: <code>%s</code>", html.EscapeString(card.LastAction))
+		if card.LastStep != "" {
+			msg += fmt.Sprintf(" / <code>%s</code>", html.EscapeString(card.LastStep))
+		}
+	}
+
+	return msg
+}
+
+// formatDrop returns the item name and quality, and the pickit rule that matched if any
+func formatDrop(drop data.Drop) string {
+	name := drop.Item.IdentifiedName
+	if name == "" {
+		name = string(drop.Item.Name)
+	}
+
+	text := fmt.Sprintf("<b>%s</b> (%s)", html.EscapeString(name), html.EscapeString(drop.Item.Quality.ToString()))
+	if drop.Rule != "" {
+		text += fmt.Sprintf("\n  <code>%s</code>", html.EscapeString(drop.Rule))
+	}
+
+	return text
+}
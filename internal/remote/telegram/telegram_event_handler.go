@@ -3,32 +3,127 @@ package telegram
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"html"
+	"image"
 	"image/jpeg"
+	"strconv"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/hectorgimenez/koolo/internal/event"
 )
 
 func (b *Bot) Handle(_ context.Context, e event.Event) error {
+	// Internal events only useful for the bot itself are not published
+	switch e.(type) {
+	case event.UsedPotionEvent, event.InteractedToEvent, event.CompanionLeaderAttackEvent, event.CompanionRequestedTPEvent:
+		return nil
+	}
+
+	return b.sendEvent(b.chatID, e)
+}
+
+// Deliver sends the event routed by a notification rule, target is the chat ID (empty for the configured one)
+func (b *Bot) Deliver(_ context.Context, e event.Event, target string) error {
+	chatID := b.chatID
+	if target != "" {
+		var err error
+		if chatID, err = strconv.ParseInt(target, 10, 64); err != nil {
+			return fmt.Errorf("invalid telegram chat ID %s: %w", target, err)
+		}
+	}
+
+	return b.sendEvent(chatID, e)
+}
+
+func (b *Bot) sendEvent(chatID int64, e event.Event) error {
+	text := formatEvent(e)
 	if e.Image() != nil {
-		buf := new(bytes.Buffer)
-		err := jpeg.Encode(buf, e.Image(), nil)
-		if err != nil {
-			return err
+		return b.sendImage(chatID, text, e.Image())
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	_, err := b.bot.Send(msg)
+
+	return err
+}
+
+// formatEvent returns the HTML message for the event
+func formatEvent(e event.Event) string {
+	supervisor := fmt.Sprintf("<b>%s</b>", html.EscapeString(e.Supervisor()))
+	message := html.EscapeString(e.Message())
+
+	switch evt := e.(type) {
+	case event.GameCreatedEvent:
+		return fmt.Sprintf("%s created a game\nGame: <code>%s</code>\nPassword: <code>%s</code>", supervisor, html.EscapeString(evt.Name), html.EscapeString(evt.Password))
+	case event.GameFinishedEvent:
+		text := fmt.Sprintf("%s game finished: <b>%s</b>", supervisor, finishReasonText(evt.Reason))
+		if message != "" {
+			text += "\n" + message
+		}
+		return text
+	case event.RunStartedEvent:
+		return fmt.Sprintf("%s started run <i>%s</i>", supervisor, html.EscapeString(evt.RunName))
+	case event.RunFinishedEvent:
+		return fmt.Sprintf("%s finished run <i>%s</i>: <b>%s</b>", supervisor, html.EscapeString(evt.RunName), finishReasonText(evt.Reason))
+	case event.ItemStashedEvent:
+		return fmt.Sprintf("%s stashed %s", supervisor, formatDrop(evt.Item))
+	case event.GamePausedEvent:
+		if evt.Paused {
+			return supervisor + " has been paused"
+		}
+		return supervisor + " has been resumed"
+	case event.GoalReachedEvent:
+		return fmt.Sprintf("%s reached a goal\n%s", supervisor, message)
+	case event.LevelUpEvent:
+		text := fmt.Sprintf("%s reached level <b>%d</b>", supervisor, evt.Level)
+		if evt.ExperiencePerHour > 0 {
+			text += fmt.Sprintf("\nXP/h: %.0f", evt.ExperiencePerHour)
 		}
+		if evt.NextLevelETA > 0 {
+			text += fmt.Sprintf("\nNext level in %s", evt.NextLevelETA.Round(time.Minute))
+		}
+		return text
+	case event.StuckEvent:
+		return fmt.Sprintf("%s is stuck in <b>%s</b> at %d,%d\nSeed: <code>%d</code>, recovery: <i>%s</i>", supervisor, html.EscapeString(evt.Area.Area().Name), evt.Position.X, evt.Position.Y, evt.MapSeed, html.EscapeString(evt.Recovery))
+	}
+
+	return fmt.Sprintf("%s: %s", supervisor, message)
+}
 
-		photo := tgbotapi.NewPhoto(b.chatID, tgbotapi.FileBytes{
-			Name:  e.Message(),
-			Bytes: buf.Bytes(),
-		})
-		photo.Caption = e.Message()
+func finishReasonText(reason event.FinishReason) string {
+	switch reason {
+	case event.FinishedOK:
+		return "OK"
+	case event.FinishedDied:
+		return "Died"
+	case event.FinishedChicken:
+		return "Chicken"
+	case event.FinishedMercChicken:
+		return "Merc chicken"
+	case event.FinishedError:
+		return "Error"
+	}
 
-		_, err = b.bot.Send(photo)
+	return html.EscapeString(string(reason))
+}
 
+// sendImage sends the image as JPEG to the chat, caption is HTML formatted
+func (b *Bot) sendImage(chatID int64, caption string, img image.Image) error {
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, img, &jpeg.Options{Quality: 80}); err != nil {
 		return err
 	}
 
-	_, err := b.bot.Send(tgbotapi.NewMessage(b.chatID, e.Message()))
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{
+		Name:  "screenshot.jpeg",
+		Bytes: buf.Bytes(),
+	})
+	photo.Caption = caption
+	photo.ParseMode = tgbotapi.ModeHTML
+	_, err := b.bot.Send(photo)
 
 	return err
 }
@@ -0,0 +1,137 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// maxOutboxEntries limits the pending deliveries when an URL is down for a long time, the oldest ones are dropped
+const maxOutboxEntries = 1000
+
+// delivery is a payload pending to be sent to one URL
+type delivery struct {
+	ID          string          `json:"id"`
+	URL         string          `json:"url"`
+	Type        string          `json:"type"`
+	Body        json.RawMessage `json:"body"`
+	Attempts    int             `json:"attempts"`
+	NextAttempt time.Time       `json:"nextAttempt"`
+}
+
+// outbox keeps the pending deliveries, they are written to disk by save so they survive restarts
+type outbox struct {
+	path       string
+	mu         sync.Mutex
+	deliveries []delivery
+	// dirty is true when there are changes not saved yet
+	dirty bool
+}
+
+func loadOutbox(path string) (*outbox, error) {
+	o := &outbox{path: path}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(content, &o.deliveries); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// add appends the deliveries, it returns how many old deliveries were dropped to stay under maxOutboxEntries
+func (o *outbox) add(deliveries ...delivery) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.deliveries = append(o.deliveries, deliveries...)
+	dropped := max(0, len(o.deliveries)-maxOutboxEntries)
+	o.deliveries = o.deliveries[dropped:]
+	o.dirty = o.dirty || len(deliveries) > 0
+
+	return dropped
+}
+
+// due returns the deliveries ready to be sent, and when the next one will be ready (zero if there is none)
+func (o *outbox) due(now time.Time) ([]delivery, time.Time) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var due []delivery
+	var next time.Time
+	for _, d := range o.deliveries {
+		if !d.NextAttempt.After(now) {
+			due = append(due, d)
+		} else if next.IsZero() || d.NextAttempt.Before(next) {
+			next = d.NextAttempt
+		}
+	}
+
+	return due, next
+}
+
+func (o *outbox) remove(id string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for i, d := range o.deliveries {
+		if d.ID == id {
+			o.deliveries = append(o.deliveries[:i], o.deliveries[i+1:]...)
+			o.dirty = true
+			return
+		}
+	}
+}
+
+func (o *outbox) update(updated delivery) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for i, d := range o.deliveries {
+		if d.ID == updated.ID {
+			o.deliveries[i] = updated
+			o.dirty = true
+			return
+		}
+	}
+}
+
+// save writes the outbox when there are changes, to a temporary file first so a crash while writing doesn't lose the
+// previous content
+func (o *outbox) save() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if !o.dirty {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(o.path), os.ModePerm); err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(o.deliveries)
+	if err != nil {
+		return err
+	}
+
+	tmp := o.path + ".tmp"
+	if err = os.WriteFile(tmp, content, 0644); err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmp, o.path); err != nil {
+		return err
+	}
+	o.dirty = false
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/jpeg"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/event"
+)
+
+// Payload is the JSON body POSTed for every event, fields that don't apply to the event type are omitted
+type Payload struct {
+	Type       string    `json:"type"`
+	Supervisor string    `json:"supervisor"`
+	Message    string    `json:"message"`
+	OccurredAt time.Time `json:"occurredAt"`
+	// RunName is the run the event belongs to, it's also set for events happening in the middle of a run (drops...)
+	RunName string `json:"runName,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Item    *Item  `json:"item,omitempty"`
+	// Level, ExperiencePerHour and NextLevelETASeconds are only sent with level_up events
+	Level               int     `json:"level,omitempty"`
+	ExperiencePerHour   float64 `json:"experiencePerHour,omitempty"`
+	NextLevelETASeconds float64 `json:"nextLevelEtaSeconds,omitempty"`
+	// Screenshot is a base64 encoded JPEG, only sent when it's enabled and the event has one
+	Screenshot string `json:"screenshot,omitempty"`
+}
+
+type Item struct {
+	Name         string `json:"name"`
+	BaseName     string `json:"baseName"`
+	Quality      string `json:"quality"`
+	Ethereal     bool   `json:"ethereal"`
+	Identified   bool   `json:"identified"`
+	Rule         string `json:"rule,omitempty"`
+	RuleFile     string `json:"ruleFile,omitempty"`
+	DropLocation string `json:"dropLocation,omitempty"`
+	Gambled      bool   `json:"gambled,omitempty"`
+}
+
+func newPayload(e event.Event, runName string, includeScreenshot bool) (Payload, error) {
+	p := Payload{
+		Type:       event.Type(e),
+		Supervisor: e.Supervisor(),
+		Message:    e.Message(),
+		OccurredAt: e.OccurredAt(),
+		RunName:    runName,
+	}
+
+	switch evt := e.(type) {
+	case event.GameFinishedEvent:
+		p.Reason = string(evt.Reason)
+	case event.RunStartedEvent:
+		p.RunName = evt.RunName
+	case event.RunFinishedEvent:
+		p.RunName = evt.RunName
+		p.Reason = string(evt.Reason)
+	case event.ItemStashedEvent:
+		p.Item = newItem(evt.Item)
+		p.Item.Gambled = evt.Gambled
+	case event.ItemBlackListedEvent:
+		p.Item = newItem(evt.Item)
+	case event.LevelUpEvent:
+		p.Level = evt.Level
+		p.ExperiencePerHour = evt.ExperiencePerHour
+		p.NextLevelETASeconds = evt.NextLevelETA.Seconds()
+	}
+
+	if includeScreenshot && e.Image() != nil {
+		buf := new(bytes.Buffer)
+		if err := jpeg.Encode(buf, e.Image(), &jpeg.Options{Quality: 80}); err != nil {
+			return Payload{}, err
+		}
+		p.Screenshot = base64.StdEncoding.EncodeToString(buf.Bytes())
+	}
+
+	return p, nil
+}
+
+func newItem(drop data.Drop) *Item {
+	name := drop.Item.IdentifiedName
+	if name == "" {
+		name = string(drop.Item.Name)
+	}
+
+	return &Item{
+		Name:         name,
+		BaseName:     string(drop.Item.Name),
+		Quality:      drop.Item.Quality.ToString(),
+		Ethereal:     drop.Item.Ethereal,
+		Identified:   drop.Item.Identified,
+		Rule:         drop.Rule,
+		RuleFile:     drop.RuleFile,
+		DropLocation: drop.DropLocation,
+	}
+}
@@ -0,0 +1,310 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/event"
+)
+
+const (
+	defaultMaxRetries     = 5
+	defaultInitialBackoff = time.Second * 5
+	defaultMaxBackoff     = time.Minute * 10
+	requestTimeout        = time.Second * 10
+	// maxQueuedEvents limits the events waiting to be added to the outbox, the oldest ones are dropped
+	maxQueuedEvents = 1000
+)
+
+// defaultEvents are sent when no events are configured, the noisy ones (potions, interactions...) are left out
+var defaultEvents = []string{
+	"game_created", "game_finished", "run_started", "run_finished", "item_stashed", "goal_reached", "goal_item_found",
+	"stuck", "stash_full", "death_loop", "crash_circuit_open", "level_up",
+}
+
+// queuedEvent is an event received from the listener, it's encoded and added to the outbox by Start
+type queuedEvent struct {
+	event   event.Event
+	runName string
+	urls    []string
+}
+
+// Notifier POSTs the events as JSON to the configured URLs. The listener only queues the events in memory, Start
+// encodes them, keeps them in a persistent outbox and sends them, so a slow or down endpoint never blocks the bot.
+type Notifier struct {
+	urls              []string
+	events            []string
+	secret            []byte
+	includeScreenshot bool
+	maxRetries        int
+	initialBackoff    time.Duration
+	maxBackoff        time.Duration
+	client            *http.Client
+	outbox            *outbox
+	logger            *slog.Logger
+	wake              chan struct{}
+
+	queueMu sync.Mutex
+	queue   []queuedEvent
+
+	// runs is the current run of every supervisor, to set the run name in the events happening during the run
+	runsMu sync.Mutex
+	runs   map[string]string
+}
+
+func NewNotifier(logger *slog.Logger) (*Notifier, error) {
+	cfg := config.Koolo.Webhook
+
+	outboxPath := cfg.OutboxPath
+	if outboxPath == "" {
+		outboxPath = filepath.Join("cache", "webhook_outbox.json")
+	}
+	o, err := loadOutbox(outboxPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading webhook outbox %s: %w", outboxPath, err)
+	}
+
+	maxRetries := cfg.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultMaxRetries
+	}
+
+	events := cfg.Events
+	if len(events) == 0 {
+		events = defaultEvents
+	}
+
+	return &Notifier{
+		urls:              cfg.URLs,
+		events:            events,
+		secret:            []byte(cfg.Secret),
+		includeScreenshot: cfg.IncludeScreenshot,
+		maxRetries:        maxRetries,
+		initialBackoff:    defaultInitialBackoff,
+		maxBackoff:        defaultMaxBackoff,
+		client:            &http.Client{Timeout: requestTimeout},
+		outbox:            o,
+		logger:            logger,
+		wake:              make(chan struct{}, 1),
+		runs:              make(map[string]string),
+	}, nil
+}
+
+func (n *Notifier) Handle(_ context.Context, e event.Event) error {
+	runName := n.trackRun(e)
+	if !slices.Contains(n.events, event.Type(e)) {
+		return nil
+	}
+
+	n.enqueue(queuedEvent{event: e, runName: runName, urls: n.urls})
+
+	return nil
+}
+
+// Deliver sends the event routed by a notification rule, target is the URL (empty for the configured ones). The rule
+// already decided to send it, so the configured event types are ignored.
+func (n *Notifier) Deliver(_ context.Context, e event.Event, target string) error {
+	qe := queuedEvent{event: e, runName: n.trackRun(e), urls: n.urls}
+	if target != "" {
+		qe.urls = []string{target}
+	}
+	n.enqueue(qe)
+
+	return nil
+}
+
+// enqueue adds the event to the in memory queue and wakes up the sender, it's called from the listener goroutine so
+// it must be fast
+func (n *Notifier) enqueue(qe queuedEvent) {
+	n.queueMu.Lock()
+	n.queue = append(n.queue, qe)
+	if dropped := len(n.queue) - maxQueuedEvents; dropped > 0 {
+		n.queue = n.queue[dropped:]
+		n.logger.Warn("Webhook queue is full, dropping the oldest events", slog.Int("dropped", dropped))
+	}
+	n.queueMu.Unlock()
+
+	// Wake up the sender without blocking, if it's already awake it will see the new events anyway
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// moveQueueToOutbox encodes the queued events and adds them to the outbox, one delivery per URL
+func (n *Notifier) moveQueueToOutbox() {
+	n.queueMu.Lock()
+	queue := n.queue
+	n.queue = nil
+	n.queueMu.Unlock()
+
+	for _, qe := range queue {
+		payload, err := newPayload(qe.event, qe.runName, n.includeScreenshot)
+		if err != nil {
+			n.logger.Error("Error encoding webhook payload", slog.String("type", event.Type(qe.event)), slog.Any("error", err))
+			continue
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			n.logger.Error("Error encoding webhook payload", slog.String("type", event.Type(qe.event)), slog.Any("error", err))
+			continue
+		}
+
+		deliveries := make([]delivery, 0, len(qe.urls))
+		for _, url := range qe.urls {
+			deliveries = append(deliveries, delivery{ID: newDeliveryID(), URL: url, Type: payload.Type, Body: body, NextAttempt: time.Now()})
+		}
+		if dropped := n.outbox.add(deliveries...); dropped > 0 {
+			n.logger.Warn("Webhook outbox is full, dropping the oldest deliveries", slog.Int("dropped", dropped))
+		}
+	}
+}
+
+// trackRun returns the current run of the event supervisor
+func (n *Notifier) trackRun(e event.Event) string {
+	n.runsMu.Lock()
+	defer n.runsMu.Unlock()
+
+	switch evt := e.(type) {
+	case event.RunStartedEvent:
+		n.runs[e.Supervisor()] = evt.RunName
+	case event.RunFinishedEvent, event.GameFinishedEvent:
+		runName := n.runs[e.Supervisor()]
+		delete(n.runs, e.Supervisor())
+		return runName
+	}
+
+	return n.runs[e.Supervisor()]
+}
+
+// Start sends the pending deliveries, including the ones left in the outbox by a previous execution, until the context
+// is finished. The outbox is saved after every pass, and the events still queued are saved before returning.
+func (n *Notifier) Start(ctx context.Context) error {
+	for {
+		n.moveQueueToOutbox()
+		wait := time.Hour
+		if next := n.flush(ctx); !next.IsZero() {
+			wait = time.Until(next)
+		}
+		n.saveOutbox()
+
+		select {
+		case <-ctx.Done():
+			n.moveQueueToOutbox()
+			n.saveOutbox()
+			return nil
+		case <-n.wake:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// flush sends the due deliveries and returns when the next retry is due, zero if there is nothing pending
+func (n *Notifier) flush(ctx context.Context) time.Time {
+	due, _ := n.outbox.due(time.Now())
+	for _, d := range due {
+		if ctx.Err() != nil {
+			break
+		}
+
+		permanent, err := n.post(ctx, d)
+		if err == nil {
+			n.outbox.remove(d.ID)
+			continue
+		}
+
+		d.Attempts++
+		if permanent || d.Attempts > n.maxRetries {
+			n.logger.Error("Webhook delivery failed, giving up", slog.String("url", d.URL), slog.String("type", d.Type), slog.Int("attempts", d.Attempts), slog.Any("error", err))
+			n.outbox.remove(d.ID)
+			continue
+		}
+
+		d.NextAttempt = time.Now().Add(n.backoff(d.Attempts))
+		n.logger.Warn("Webhook delivery failed, retrying", slog.String("url", d.URL), slog.String("type", d.Type), slog.Time("nextAttempt", d.NextAttempt), slog.Any("error", err))
+		n.outbox.update(d)
+	}
+
+	due, next := n.outbox.due(time.Now())
+	if len(due) > 0 && ctx.Err() == nil {
+		return time.Now()
+	}
+
+	return next
+}
+
+// backoff doubles the wait after every failed attempt, up to maxBackoff
+func (n *Notifier) backoff(attempts int) time.Duration {
+	wait := n.initialBackoff
+	for i := 1; i < attempts && wait < n.maxBackoff; i++ {
+		wait *= 2
+	}
+
+	return min(wait, n.maxBackoff)
+}
+
+// post sends the delivery, permanent is true when retrying makes no sense (the request is rejected by the server)
+func (n *Notifier) post(ctx context.Context, d delivery) (permanent bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return true, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Koolo-Event", d.Type)
+	req.Header.Set("X-Koolo-Delivery", d.ID)
+	if len(n.secret) > 0 {
+		req.Header.Set("X-Koolo-Signature", "sha256="+Sign(n.secret, d.Body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	// Client errors won't change retrying, except timeouts and rate limits
+	permanent = resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests
+
+	return permanent, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+}
+
+// saveOutbox writes the outbox to disk when it changed since the last save
+func (n *Notifier) saveOutbox() {
+	if err := n.outbox.save(); err != nil {
+		n.logger.Error("Error saving webhook outbox", slog.Any("error", err))
+	}
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the body, receivers can use it to verify the X-Koolo-Signature header
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newDeliveryID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,285 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/event"
+)
+
+const testSecret = "secret"
+
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+// fakeEndpoint records the requests and replies with the given status codes in order, the last one is repeated
+type fakeEndpoint struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	requests []request
+	received chan struct{}
+}
+
+func newFakeEndpoint(t *testing.T, statuses ...int) *fakeEndpoint {
+	e := &fakeEndpoint{statuses: statuses, received: make(chan struct{}, 100)}
+	e.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		e.mu.Lock()
+		e.requests = append(e.requests, request{header: r.Header.Clone(), body: body})
+		status := e.statuses[min(len(e.requests), len(e.statuses))-1]
+		e.mu.Unlock()
+
+		w.WriteHeader(status)
+		e.received <- struct{}{}
+	}))
+	t.Cleanup(e.Close)
+
+	return e
+}
+
+// waitRequests waits until the endpoint received the given amount of requests
+func (e *fakeEndpoint) waitRequests(t *testing.T, count int) []request {
+	t.Helper()
+
+	for len(e.sent()) < count {
+		select {
+		case <-e.received:
+		case <-time.After(time.Second * 5):
+			t.Fatalf("expected %d requests, received %d", count, len(e.sent()))
+		}
+	}
+
+	return e.sent()
+}
+
+func (e *fakeEndpoint) sent() []request {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return slices.Clone(e.requests)
+}
+
+func newTestNotifier(t *testing.T, outboxPath string, urls ...string) *Notifier {
+	t.Helper()
+
+	config.Koolo = &config.KooloCfg{}
+	config.Koolo.Webhook.URLs = urls
+	config.Koolo.Webhook.Secret = testSecret
+	config.Koolo.Webhook.MaxRetries = 2
+	config.Koolo.Webhook.OutboxPath = outboxPath
+
+	n, err := NewNotifier(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("NewNotifier: %v", err)
+	}
+	n.initialBackoff = time.Millisecond * 10
+	n.maxBackoff = time.Millisecond * 40
+
+	return n
+}
+
+// start runs the notifier until the returned function is called, it waits for Start to return
+func start(n *Notifier) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		n.Start(ctx)
+		close(done)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func gameFinished() event.Event {
+	return event.GameFinished(event.Text("sorc", "Game finished"), event.FinishedChicken)
+}
+
+func TestSignatureHeader(t *testing.T) {
+	endpoint := newFakeEndpoint(t, http.StatusOK)
+	n := newTestNotifier(t, filepath.Join(t.TempDir(), "outbox.json"), endpoint.URL)
+	stop := start(n)
+	defer stop()
+
+	n.Handle(context.Background(), gameFinished())
+
+	req := endpoint.waitRequests(t, 1)[0]
+	if got, expected := req.header.Get("X-Koolo-Signature"), "sha256="+Sign([]byte(testSecret), req.body); got != expected {
+		t.Errorf("expected signature %s, got %s", expected, got)
+	}
+	if got := req.header.Get("X-Koolo-Event"); got != "game_finished" {
+		t.Errorf("expected event header game_finished, got %s", got)
+	}
+
+	var p Payload
+	if err := json.Unmarshal(req.body, &p); err != nil {
+		t.Fatalf("decoding payload: %v", err)
+	}
+	if p.Type != "game_finished" || p.Supervisor != "sorc" || p.Reason != string(event.FinishedChicken) {
+		t.Errorf("unexpected payload %+v", p)
+	}
+}
+
+func TestDefaultEvents(t *testing.T) {
+	endpoint := newFakeEndpoint(t, http.StatusOK)
+	n := newTestNotifier(t, filepath.Join(t.TempDir(), "outbox.json"), endpoint.URL)
+	stop := start(n)
+	defer stop()
+
+	n.Handle(context.Background(), event.UsedPotion(event.Text("sorc", "Potion"), data.HealingPotion, false))
+	n.Handle(context.Background(), event.InteractedTo(event.Text("sorc", "Interacted"), 1, event.InteractionTypeNPC))
+	n.Handle(context.Background(), event.GoalItemStashed(event.Text("sorc", "Goal item stashed"), data.Drop{}))
+	n.Handle(context.Background(), gameFinished())
+
+	req := endpoint.waitRequests(t, 1)[0]
+	if got := req.header.Get("X-Koolo-Event"); got != "game_finished" {
+		t.Errorf("only game_finished should be sent by default, got %s", got)
+	}
+	time.Sleep(time.Millisecond * 50)
+	if sent := len(endpoint.sent()); sent != 1 {
+		t.Errorf("expected a single request, got %d", sent)
+	}
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	endpoint := newFakeEndpoint(t, http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK)
+	outboxPath := filepath.Join(t.TempDir(), "outbox.json")
+	n := newTestNotifier(t, outboxPath, endpoint.URL)
+	stop := start(n)
+
+	n.Handle(context.Background(), gameFinished())
+
+	requests := endpoint.waitRequests(t, 3)
+	waitEmptyOutbox(t, outboxPath)
+	stop()
+
+	// Retries are the same delivery
+	for _, r := range requests[1:] {
+		if r.header.Get("X-Koolo-Delivery") != requests[0].header.Get("X-Koolo-Delivery") {
+			t.Errorf("retries must keep the delivery ID")
+		}
+	}
+
+	expected := []time.Duration{time.Millisecond * 10, time.Millisecond * 20, time.Millisecond * 40, time.Millisecond * 40}
+	for i, wait := range expected {
+		if got := n.backoff(i + 1); got != wait {
+			t.Errorf("backoff after %d attempts: expected %s, got %s", i+1, wait, got)
+		}
+	}
+}
+
+func TestStatusCodes(t *testing.T) {
+	tests := []struct {
+		status   int
+		attempts int
+	}{
+		{status: http.StatusBadRequest, attempts: 1},
+		{status: http.StatusUnauthorized, attempts: 1},
+		{status: http.StatusNotFound, attempts: 1},
+		// Timeouts, rate limits and server errors are retried, the first attempt and maxRetries
+		{status: http.StatusRequestTimeout, attempts: 3},
+		{status: http.StatusTooManyRequests, attempts: 3},
+		{status: http.StatusInternalServerError, attempts: 3},
+		{status: http.StatusServiceUnavailable, attempts: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			endpoint := newFakeEndpoint(t, tt.status)
+			outboxPath := filepath.Join(t.TempDir(), "outbox.json")
+			n := newTestNotifier(t, outboxPath, endpoint.URL)
+			stop := start(n)
+
+			n.Handle(context.Background(), gameFinished())
+
+			endpoint.waitRequests(t, tt.attempts)
+			// Give it time to retry more than expected
+			time.Sleep(time.Millisecond * 150)
+			stop()
+
+			if sent := len(endpoint.sent()); sent != tt.attempts {
+				t.Errorf("expected %d attempts, got %d", tt.attempts, sent)
+			}
+			if pending := readOutbox(t, outboxPath); len(pending) != 0 {
+				t.Errorf("failed delivery should be removed from the outbox, found %d", len(pending))
+			}
+		})
+	}
+}
+
+func TestOutboxSurvivesRestart(t *testing.T) {
+	endpoint := newFakeEndpoint(t, http.StatusOK)
+	outboxPath := filepath.Join(t.TempDir(), "outbox.json")
+
+	// The event is received but the notifier is stopped before sending it
+	n := newTestNotifier(t, outboxPath, endpoint.URL)
+	n.Handle(context.Background(), gameFinished())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	n.Start(ctx)
+
+	if len(endpoint.sent()) != 0 {
+		t.Fatalf("nothing should be sent after the notifier is stopped")
+	}
+	pending := readOutbox(t, outboxPath)
+	if len(pending) != 1 || pending[0].Type != "game_finished" || pending[0].URL != endpoint.URL {
+		t.Fatalf("expected the queued event in the outbox, found %+v", pending)
+	}
+
+	// A new notifier sends what the previous one left in the outbox
+	restarted := newTestNotifier(t, outboxPath, endpoint.URL)
+	stop := start(restarted)
+	req := endpoint.waitRequests(t, 1)[0]
+	waitEmptyOutbox(t, outboxPath)
+	stop()
+
+	if req.header.Get("X-Koolo-Delivery") != pending[0].ID {
+		t.Errorf("expected delivery %s, got %s", pending[0].ID, req.header.Get("X-Koolo-Delivery"))
+	}
+}
+
+// waitEmptyOutbox waits until the deliveries are removed from the outbox file, after the responses are processed
+func waitEmptyOutbox(t *testing.T, path string) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second * 5)
+	for len(readOutbox(t, path)) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("outbox should be empty after the delivery")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func readOutbox(t *testing.T, path string) []delivery {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading outbox: %v", err)
+	}
+	var deliveries []delivery
+	if err = json.Unmarshal(content, &deliveries); err != nil {
+		t.Fatalf("decoding outbox: %v", err)
+	}
+
+	return deliveries
+}
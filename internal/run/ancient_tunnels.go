@@ -44,5 +44,5 @@ func (a AncientTunnels) Run() error {
 
 	// Clear Ancient Tunnels
 
-	return action.ClearCurrentLevel(openChests, filter)
+	return action.ClearCurrentLevel(openChests, filter, action.WithSkipEmptyRooms(onlyElites))
 }
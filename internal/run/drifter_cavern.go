@@ -43,5 +43,5 @@ func (s DrifterCavern) Run() error {
 	}
 
 	// Clear the area
-	return action.ClearCurrentLevel(s.ctx.CharacterCfg.Game.DrifterCavern.OpenChests, monsterFilter)
+	return action.ClearCurrentLevel(s.ctx.CharacterCfg.Game.DrifterCavern.OpenChests, monsterFilter, action.WithSkipEmptyRooms(s.ctx.CharacterCfg.Game.DrifterCavern.FocusOnElitePacks))
 }
@@ -52,5 +52,5 @@ func (a Mausoleum) Run() error {
 	action.OpenTPIfLeader()
 
 	// Clear the area
-	return action.ClearCurrentLevel(a.ctx.CharacterCfg.Game.Mausoleum.OpenChests, monsterFilter)
+	return action.ClearCurrentLevel(a.ctx.CharacterCfg.Game.Mausoleum.OpenChests, monsterFilter, action.WithSkipEmptyRooms(a.ctx.CharacterCfg.Game.Mausoleum.FocusOnElitePacks))
 }
@@ -63,7 +63,7 @@ func (p Pit) Run() error {
 
 	// Clear the area if we don't have only clear lvl2 selected
 	if !p.ctx.CharacterCfg.Game.Pit.OnlyClearLevel2 {
-		if err := action.ClearCurrentLevel(p.ctx.CharacterCfg.Game.Pit.OpenChests, monsterFilter); err != nil {
+		if err := action.ClearCurrentLevel(p.ctx.CharacterCfg.Game.Pit.OpenChests, monsterFilter, action.WithSkipEmptyRooms(p.ctx.CharacterCfg.Game.Pit.FocusOnElitePacks), action.WithRouteEnd(area.PitLevel2)); err != nil {
 			return err
 		}
 	}
@@ -74,5 +74,5 @@ func (p Pit) Run() error {
 	}
 
 	// Clear it
-	return action.ClearCurrentLevel(p.ctx.CharacterCfg.Game.Pit.OpenChests, monsterFilter)
+	return action.ClearCurrentLevel(p.ctx.CharacterCfg.Game.Pit.OpenChests, monsterFilter, action.WithSkipEmptyRooms(p.ctx.CharacterCfg.Game.Pit.FocusOnElitePacks))
 }
@@ -0,0 +1,108 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/hectorgimenez/d2go/pkg/data/area"
+	"github.com/hectorgimenez/koolo/internal/action"
+	"github.com/hectorgimenez/koolo/internal/config"
+	"github.com/hectorgimenez/koolo/internal/context"
+	"github.com/hectorgimenez/koolo/internal/town"
+)
+
+type Shopping struct {
+	ctx *context.Status
+}
+
+func NewShopping() *Shopping {
+	return &Shopping{
+		ctx: context.Get(),
+	}
+}
+
+func (s Shopping) Name() string {
+	return string(config.ShoppingRun)
+}
+
+func (s Shopping) Run() error {
+	if len(s.ctx.CharacterCfg.Runtime.ShoppingRules) == 0 {
+		s.ctx.Logger.Info("No shopping rules found, vendor items will only be logged")
+	}
+
+	vendors := make([]town.Vendor, 0, len(s.ctx.CharacterCfg.Shopping.Vendors))
+	for _, name := range s.ctx.CharacterCfg.Shopping.Vendors {
+		vendor, found := town.Vendors[strings.ToLower(name)]
+		if !found {
+			return fmt.Errorf("unknown shopping vendor: %s", name)
+		}
+		vendors = append(vendors, vendor)
+	}
+
+	if len(vendors) == 0 {
+		return errors.New("no shopping vendors configured")
+	}
+
+	spent := 0
+	for pass := 0; pass < s.ctx.CharacterCfg.Shopping.RefreshPasses; pass++ {
+		for _, vendor := range vendors {
+			// Going to another town refreshes the vendor inventory, so there is no need to do anything else
+			// when the vendors are in different acts
+			if err := action.WayPoint(vendor.Town); err != nil {
+				return err
+			}
+
+			budgetLeft := 0
+			if s.ctx.CharacterCfg.Shopping.GoldBudget > 0 {
+				budgetLeft = s.ctx.CharacterCfg.Shopping.GoldBudget - spent
+				if budgetLeft <= 0 {
+					s.ctx.Logger.Info("Shopping gold budget reached", slog.Int("spent", spent))
+					return nil
+				}
+			}
+
+			vendorSpent, err := action.ShopVendor(vendor.NPC, budgetLeft)
+			spent += vendorSpent
+			if err != nil {
+				return err
+			}
+		}
+
+		// Stash what we bought before the next pass, we don't want to run out of space
+		if err := action.Stash(false); err != nil {
+			return err
+		}
+
+		if pass < s.ctx.CharacterCfg.Shopping.RefreshPasses-1 {
+			if err := s.refreshVendors(vendors); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.ctx.Logger.Info("Finished shopping", slog.Int("goldSpent", spent))
+
+	return nil
+}
+
+// refreshVendors leaves the town and comes back, which makes the vendors restock. When all the vendors are in the same
+// act we need to visit another town, otherwise the next pass will switch acts anyway.
+func (s Shopping) refreshVendors(vendors []town.Vendor) error {
+	currentTown := s.ctx.Data.PlayerUnit.Area
+	for _, vendor := range vendors {
+		if vendor.Town != currentTown {
+			return nil
+		}
+	}
+
+	refreshTown := area.Harrogath
+	if currentTown == area.Harrogath {
+		refreshTown = area.ThePandemoniumFortress
+	}
+
+	s.ctx.Logger.Debug("Refreshing vendors stock", slog.String("town", refreshTown.Area().Name))
+
+	return action.WayPoint(refreshTown)
+}
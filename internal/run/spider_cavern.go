@@ -43,7 +43,7 @@ func (run SpiderCavern) Run() error {
 	}
 
 	// Clear the area
-	action.ClearCurrentLevel(run.ctx.CharacterCfg.Game.SpiderCavern.OpenChests, monsterFilter)
+	action.ClearCurrentLevel(run.ctx.CharacterCfg.Game.SpiderCavern.OpenChests, monsterFilter, action.WithSkipEmptyRooms(run.ctx.CharacterCfg.Game.SpiderCavern.FocusOnElitePacks))
 
 	// Return to town
 	if err = action.ReturnTown(); err != nil {
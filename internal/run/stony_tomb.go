@@ -52,7 +52,7 @@ func (s StonyTomb) Run() error {
 	action.OpenTPIfLeader()
 
 	// Clear the area
-	if err = action.ClearCurrentLevel(s.ctx.CharacterCfg.Game.StonyTomb.OpenChests, monsterFilter); err != nil {
+	if err = action.ClearCurrentLevel(s.ctx.CharacterCfg.Game.StonyTomb.OpenChests, monsterFilter, action.WithSkipEmptyRooms(s.ctx.CharacterCfg.Game.StonyTomb.FocusOnElitePacks), action.WithRouteEnd(area.StonyTombLevel2)); err != nil {
 		return err
 	}
 
@@ -62,5 +62,5 @@ func (s StonyTomb) Run() error {
 	}
 
 	// Clear the area
-	return action.ClearCurrentLevel(s.ctx.CharacterCfg.Game.StonyTomb.OpenChests, monsterFilter)
+	return action.ClearCurrentLevel(s.ctx.CharacterCfg.Game.StonyTomb.OpenChests, monsterFilter, action.WithSkipEmptyRooms(s.ctx.CharacterCfg.Game.StonyTomb.FocusOnElitePacks))
 }
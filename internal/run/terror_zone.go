@@ -92,7 +92,7 @@ func (tz TerrorZone) Run() error {
 				}
 			}
 			if slices.Contains(availableTzs, tzArea) {
-				action.ClearCurrentLevel(tz.ctx.CharacterCfg.Game.TerrorZone.OpenChests, tz.customTZEnemyFilter())
+				action.ClearCurrentLevel(tz.ctx.CharacterCfg.Game.TerrorZone.OpenChests, tz.customTZEnemyFilter(), action.WithSkipEmptyRooms(tz.ctx.CharacterCfg.Game.TerrorZone.FocusOnElitePacks))
 			} else {
 				tz.ctx.Logger.Debug("Skipping area %v", tzArea.Area().Name)
 			}
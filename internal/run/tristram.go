@@ -85,11 +85,12 @@ func (t Tristram) Run() error {
 		})
 	} else {
 		filter := data.MonsterAnyFilter()
-		if t.ctx.CharacterCfg.Game.Tristram.FocusOnElitePacks && t.ctx.CharacterCfg.Game.Runs[0] != "leveling" {
+		onlyElites := t.ctx.CharacterCfg.Game.Tristram.FocusOnElitePacks && t.ctx.CharacterCfg.Game.Runs[0] != "leveling"
+		if onlyElites {
 			filter = data.MonsterEliteFilter()
 		}
 
-		return action.ClearCurrentLevel(false, filter)
+		return action.ClearCurrentLevel(false, filter, action.WithSkipEmptyRooms(onlyElites))
 	}
 
 	return nil
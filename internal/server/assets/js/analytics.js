@@ -0,0 +1,123 @@
+const windowSelect = document.getElementById('window');
+const tableBody = document.querySelector('#analytics tbody');
+const analyticsStatus = document.getElementById('analytics-status');
+const refreshInterval = 30000;
+
+// Columns highlighted in every group of runs, higher is better unless lowerIsBetter
+const comparedColumns = {
+    successRate: {},
+    medianDurationSeconds: {lowerIsBetter: true},
+    dropsPerHour: {},
+    experiencePerHour: {},
+    goldPerHour: {},
+    potionsPerRun: {lowerIsBetter: true},
+};
+
+function escapeHTML(text) {
+    const div = document.createElement('div');
+    div.textContent = text;
+    return div.innerHTML;
+}
+
+function formatDuration(seconds) {
+    const rounded = Math.round(seconds);
+    const minutes = Math.floor(rounded / 60);
+    return minutes > 0 ? `${minutes}m ${rounded % 60}s` : `${rounded}s`;
+}
+
+function formatNumber(value) {
+    return Math.round(value).toLocaleString();
+}
+
+function formatPercent(value) {
+    return `${value.toFixed(1)}%`;
+}
+
+function breakdown(values, format) {
+    return Object.entries(values)
+        .sort((a, b) => b[1] - a[1])
+        .map(([key, value]) => `${key}: ${format(value)}`)
+        .join('\n');
+}
+
+function bestValues(group) {
+    const best = {};
+    if (group.length < 2) {
+        return best;
+    }
+
+    for (const [column, options] of Object.entries(comparedColumns)) {
+        const values = group.map(r => r[column]);
+        best[column] = options.lowerIsBetter ? Math.min(...values) : Math.max(...values);
+    }
+    return best;
+}
+
+function cell(row, column, text, best, title) {
+    const classes = [];
+    if (best[column] !== undefined && row[column] === best[column]) {
+        classes.push('best');
+    }
+    if ((column === 'deathRate' || column === 'errorRate') && row[column] > 0) {
+        classes.push('bad');
+    }
+    const titleAttr = title ? ` title="${escapeHTML(title)}"` : '';
+    return `<td class="${classes.join(' ')}"${titleAttr}>${text}</td>`;
+}
+
+function render(data) {
+    const groups = new Map();
+    data.runs.forEach(r => {
+        if (!groups.has(r.run)) {
+            groups.set(r.run, []);
+        }
+        groups.get(r.run).push(r);
+    });
+
+    let html = '';
+    groups.forEach((group, runName) => {
+        const best = bestValues(group);
+        group.forEach((r, i) => {
+            html += `<tr class="${i === 0 ? 'group-start' : ''}">
+                <td class="run-name">${i === 0 ? escapeHTML(runName) : ''}</td>
+                <td>${escapeHTML(r.supervisor)}</td>
+                <td>${r.runs}</td>
+                ${cell(r, 'successRate', formatPercent(r.successRate), best)}
+                ${cell(r, 'chickenRate', formatPercent(r.chickenRate), best)}
+                ${cell(r, 'deathRate', formatPercent(r.deathRate), best)}
+                ${cell(r, 'errorRate', formatPercent(r.errorRate), best)}
+                ${cell(r, 'meanDurationSeconds', formatDuration(r.meanDurationSeconds), best)}
+                ${cell(r, 'medianDurationSeconds', formatDuration(r.medianDurationSeconds), best)}
+                ${cell(r, 'p95DurationSeconds', formatDuration(r.p95DurationSeconds), best)}
+                ${cell(r, 'dropsPerHour', r.dropsPerHour.toFixed(1), best, breakdown(r.dropsPerHourByQuality, v => v.toFixed(1)))}
+                ${cell(r, 'experiencePerHour', formatNumber(r.experiencePerHour), best)}
+                ${cell(r, 'goldPerHour', formatNumber(r.goldPerHour), best)}
+                ${cell(r, 'potionsPerRun', `${r.potions} (${r.potionsPerRun.toFixed(1)}/run)`, best, breakdown(r.potionsByType, v => v))}
+            </tr>`;
+        });
+    });
+
+    tableBody.innerHTML = html || '<tr><td colspan="14">No finished runs in this window</td></tr>';
+    analyticsStatus.textContent = `Updated at ${new Date(data.now).toLocaleTimeString()}`;
+}
+
+async function load() {
+    const params = new URLSearchParams();
+    if (windowSelect.value !== '') {
+        params.set('window', windowSelect.value);
+    }
+
+    try {
+        const response = await fetch(`/api/v1/analytics/runs?${params}`);
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+        render(await response.json());
+    } catch (err) {
+        analyticsStatus.textContent = `Error loading analytics: ${err.message}`;
+    }
+}
+
+windowSelect.onchange = load;
+load();
+setInterval(load, refreshInterval);
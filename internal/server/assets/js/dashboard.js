@@ -63,6 +63,9 @@ let socket;
                 container.appendChild(card);
             }
             updateCharacterCard(card, key, value, data.DropCount[key]);
+            updateGoals(card, data.Goals ? data.Goals[key] : null);
+            updateExperience(card, data.Experience ? data.Experience[key] : null);
+            updateGambling(card, value.Gambling);
         }
 
         // Remove cards for characters that no longer exist
@@ -89,6 +92,12 @@ let socket;
                     <button class="btn btn-outline" onclick="location.href='/debug?characterName=${key}'">
                         <i class="bi bi-bug btn-icon"></i>Debug
                     </button>
+                    <button class="btn btn-outline" onclick="location.href='/map?characterName=${key}'">
+                        <i class="bi bi-map btn-icon"></i>Map
+                    </button>
+                    <button class="btn btn-outline" onclick="location.href='/timeline?characterName=${key}'">
+                        <i class="bi bi-bar-chart-steps btn-icon"></i>Timeline
+                    </button>
                     <button class="btn btn-outline" onclick="location.href='/supervisorSettings?supervisor=${key}'">
                         <i class="bi bi-gear btn-icon"></i>Settings
                     </button>
@@ -132,6 +141,9 @@ let socket;
                         <div class="stat-value errors">0</div>
                     </div>
                 </div>
+                <div class="experience"></div>
+                <div class="gambling"></div>
+                <div class="goals"></div>
                 <div class="run-stats"></div>
             </div>
         `;
@@ -363,6 +375,118 @@ function updateButtons(startPauseBtn, stopBtn, attachBtn, status) {
     }   
 
 
+    function updateGoals(card, goals) {
+        const goalsElement = card.querySelector('.goals');
+        if (!goalsElement) return;
+
+        if (!goals || goals.length === 0) {
+            goalsElement.innerHTML = '';
+            return;
+        }
+
+        goalsElement.innerHTML = '<h3>Goals</h3>';
+
+        const goalsGrid = document.createElement('div');
+        goalsGrid.className = 'run-stats-grid';
+
+        goals.forEach(goal => {
+            const goalElement = document.createElement('div');
+            goalElement.className = 'run-stat';
+            if (goal.Reached) {
+                goalElement.classList.add('current-run');
+            }
+            goalElement.innerHTML = `
+                <h4>${escapeHTML(goal.Name)}${goal.Reached ? ' <span class="current-run-indicator">Reached</span>' : ''}</h4>
+                <div class="run-stat-content">
+                    <div class="run-stat-item" title="Goal type">
+                        <span class="stat-label">Type:</span> ${goal.Type}
+                    </div>
+                    <div class="run-stat-item" title="Progress">
+                        <span class="stat-label">Progress:</span> ${Math.min(goal.Current, goal.Target)} / ${goal.Target}
+                    </div>
+                    ${goal.Reached ? `<div class="run-stat-item" title="Reached at">
+                        <span class="stat-label">Reached:</span> ${new Date(goal.ReachedAt).toLocaleString()}
+                    </div>` : ''}
+                </div>
+            `;
+            goalsGrid.appendChild(goalElement);
+        });
+
+        goalsElement.appendChild(goalsGrid);
+    }
+
+
+    function updateExperience(card, experience) {
+        const experienceElement = card.querySelector('.experience');
+        if (!experienceElement) return;
+
+        if (!experience || !experience.Tracked) {
+            experienceElement.innerHTML = '';
+            return;
+        }
+
+        // NextLevelETA is a Go duration, in nanoseconds
+        const eta = experience.NextLevelETAKnown ? formatDuration(experience.NextLevelETA / 1e6) : '-';
+        const lastLevelUp = experience.LevelUps && experience.LevelUps.length > 0
+            ? experience.LevelUps[experience.LevelUps.length - 1] : null;
+
+        experienceElement.innerHTML = `
+            <h3>Experience</h3>
+            <div class="stats-grid">
+                <div class="stat-item" title="${experience.Experience.toLocaleString()} experience">
+                    <div class="stat-label">Level</div>
+                    <div class="stat-value">${experience.Level} (${experience.NextLevelPercent.toFixed(1)}%)</div>
+                </div>
+                <div class="stat-item" title="${experience.Gained.toLocaleString()} experience gained">
+                    <div class="stat-label">XP/h</div>
+                    <div class="stat-value">${Math.round(experience.PerHour).toLocaleString()}</div>
+                </div>
+                <div class="stat-item" title="${experience.ToNextLevel.toLocaleString()} experience to the next level">
+                    <div class="stat-label">Next level in</div>
+                    <div class="stat-value">${eta}</div>
+                </div>
+                <div class="stat-item" title="${lastLevelUp ? `Level ${lastLevelUp.Level} reached at ${new Date(lastLevelUp.ReachedAt).toLocaleString()}` : ''}">
+                    <div class="stat-label">Level ups</div>
+                    <div class="stat-value">${experience.LevelUps ? experience.LevelUps.length : 0}</div>
+                </div>
+            </div>
+        `;
+    }
+
+
+    function updateGambling(card, gambling) {
+        const gamblingElement = card.querySelector('.gambling');
+        if (!gamblingElement) return;
+
+        if (!gambling || gambling.ItemsBought === 0) {
+            gamblingElement.innerHTML = '';
+            return;
+        }
+
+        gamblingElement.innerHTML = `
+            <h3>Gambling</h3>
+            <div class="stats-grid">
+                <div class="stat-item">
+                    <div class="stat-label">Items bought</div>
+                    <div class="stat-value">${gambling.ItemsBought}</div>
+                </div>
+                <div class="stat-item">
+                    <div class="stat-label">Items kept</div>
+                    <div class="stat-value">${gambling.ItemsKept}</div>
+                </div>
+                <div class="stat-item" title="${gambling.GoldSpent.toLocaleString()} gold spent, ${gambling.GoldRecovered.toLocaleString()} recovered selling the items not kept">
+                    <div class="stat-label">Net gold spent</div>
+                    <div class="stat-value">${(gambling.GoldSpent - gambling.GoldRecovered).toLocaleString()}</div>
+                </div>
+                <div class="stat-item" title="Gold paid for the kept items">
+                    <div class="stat-label">Spent on kept</div>
+                    <div class="stat-value">${gambling.GoldSpentOnKept.toLocaleString()}</div>
+                </div>
+            </div>
+        `;
+    }
+
+
     function calculateRunStats(games) {
         if (!games || games.length === 0) {
             return {};
@@ -462,6 +586,12 @@ function updateButtons(startPauseBtn, stopBtn, attachBtn, status) {
         return `${seconds}s`;
     }
 
+    function escapeHTML(text) {
+        const div = document.createElement('div');
+        div.textContent = text;
+        return div.innerHTML;
+    }
+
     function saveExpandedState() {
         const expandedCards = Array.from(document.querySelectorAll('.character-card.expanded'))
             .map(card => card.id);
@@ -0,0 +1,133 @@
+const form = document.getElementById('drop-filters');
+const tableBody = document.querySelector('#drops tbody');
+const dropsStatus = document.getElementById('drops-status');
+const pageInfo = document.getElementById('page-info');
+const pageSize = document.getElementById('page-size');
+const listFilters = ['supervisor', 'quality', 'type', 'run', 'area'];
+const textFilters = ['rule', 'from', 'to'];
+
+// The filters, sorting and page live in the URL, so a filtered view can be bookmarked or linked
+const state = new URLSearchParams(window.location.search);
+
+function escapeHTML(text) {
+    const div = document.createElement('div');
+    div.textContent = text;
+    return div.innerHTML;
+}
+
+function readForm() {
+    listFilters.forEach(name => {
+        const selected = [...form.elements[name].selectedOptions].map(o => o.value);
+        selected.length > 0 ? state.set(name, selected.join(',')) : state.delete(name);
+    });
+    textFilters.forEach(name => {
+        const value = form.elements[name].value.trim();
+        value !== '' ? state.set(name, value) : state.delete(name);
+    });
+}
+
+function fillForm() {
+    textFilters.forEach(name => form.elements[name].value = state.get(name) || '');
+    pageSize.value = state.get('pageSize') || '50';
+}
+
+// Keeps the selected values even when they aren't in the history anymore
+function fillOptions(name, values) {
+    const select = form.elements[name];
+    const selected = (state.get(name) || '').split(',').filter(v => v !== '');
+    const all = [...new Set([...values, ...selected])];
+    select.innerHTML = all.map(v => `<option value="${escapeHTML(v)}">${escapeHTML(v)}</option>`).join('');
+    [...select.options].forEach(o => o.selected = selected.includes(o.value));
+}
+
+function updateSortHeaders() {
+    const sort = state.get('sort') || 'stashedAt';
+    const order = state.get('order') || 'desc';
+    document.querySelectorAll('th[data-sort]').forEach(th => {
+        th.classList.toggle('sort-asc', th.dataset.sort === sort && order === 'asc');
+        th.classList.toggle('sort-desc', th.dataset.sort === sort && order !== 'asc');
+    });
+}
+
+function render(data) {
+    listFilters.forEach(name => fillOptions(name, data.options[name === 'quality' ? 'qualities' : name + 's']));
+
+    tableBody.innerHTML = data.drops.map(d => `
+        <tr>
+            <td>${new Date(d.stashedAt).toLocaleString()}</td>
+            <td>${escapeHTML(d.supervisor)}</td>
+            <td class="quality-${escapeHTML(d.quality)}" title="${escapeHTML(d.baseName)}">${escapeHTML(d.name)}${d.ethereal ? ' (eth)' : ''}</td>
+            <td>${escapeHTML(d.quality)}</td>
+            <td>${escapeHTML(d.type)}</td>
+            <td>${escapeHTML(d.run || (d.gambled ? 'Gambled' : '-'))}</td>
+            <td>${escapeHTML(d.area || d.location)}</td>
+            <td class="rule" title="${escapeHTML(d.ruleFile)}">${escapeHTML(d.rule)}</td>
+        </tr>`).join('');
+
+    const pages = Math.max(1, Math.ceil(data.total / data.pageSize));
+    pageInfo.textContent = `Page ${data.page} of ${pages}`;
+    document.getElementById('page-prev').disabled = data.page <= 1;
+    document.getElementById('page-next').disabled = data.page >= pages;
+    dropsStatus.textContent = `${data.total} drops`;
+    updateSortHeaders();
+}
+
+async function load() {
+    history.replaceState(null, '', `${window.location.pathname}?${state}`);
+    try {
+        const response = await fetch(`/api/v1/drops?${state}`);
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+        render(await response.json());
+    } catch (err) {
+        dropsStatus.textContent = `Error loading drops: ${err.message}`;
+    }
+}
+
+function goToPage(page) {
+    state.set('page', page);
+    load();
+}
+
+function exportDrops(format) {
+    const params = new URLSearchParams(state);
+    ['page', 'pageSize'].forEach(p => params.delete(p));
+    params.set('format', format);
+    window.location.href = `/api/v1/drops/export?${params}`;
+}
+
+form.onsubmit = e => {
+    e.preventDefault();
+    readForm();
+    goToPage(1);
+};
+
+document.getElementById('filters-reset').onclick = () => {
+    [...listFilters, ...textFilters, 'page'].forEach(name => state.delete(name));
+    form.querySelectorAll('option').forEach(o => o.selected = false);
+    fillForm();
+    load();
+};
+
+document.getElementById('export-csv').onclick = () => exportDrops('csv');
+document.getElementById('export-json').onclick = () => exportDrops('json');
+document.getElementById('page-prev').onclick = () => goToPage(Number(state.get('page') || 1) - 1);
+document.getElementById('page-next').onclick = () => goToPage(Number(state.get('page') || 1) + 1);
+
+pageSize.onchange = () => {
+    state.set('pageSize', pageSize.value);
+    goToPage(1);
+};
+
+document.querySelectorAll('th[data-sort]').forEach(th => {
+    th.onclick = () => {
+        const sameColumn = (state.get('sort') || 'stashedAt') === th.dataset.sort;
+        state.set('sort', th.dataset.sort);
+        state.set('order', sameColumn && state.get('order') !== 'asc' ? 'asc' : 'desc');
+        goToPage(1);
+    };
+});
+
+fillForm();
+load();
@@ -0,0 +1,33 @@
+const characterName = new URLSearchParams(window.location.search).get('characterName');
+const mapImage = document.getElementById('map-image');
+const mapStatus = document.getElementById('map-status');
+let currentFrameURL = null;
+
+document.getElementById('supervisor-name').textContent = `Live Map: ${characterName}`;
+
+function connect() {
+    const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+    const socket = new WebSocket(`${protocol}//${window.location.host}/api/v1/supervisors/${encodeURIComponent(characterName)}/map/ws`);
+    socket.binaryType = 'blob';
+
+    socket.onopen = () => {
+        mapStatus.textContent = 'Waiting for the game...';
+    };
+
+    socket.onmessage = (event) => {
+        const frameURL = URL.createObjectURL(event.data);
+        mapImage.src = frameURL;
+        if (currentFrameURL) {
+            URL.revokeObjectURL(currentFrameURL);
+        }
+        currentFrameURL = frameURL;
+        mapStatus.textContent = `Updated at ${new Date().toLocaleTimeString()}`;
+    };
+
+    socket.onclose = () => {
+        mapStatus.textContent = 'Disconnected, reconnecting...';
+        setTimeout(connect, 2000);
+    };
+}
+
+connect();
@@ -0,0 +1,132 @@
+const characterName = new URLSearchParams(window.location.search).get('characterName');
+const timelineContainer = document.getElementById('timeline');
+const timelineStatus = document.getElementById('timeline-status');
+const details = document.getElementById('timeline-details');
+const detailsContent = document.getElementById('details-content');
+const detailsScreenshot = document.getElementById('details-screenshot');
+const refreshInterval = 10000;
+
+document.getElementById('supervisor-name').textContent = `Run Timeline: ${characterName}`;
+document.getElementById('details-close').onclick = () => {
+    details.hidden = true;
+    document.querySelectorAll('.run-segment.selected').forEach(el => el.classList.remove('selected'));
+};
+
+function escapeHTML(text) {
+    const div = document.createElement('div');
+    div.textContent = text;
+    return div.innerHTML;
+}
+
+// "merc chicken" uses the same color as chicken
+function reasonClass(reason) {
+    return 'reason-' + (reason === 'merc chicken' ? 'chicken' : reason);
+}
+
+function formatDuration(ms) {
+    const seconds = Math.round(ms / 1000);
+    const minutes = Math.floor(seconds / 60);
+    return minutes > 0 ? `${minutes}m ${seconds % 60}s` : `${seconds}s`;
+}
+
+function endOf(item, now) {
+    return item.reason === 'running' ? now : new Date(item.finishedAt);
+}
+
+function showRun(game, run, now) {
+    const start = new Date(run.startedAt);
+    let html = `<h2>${escapeHTML(run.name)} <small class="${reasonClass(run.reason)}">&nbsp;${escapeHTML(run.reason)}&nbsp;</small></h2>`;
+    html += `<div>Game #${game.index + 1}, started at ${start.toLocaleTimeString()}, ${formatDuration(endOf(run, now) - start)}</div>`;
+    if (run.markers.length > 0) {
+        html += '<ul>' + run.markers.map(m =>
+            `<li>${new Date(m.occurredAt).toLocaleTimeString()} <b>${escapeHTML(m.type)}</b> ${escapeHTML(m.text)}</li>`
+        ).join('') + '</ul>';
+    }
+    showDetails(game, html);
+}
+
+function showGame(game, now) {
+    const start = new Date(game.startedAt);
+    let html = `<h2>Game #${game.index + 1} <small class="${reasonClass(game.reason)}">&nbsp;${escapeHTML(game.reason)}&nbsp;</small></h2>`;
+    html += `<div>Started at ${start.toLocaleTimeString()}, ${formatDuration(endOf(game, now) - start)}, ${game.runs.length} runs</div>`;
+    showDetails(game, html);
+}
+
+function showDetails(game, html) {
+    if (game.message && game.reason !== 'ok' && game.reason !== 'running') {
+        html += `<p>${escapeHTML(game.message)}</p>`;
+    }
+    detailsContent.innerHTML = html;
+    detailsScreenshot.hidden = !game.hasScreenshot;
+    if (game.hasScreenshot) {
+        detailsScreenshot.src = `/api/v1/supervisors/${encodeURIComponent(characterName)}/timeline/${game.index}/screenshot`;
+    }
+    details.hidden = false;
+}
+
+function render(data) {
+    const now = new Date(data.now);
+    timelineContainer.innerHTML = '';
+    if (data.games.length === 0) {
+        timelineContainer.textContent = 'No games played yet.';
+        return;
+    }
+
+    // Newest games first
+    data.games.slice().reverse().forEach(game => {
+        const gameStart = new Date(game.startedAt);
+        const gameDuration = Math.max(endOf(game, now) - gameStart, 1);
+        const position = date => `${Math.min(Math.max((new Date(date) - gameStart) / gameDuration, 0), 1) * 100}%`;
+
+        const row = document.createElement('div');
+        row.className = 'game-row';
+
+        const label = document.createElement('div');
+        label.className = 'game-label' + (game.hasScreenshot ? ' has-screenshot' : '');
+        label.textContent = `#${game.index + 1} ${gameStart.toLocaleTimeString()} (${formatDuration(gameDuration)})`;
+        label.onclick = () => showGame(game, now);
+        row.appendChild(label);
+
+        const bar = document.createElement('div');
+        bar.className = 'game-bar';
+        game.runs.forEach(run => {
+            const segment = document.createElement('div');
+            segment.className = 'run-segment ' + reasonClass(run.reason);
+            segment.style.left = position(run.startedAt);
+            segment.style.width = `calc(${position(endOf(run, now))} - ${position(run.startedAt)})`;
+            segment.textContent = run.name;
+            segment.title = `${run.name}: ${run.reason}`;
+            segment.onclick = () => {
+                document.querySelectorAll('.run-segment.selected').forEach(el => el.classList.remove('selected'));
+                segment.classList.add('selected');
+                showRun(game, run, now);
+            };
+            bar.appendChild(segment);
+
+            run.markers.forEach(m => {
+                const marker = document.createElement('b');
+                marker.className = `marker marker-${m.type}`;
+                marker.style.left = position(m.occurredAt);
+                bar.appendChild(marker);
+            });
+        });
+        row.appendChild(bar);
+        timelineContainer.appendChild(row);
+    });
+}
+
+async function refresh() {
+    try {
+        const response = await fetch(`/api/v1/supervisors/${encodeURIComponent(characterName)}/timeline`);
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+        render(await response.json());
+        timelineStatus.textContent = `Updated at ${new Date().toLocaleTimeString()}`;
+    } catch (err) {
+        timelineStatus.textContent = `Error loading the timeline: ${err.message}`;
+    }
+}
+
+refresh();
+setInterval(refresh, refreshInterval);
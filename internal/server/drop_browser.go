@@ -0,0 +1,329 @@
+package server
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hectorgimenez/koolo/internal/bot"
+)
+
+const (
+	defaultDropsPageSize = 50
+	maxDropsPageSize     = 500
+)
+
+type dropView struct {
+	Supervisor string    `json:"supervisor"`
+	StashedAt  time.Time `json:"stashedAt"`
+	Name       string    `json:"name"`
+	BaseName   string    `json:"baseName"`
+	Quality    string    `json:"quality"`
+	Type       string    `json:"type"`
+	Ethereal   bool      `json:"ethereal"`
+	Run        string    `json:"run"`
+	Area       string    `json:"area"`
+	Location   string    `json:"location"`
+	Rule       string    `json:"rule"`
+	RuleFile   string    `json:"ruleFile"`
+	Gambled    bool      `json:"gambled"`
+}
+
+type dropFilterOptions struct {
+	Supervisors []string `json:"supervisors"`
+	Qualities   []string `json:"qualities"`
+	Types       []string `json:"types"`
+	Runs        []string `json:"runs"`
+	Areas       []string `json:"areas"`
+}
+
+type dropPage struct {
+	Total    int               `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"pageSize"`
+	Drops    []dropView        `json:"drops"`
+	Options  dropFilterOptions `json:"options"`
+}
+
+// dropFilter is the filter and sorting of the drop browser, list values match any of them and empty ones match all
+type dropFilter struct {
+	supervisors []string
+	qualities   []string
+	types       []string
+	runs        []string
+	areas       []string
+	rule        string
+	from        time.Time
+	to          time.Time
+	sort        string
+	desc        bool
+}
+
+func (s *HttpServer) dropBrowserPage(w http.ResponseWriter, r *http.Request) {
+	s.templates.ExecuteTemplate(w, "drop_browser.gohtml", nil)
+}
+
+// dropHistory returns a page of the drop history of every supervisor matching the filters
+func (s *HttpServer) dropHistory(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseDropFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, pageSize := 1, defaultDropsPageSize
+	if v := r.URL.Query().Get("page"); v != "" {
+		if page, err = strconv.Atoi(v); err != nil || page < 1 {
+			http.Error(w, "invalid page", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := r.URL.Query().Get("pageSize"); v != "" {
+		if pageSize, err = strconv.Atoi(v); err != nil || pageSize < 1 || pageSize > maxDropsPageSize {
+			http.Error(w, fmt.Sprintf("invalid page size, it must be between 1 and %d", maxDropsPageSize), http.StatusBadRequest)
+			return
+		}
+	}
+
+	all := dropViews(s.manager.DropHistory())
+	drops := filter.apply(all)
+
+	start := min((page-1)*pageSize, len(drops))
+	end := min(start+pageSize, len(drops))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(dropPage{
+		Total:    len(drops),
+		Page:     page,
+		PageSize: pageSize,
+		Drops:    drops[start:end],
+		Options:  filterOptions(all),
+	})
+}
+
+// exportDrops returns every drop matching the filters as a CSV or JSON file
+func (s *HttpServer) exportDrops(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseDropFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	drops := filter.apply(dropViews(s.manager.DropHistory()))
+	filename := "drops-" + time.Now().Format("2006-01-02-150405")
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+		writeDropsCSV(w, drops)
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(drops)
+	default:
+		http.Error(w, "unknown format "+format+", expected csv or json", http.StatusBadRequest)
+	}
+}
+
+func writeDropsCSV(w http.ResponseWriter, drops []dropView) {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"Stashed at", "Supervisor", "Name", "Base", "Quality", "Type", "Ethereal", "Run", "Area", "Location", "Rule", "Rule file", "Gambled"})
+	for _, d := range drops {
+		cw.Write([]string{
+			d.StashedAt.Format(time.RFC3339),
+			d.Supervisor,
+			d.Name,
+			d.BaseName,
+			d.Quality,
+			d.Type,
+			strconv.FormatBool(d.Ethereal),
+			d.Run,
+			d.Area,
+			d.Location,
+			d.Rule,
+			d.RuleFile,
+			strconv.FormatBool(d.Gambled),
+		})
+	}
+	cw.Flush()
+}
+
+func dropViews(records []bot.DropRecord) []dropView {
+	views := make([]dropView, 0, len(records))
+	for _, r := range records {
+		itm := r.Drop.Item
+		v := dropView{
+			Supervisor: r.Supervisor,
+			StashedAt:  r.StashedAt,
+			Name:       itm.IdentifiedName,
+			BaseName:   string(itm.Name),
+			Quality:    itm.Quality.ToString(),
+			Type:       itm.Type().Name,
+			Ethereal:   itm.Ethereal,
+			Run:        r.RunName,
+			Location:   r.Drop.DropLocation,
+			Rule:       r.Drop.Rule,
+			RuleFile:   r.Drop.RuleFile,
+			Gambled:    r.Gambled,
+		}
+		if v.Name == "" {
+			v.Name = v.BaseName
+		}
+		if r.Area != 0 {
+			v.Area = r.Area.Area().Name
+		}
+		views = append(views, v)
+	}
+
+	return views
+}
+
+func parseDropFilter(q url.Values) (dropFilter, error) {
+	f := dropFilter{
+		supervisors: splitFilter(q.Get("supervisor")),
+		qualities:   splitFilter(q.Get("quality")),
+		types:       splitFilter(q.Get("type")),
+		runs:        splitFilter(q.Get("run")),
+		areas:       splitFilter(q.Get("area")),
+		rule:        strings.ToLower(strings.TrimSpace(q.Get("rule"))),
+		sort:        q.Get("sort"),
+		desc:        q.Get("order") != "asc",
+	}
+
+	var err error
+	if v := q.Get("from"); v != "" {
+		if f.from, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			return f, fmt.Errorf("invalid from date %s, expected YYYY-MM-DD", v)
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if f.to, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			return f, fmt.Errorf("invalid to date %s, expected YYYY-MM-DD", v)
+		}
+		// The whole day is included
+		f.to = f.to.AddDate(0, 0, 1)
+	}
+
+	switch f.sort {
+	case "":
+		f.sort = "stashedAt"
+	case "stashedAt", "supervisor", "name", "quality", "type", "run", "area":
+	default:
+		return f, fmt.Errorf("unknown sort %s", f.sort)
+	}
+
+	return f, nil
+}
+
+func splitFilter(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// apply returns the matching drops sorted, the newest first when sorting by another field and they are equal
+func (f dropFilter) apply(drops []dropView) []dropView {
+	matched := make([]dropView, 0, len(drops))
+	for _, d := range drops {
+		if f.matches(d) {
+			matched = append(matched, d)
+		}
+	}
+
+	slices.SortStableFunc(matched, func(a, b dropView) int {
+		var c int
+		switch f.sort {
+		case "supervisor":
+			c = strings.Compare(a.Supervisor, b.Supervisor)
+		case "name":
+			c = strings.Compare(a.Name, b.Name)
+		case "quality":
+			c = strings.Compare(a.Quality, b.Quality)
+		case "type":
+			c = strings.Compare(a.Type, b.Type)
+		case "run":
+			c = strings.Compare(a.Run, b.Run)
+		case "area":
+			c = strings.Compare(a.Area, b.Area)
+		}
+		if f.desc {
+			c = -c
+		}
+
+		return cmp.Or(c, b.StashedAt.Compare(a.StashedAt))
+	})
+	if f.sort == "stashedAt" && !f.desc {
+		slices.Reverse(matched)
+	}
+
+	return matched
+}
+
+func (f dropFilter) matches(d dropView) bool {
+	if !matchesAny(f.supervisors, d.Supervisor) || !matchesAny(f.qualities, d.Quality) || !matchesAny(f.types, d.Type) ||
+		!matchesAny(f.runs, d.Run) || !matchesAny(f.areas, d.Area) {
+		return false
+	}
+	if f.rule != "" && !strings.Contains(strings.ToLower(d.Rule), f.rule) && !strings.Contains(strings.ToLower(d.RuleFile), f.rule) {
+		return false
+	}
+	if !f.from.IsZero() && d.StashedAt.Before(f.from) {
+		return false
+	}
+	if !f.to.IsZero() && !d.StashedAt.Before(f.to) {
+		return false
+	}
+
+	return true
+}
+
+func matchesAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(values, func(v string) bool {
+		return strings.EqualFold(v, value)
+	})
+}
+
+// filterOptions returns the values found in the history for every filter, to be shown in the browser
+func filterOptions(drops []dropView) dropFilterOptions {
+	o := dropFilterOptions{Supervisors: []string{}, Qualities: []string{}, Types: []string{}, Runs: []string{}, Areas: []string{}}
+	for _, d := range drops {
+		o.Supervisors = appendOption(o.Supervisors, d.Supervisor)
+		o.Qualities = appendOption(o.Qualities, d.Quality)
+		o.Types = appendOption(o.Types, d.Type)
+		o.Runs = appendOption(o.Runs, d.Run)
+		o.Areas = appendOption(o.Areas, d.Area)
+	}
+	for _, values := range [][]string{o.Supervisors, o.Qualities, o.Types, o.Runs, o.Areas} {
+		slices.Sort(values)
+	}
+
+	return o
+}
+
+func appendOption(values []string, value string) []string {
+	if value == "" || slices.Contains(values, value) {
+		return values
+	}
+
+	return append(values, value)
+}
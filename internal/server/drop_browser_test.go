@@ -0,0 +1,73 @@
+package server
+
+import (
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDropFilter(t *testing.T) {
+	stashedAt := func(day, hour, minute, second int) time.Time {
+		return time.Date(2024, 5, day, hour, minute, second, 0, time.Local)
+	}
+	drops := []dropView{
+		{Supervisor: "sorc", Name: "Shako", Quality: "Unique", Type: "Helm", Run: "mephisto", Rule: "[name] == shako", RuleFile: "unique.nip", StashedAt: stashedAt(1, 10, 0, 0)},
+		{Supervisor: "pala", Name: "Ring", Quality: "Rare", Type: "Ring", Run: "andariel", Rule: "[type] == ring", RuleFile: "rare.nip", StashedAt: stashedAt(2, 23, 59, 59)},
+		{Supervisor: "sorc", Name: "Amulet", Quality: "Rare", Type: "Amulet", Run: "mephisto", Rule: "[type] == amulet", RuleFile: "rare.nip", StashedAt: stashedAt(3, 0, 0, 0)},
+		{Supervisor: "sorc", Name: "Nagelring", Quality: "Set", Type: "Ring", Run: "andariel", Rule: "[quality] == set", RuleFile: "set.nip", StashedAt: stashedAt(1, 12, 0, 0)},
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{name: "newest first by default", query: "", expected: []string{"Amulet", "Ring", "Nagelring", "Shako"}},
+		{name: "oldest first", query: "order=asc", expected: []string{"Shako", "Nagelring", "Ring", "Amulet"}},
+		{name: "supervisor ignoring the case", query: "supervisor=SORC", expected: []string{"Amulet", "Nagelring", "Shako"}},
+		{name: "any of the qualities", query: "quality=rare,set", expected: []string{"Amulet", "Ring", "Nagelring"}},
+		{name: "type and run", query: "type=ring&run=andariel", expected: []string{"Ring", "Nagelring"}},
+		{name: "rule file", query: "rule=UNIQUE.nip", expected: []string{"Shako"}},
+		{name: "rule text", query: "rule=type", expected: []string{"Amulet", "Ring"}},
+		// The to day is included until its last second, the next midnight is not
+		{name: "single day", query: "from=2024-05-02&to=2024-05-02", expected: []string{"Ring"}},
+		{name: "to day inclusive", query: "to=2024-05-02", expected: []string{"Ring", "Nagelring", "Shako"}},
+		{name: "from day", query: "from=2024-05-03", expected: []string{"Amulet"}},
+		// Equal values are sorted by the newest first whatever the order
+		{name: "sort ascending with ties", query: "sort=quality&order=asc", expected: []string{"Amulet", "Ring", "Nagelring", "Shako"}},
+		{name: "sort descending with ties", query: "sort=quality", expected: []string{"Shako", "Nagelring", "Amulet", "Ring"}},
+		{name: "sort by supervisor", query: "sort=supervisor&order=asc", expected: []string{"Ring", "Amulet", "Nagelring", "Shako"}},
+		{name: "nothing matches", query: "supervisor=necro", expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("parsing query: %v", err)
+			}
+			f, err := parseDropFilter(q)
+			if err != nil {
+				t.Fatalf("parseDropFilter: %v", err)
+			}
+
+			names := make([]string, 0)
+			for _, d := range f.apply(drops) {
+				names = append(names, d.Name)
+			}
+			if !slices.Equal(names, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+func TestParseDropFilterErrors(t *testing.T) {
+	for _, query := range []string{"sort=price", "from=01-05-2024", "to=yesterday"} {
+		q, _ := url.ParseQuery(query)
+		if _, err := parseDropFilter(q); err == nil {
+			t.Errorf("%s: expected an error", query)
+		}
+	}
+}
@@ -386,6 +386,8 @@ func (s *HttpServer) initialData(w http.ResponseWriter, r *http.Request) {
 func (s *HttpServer) getStatusData() IndexData {
 	status := make(map[string]bot.Stats)
 	drops := make(map[string]int)
+	goals := make(map[string][]bot.GoalProgress)
+	experience := make(map[string]bot.ExperienceProgress)
 
 	for _, supervisorName := range s.manager.AvailableSupervisors() {
 		status[supervisorName] = s.manager.Status(supervisorName)
@@ -394,12 +396,16 @@ func (s *HttpServer) getStatusData() IndexData {
 		} else {
 			drops[supervisorName] = 0
 		}
+		goals[supervisorName] = s.manager.GoalsProgress(supervisorName)
+		experience[supervisorName] = s.manager.GetSupervisorStats(supervisorName).ExperienceProgress()
 	}
 
 	return IndexData{
-		Version:   config.Version,
-		Status:    status,
-		DropCount: drops,
+		Version:    config.Version,
+		Status:     status,
+		DropCount:  drops,
+		Goals:      goals,
+		Experience: experience,
 	}
 }
 
@@ -422,6 +428,19 @@ func (s *HttpServer) Listen(port int) error {
 	http.HandleFunc("/ws", s.wsServer.HandleWebSocket)    // Web socket
 	http.HandleFunc("/initial-data", s.initialData)       // Web socket data
 	http.HandleFunc("/api/reload-config", s.reloadConfig) // New handler
+	http.HandleFunc("/map", s.minimapPage)
+	http.HandleFunc("GET /api/v1/supervisors/{name}/map", s.minimap)
+	http.HandleFunc("GET /api/v1/supervisors/{name}/map/ws", s.minimapStream)
+	http.HandleFunc("GET /api/v1/supervisors/{name}/screenshot", s.supervisorScreenshot)
+	http.HandleFunc("GET /api/v1/supervisors/{name}/status", s.supervisorStatus)
+	http.HandleFunc("/timeline", s.timelinePage)
+	http.HandleFunc("GET /api/v1/supervisors/{name}/timeline", s.timeline)
+	http.HandleFunc("GET /api/v1/supervisors/{name}/timeline/{game}/screenshot", s.timelineScreenshot)
+	http.HandleFunc("/drops/browser", s.dropBrowserPage)
+	http.HandleFunc("GET /api/v1/drops", s.dropHistory)
+	http.HandleFunc("GET /api/v1/drops/export", s.exportDrops)
+	http.HandleFunc("/analytics", s.analyticsPage)
+	http.HandleFunc("GET /api/v1/analytics/runs", s.runAnalytics)
 
 	assets, _ := fs.Sub(assetsFS, "assets")
 	http.Handle("/assets/", http.StripPrefix("/assets/", http.FileServer(http.FS(assets))))
@@ -477,15 +496,16 @@ func (s *HttpServer) debugData(w http.ResponseWriter, r *http.Request) {
 	}
 
 	type DebugData struct {
-		DebugData map[ctx.Priority]*ctx.Debug
+		DebugData map[ctx.Priority]ctx.Debug
 		GameData  *game.Data
 	}
 
 	context := s.manager.GetContext(characterName)
+	gameData, debug := context.Snapshot()
 
 	debugData := DebugData{
-		DebugData: context.ContextDebug,
-		GameData:  context.Data,
+		DebugData: debug,
+		GameData:  &gameData,
 	}
 
 	jsonData, err := json.Marshal(debugData)
@@ -501,6 +521,10 @@ func (s *HttpServer) debugHandler(w http.ResponseWriter, r *http.Request) {
 	s.templates.ExecuteTemplate(w, "debug.gohtml", nil)
 }
 
+func (s *HttpServer) minimapPage(w http.ResponseWriter, r *http.Request) {
+	s.templates.ExecuteTemplate(w, "map.gohtml", nil)
+}
+
 func (s *HttpServer) startSupervisor(w http.ResponseWriter, r *http.Request) {
 	supervisorList := s.manager.AvailableSupervisors()
 	Supervisor := r.URL.Query().Get("characterName")
@@ -596,6 +620,7 @@ func (s *HttpServer) drops(w http.ResponseWriter, r *http.Request) {
 	s.templates.ExecuteTemplate(w, "drops.gohtml", DropData{
 		NumberOfDrops: len(Drops),
 		Character:     cfg.CharacterName,
+		Supervisor:    sup,
 		Drops:         Drops,
 	})
 }
@@ -675,6 +700,18 @@ func (s *HttpServer) config(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		newConfig.Telegram.ChatID = telegramChatId
+		newConfig.Telegram.BotAdmins = nil
+		for _, id := range strings.Split(r.Form.Get("telegram_admins"), ",") {
+			if id = strings.TrimSpace(id); id == "" {
+				continue
+			}
+			adminID, err := strconv.ParseInt(id, 10, 64)
+			if err != nil {
+				s.templates.ExecuteTemplate(w, "config.gohtml", ConfigData{KooloCfg: &newConfig, ErrorMessage: "Invalid Telegram User ID: " + id})
+				return
+			}
+			newConfig.Telegram.BotAdmins = append(newConfig.Telegram.BotAdmins, adminID)
+		}
 
 		err = config.ValidateAndSaveConfig(newConfig)
 		if err != nil {
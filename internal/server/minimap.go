@@ -0,0 +1,108 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	minimapDefaultRadius = 80
+	minimapDefaultScale  = 4
+	minimapMaxRadius     = 300
+	minimapMaxScale      = 10
+	// minimapFrameInterval is the time between frames sent over the websocket, it's enough to follow what the bot is
+	// doing without overloading the phone or the bot
+	minimapFrameInterval = 500 * time.Millisecond
+)
+
+// minimap renders the current area around the character as a PNG image
+func (s *HttpServer) minimap(w http.ResponseWriter, r *http.Request) {
+	img, err := s.renderMinimap(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(img)
+}
+
+// minimapStream sends a new minimap frame as a binary websocket message every minimapFrameInterval, until the client
+// disconnects
+func (s *HttpServer) minimapStream(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.Error("Failed to upgrade connection to WebSocket", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// Messages from the client are not expected, reading is needed to detect when the connection is closed
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(minimapFrameInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+			img, err := s.renderMinimap(r)
+			if err != nil {
+				// Not in game yet, or loading screen, just try again with the next frame
+				continue
+			}
+			if err = conn.WriteMessage(websocket.BinaryMessage, img); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *HttpServer) renderMinimap(r *http.Request) ([]byte, error) {
+	ctx := s.manager.GetContext(r.PathValue("name"))
+	if ctx == nil || ctx.PathFinder == nil {
+		return nil, errors.New("supervisor is not running")
+	}
+
+	radius := queryInt(r, "radius", minimapDefaultRadius, minimapMaxRadius)
+	scale := queryInt(r, "scale", minimapDefaultScale, minimapMaxScale)
+	d, _ := ctx.Snapshot()
+	img, err := ctx.PathFinder.RenderMinimap(d, radius, scale)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	if err = png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// queryInt returns the given query parameter, or the default value if it's missing or not in the [1, maxValue] range
+func queryInt(r *http.Request, name string, defaultValue, maxValue int) int {
+	value, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil || value < 1 || value > maxValue {
+		return defaultValue
+	}
+
+	return value
+}
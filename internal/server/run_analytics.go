@@ -0,0 +1,203 @@
+package server
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hectorgimenez/koolo/internal/bot"
+	"github.com/hectorgimenez/koolo/internal/event"
+)
+
+type runAnalytics struct {
+	Supervisor        string             `json:"supervisor"`
+	Run               string             `json:"run"`
+	Runs              int                `json:"runs"`
+	SuccessRate       float64            `json:"successRate"`
+	ChickenRate       float64            `json:"chickenRate"`
+	DeathRate         float64            `json:"deathRate"`
+	ErrorRate         float64            `json:"errorRate"`
+	MeanDuration      float64            `json:"meanDurationSeconds"`
+	MedianDuration    float64            `json:"medianDurationSeconds"`
+	P95Duration       float64            `json:"p95DurationSeconds"`
+	Drops             int                `json:"drops"`
+	DropsPerHour      float64            `json:"dropsPerHour"`
+	QualityPerHour    map[string]float64 `json:"dropsPerHourByQuality"`
+	ExperiencePerHour float64            `json:"experiencePerHour"`
+	GoldPerHour       float64            `json:"goldPerHour"`
+	Potions           int                `json:"potions"`
+	PotionsPerRun     float64            `json:"potionsPerRun"`
+	PotionsByType     map[string]int     `json:"potionsByType"`
+}
+
+type runAnalyticsResponse struct {
+	Since time.Time      `json:"since"`
+	Now   time.Time      `json:"now"`
+	Runs  []runAnalytics `json:"runs"`
+}
+
+func (s *HttpServer) analyticsPage(w http.ResponseWriter, r *http.Request) {
+	s.templates.ExecuteTemplate(w, "analytics.gohtml", nil)
+}
+
+// runAnalytics returns the performance of every run by supervisor, for the runs started in the time window (all of
+// them when it's empty). The runs are kept in memory by the supervisor stats, so only the runs since the supervisor
+// was started are available, a window starting before it is rejected instead of returning partial analytics.
+func (s *HttpServer) runAnalytics(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	resp := runAnalyticsResponse{Now: now}
+	if v := r.URL.Query().Get("window"); v != "" {
+		window, err := time.ParseDuration(v)
+		if err != nil || window <= 0 {
+			http.Error(w, "invalid window "+v+", expected a duration like 24h", http.StatusBadRequest)
+			return
+		}
+		resp.Since = now.Add(-window)
+	}
+
+	supervisors := splitFilter(r.URL.Query().Get("supervisor"))
+	if len(supervisors) == 0 {
+		supervisors = s.manager.AvailableSupervisors()
+	}
+
+	resp.Runs = make([]runAnalytics, 0)
+	for _, name := range supervisors {
+		stats := s.manager.GetSupervisorStats(name)
+		if err := checkAnalyticsWindow(name, stats, resp.Since); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp.Runs = append(resp.Runs, buildRunAnalytics(name, stats, resp.Since)...)
+	}
+	slices.SortFunc(resp.Runs, func(a, b runAnalytics) int {
+		return cmp.Or(strings.Compare(a.Run, b.Run), strings.Compare(a.Supervisor, b.Supervisor))
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// checkAnalyticsWindow returns an error when the window starts before the supervisor stats, the runs of the previous
+// executions aren't kept
+func checkAnalyticsWindow(supervisor string, stats bot.Stats, since time.Time) error {
+	if !since.IsZero() && since.Before(stats.StartedAt) {
+		return fmt.Errorf("the window starts before %s was started at %s, only the runs since then are available", supervisor, stats.StartedAt.Format(time.DateTime))
+	}
+
+	return nil
+}
+
+// buildRunAnalytics aggregates the finished runs started after since by run name, the rates per hour are calculated
+// over the time spent in the run
+func buildRunAnalytics(supervisor string, stats bot.Stats, since time.Time) []runAnalytics {
+	type accumulator struct {
+		runAnalytics
+		durations  []float64
+		seconds    float64
+		experience int
+		gold       int
+		byQuality  map[string]int
+		successful int
+		chickens   int
+		deaths     int
+		errors     int
+	}
+
+	byRun := make(map[string]*accumulator)
+	for _, g := range stats.Games {
+		for _, r := range g.Runs {
+			if r.FinishedAt.IsZero() || r.StartedAt.Before(since) {
+				continue
+			}
+
+			acc, found := byRun[r.Name]
+			if !found {
+				acc = &accumulator{
+					runAnalytics: runAnalytics{Supervisor: supervisor, Run: r.Name, PotionsByType: make(map[string]int)},
+					byQuality:    make(map[string]int),
+				}
+				byRun[r.Name] = acc
+			}
+
+			duration := r.FinishedAt.Sub(r.StartedAt).Seconds()
+			acc.Runs++
+			acc.durations = append(acc.durations, duration)
+			acc.seconds += duration
+			acc.experience += r.ExperienceGained
+			acc.gold += r.GoldGained
+
+			switch r.Reason {
+			case event.FinishedOK:
+				acc.successful++
+			case event.FinishedChicken, event.FinishedMercChicken:
+				acc.chickens++
+			case event.FinishedDied:
+				acc.deaths++
+			case event.FinishedError:
+				acc.errors++
+			}
+
+			for _, itm := range r.Items {
+				acc.byQuality[itm.Quality.ToString()]++
+				acc.Drops++
+			}
+			for _, p := range r.UsedPotions {
+				acc.PotionsByType[string(p.PotionType)]++
+				acc.Potions++
+			}
+		}
+	}
+
+	analytics := make([]runAnalytics, 0, len(byRun))
+	for _, acc := range byRun {
+		a := acc.runAnalytics
+		runs := float64(a.Runs)
+		a.SuccessRate = float64(acc.successful) / runs * 100
+		a.ChickenRate = float64(acc.chickens) / runs * 100
+		a.DeathRate = float64(acc.deaths) / runs * 100
+		a.ErrorRate = float64(acc.errors) / runs * 100
+		a.PotionsPerRun = float64(a.Potions) / runs
+
+		slices.Sort(acc.durations)
+		a.MeanDuration = acc.seconds / runs
+		a.MedianDuration = median(acc.durations)
+		a.P95Duration = percentile(acc.durations, 95)
+
+		a.QualityPerHour = make(map[string]float64, len(acc.byQuality))
+		if hours := acc.seconds / 3600; hours > 0 {
+			a.DropsPerHour = float64(a.Drops) / hours
+			a.ExperiencePerHour = float64(acc.experience) / hours
+			a.GoldPerHour = float64(acc.gold) / hours
+			for q, count := range acc.byQuality {
+				a.QualityPerHour[q] = float64(count) / hours
+			}
+		}
+
+		analytics = append(analytics, a)
+	}
+
+	return analytics
+}
+
+// median returns the median of the sorted values
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// percentile returns the nearest-rank percentile of the sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+
+	return sorted[max(rank-1, 0)]
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/d2go/pkg/data/item"
+	"github.com/hectorgimenez/koolo/internal/bot"
+	"github.com/hectorgimenez/koolo/internal/event"
+)
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		values   []float64
+		expected float64
+	}{
+		{values: []float64{5}, expected: 5},
+		{values: []float64{1, 3, 8}, expected: 3},
+		{values: []float64{1, 3, 8, 10}, expected: 5.5},
+	}
+
+	for _, tt := range tests {
+		if got := median(tt.values); got != tt.expected {
+			t.Errorf("median of %v: expected %v, got %v", tt.values, tt.expected, got)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
+
+	tests := []struct {
+		values     []float64
+		percentile float64
+		expected   float64
+	}{
+		{values: values, percentile: 95, expected: 19},
+		{values: values, percentile: 50, expected: 10},
+		{values: values, percentile: 100, expected: 20},
+		{values: values, percentile: 1, expected: 1},
+		{values: values, percentile: 0, expected: 1},
+		{values: []float64{42}, percentile: 95, expected: 42},
+	}
+
+	for _, tt := range tests {
+		if got := percentile(tt.values, tt.percentile); got != tt.expected {
+			t.Errorf("p%v of %d values: expected %v, got %v", tt.percentile, len(tt.values), tt.expected, got)
+		}
+	}
+}
+
+func TestBuildRunAnalytics(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	run := func(name string, startAfter, duration time.Duration, reason event.FinishReason) bot.RunStats {
+		return bot.RunStats{
+			Name:       name,
+			StartedAt:  start.Add(startAfter),
+			FinishedAt: start.Add(startAfter + duration),
+			Reason:     reason,
+		}
+	}
+
+	// Too old for the window
+	old := run("mephisto", -time.Hour, time.Minute*10, event.FinishedOK)
+
+	meph1 := run("mephisto", 0, time.Minute*10, event.FinishedOK)
+	meph1.Items = []data.Item{{Name: "Shako", Quality: item.QualityUnique}, {Name: "Ring", Quality: item.QualityRare}}
+	meph1.ExperienceGained = 1000
+	meph1.GoldGained = 5000
+	meph1.UsedPotions = []event.UsedPotionEvent{
+		event.UsedPotion(event.Text("sorc", ""), data.HealingPotion, false),
+		event.UsedPotion(event.Text("sorc", ""), data.ManaPotion, false),
+	}
+	meph2 := run("mephisto", time.Minute*15, time.Minute*20, event.FinishedChicken)
+	meph2.ExperienceGained = 2000
+	meph2.GoldGained = 10000
+	meph3 := run("mephisto", time.Minute*40, time.Minute*30, event.FinishedDied)
+	meph4 := run("mephisto", time.Minute*75, time.Minute*20, event.FinishedOK)
+	andariel := run("andariel", time.Minute*100, time.Minute*5, event.FinishedError)
+	// Still running
+	inProgress := bot.RunStats{Name: "mephisto", StartedAt: start.Add(time.Minute * 110)}
+
+	stats := bot.Stats{Games: []bot.GameStats{
+		{Runs: []bot.RunStats{old}},
+		{Runs: []bot.RunStats{meph1, meph2}},
+		{Runs: []bot.RunStats{meph3, meph4, andariel, inProgress}},
+	}}
+
+	byRun := make(map[string]runAnalytics)
+	for _, a := range buildRunAnalytics("sorc", stats, start) {
+		byRun[a.Run] = a
+	}
+	if len(byRun) != 2 {
+		t.Fatalf("expected analytics for 2 runs, got %d", len(byRun))
+	}
+
+	meph := byRun["mephisto"]
+	// 4 runs in 80 minutes: 10, 20, 30 and 20
+	checks := []struct {
+		name          string
+		got, expected float64
+	}{
+		{"runs", float64(meph.Runs), 4},
+		{"success rate", meph.SuccessRate, 50},
+		{"chicken rate", meph.ChickenRate, 25},
+		{"death rate", meph.DeathRate, 25},
+		{"error rate", meph.ErrorRate, 0},
+		{"mean duration", meph.MeanDuration, 1200},
+		{"median duration", meph.MedianDuration, 1200},
+		{"p95 duration", meph.P95Duration, 1800},
+		{"drops", float64(meph.Drops), 2},
+		{"drops per hour", meph.DropsPerHour, 1.5},
+		{"unique drops per hour", meph.QualityPerHour[item.QualityUnique.ToString()], 0.75},
+		{"experience per hour", meph.ExperiencePerHour, 2250},
+		{"gold per hour", meph.GoldPerHour, 11250},
+		{"potions", float64(meph.Potions), 2},
+		{"potions per run", meph.PotionsPerRun, 0.5},
+		{"healing potions", float64(meph.PotionsByType[string(data.HealingPotion)]), 1},
+	}
+	for _, c := range checks {
+		if math.Abs(c.got-c.expected) > 1e-9 {
+			t.Errorf("mephisto %s: expected %v, got %v", c.name, c.expected, c.got)
+		}
+	}
+
+	if andy := byRun["andariel"]; andy.Runs != 1 || andy.ErrorRate != 100 || andy.SuccessRate != 0 || andy.Supervisor != "sorc" {
+		t.Errorf("unexpected andariel analytics %+v", andy)
+	}
+
+	// Without a window every finished run is included
+	for _, a := range buildRunAnalytics("sorc", stats, time.Time{}) {
+		if a.Run == "mephisto" && a.Runs != 5 {
+			t.Errorf("expected 5 mephisto runs without a window, got %d", a.Runs)
+		}
+	}
+}
+
+func TestCheckAnalyticsWindow(t *testing.T) {
+	startedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		stats bot.Stats
+		since time.Time
+		valid bool
+	}{
+		{name: "no window", stats: bot.Stats{StartedAt: startedAt}, valid: true},
+		{name: "after the start", stats: bot.Stats{StartedAt: startedAt}, since: startedAt.Add(time.Minute), valid: true},
+		{name: "at the start", stats: bot.Stats{StartedAt: startedAt}, since: startedAt, valid: true},
+		{name: "before the start", stats: bot.Stats{StartedAt: startedAt}, since: startedAt.Add(-time.Minute)},
+		{name: "not started", since: startedAt, valid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkAnalyticsWindow("sorc", tt.stats, tt.since); (err == nil) != tt.valid {
+				t.Errorf("expected valid %t, got error %v", tt.valid, err)
+			}
+		})
+	}
+}
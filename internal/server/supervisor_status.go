@@ -0,0 +1,59 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"image/jpeg"
+	"net/http"
+	"slices"
+
+	"github.com/hectorgimenez/koolo/internal/bot"
+)
+
+// supervisorScreenshot returns the current game window of the supervisor as a JPEG image
+func (s *HttpServer) supervisorScreenshot(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !slices.Contains(s.manager.AvailableSupervisors(), name) {
+		http.Error(w, "supervisor not found", http.StatusNotFound)
+		return
+	}
+
+	img, err := s.manager.Screenshot(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Cache-Control", "no-store")
+	jpeg.Encode(w, img, &jpeg.Options{Quality: 80})
+}
+
+// supervisorStatus returns the status card of the supervisor as JSON, with the game time in seconds for convenience
+func (s *HttpServer) supervisorStatus(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !slices.Contains(s.manager.AvailableSupervisors(), name) {
+		http.Error(w, "supervisor not found", http.StatusNotFound)
+		return
+	}
+
+	card, err := s.manager.StatusCard(name)
+	if errors.Is(err, bot.ErrSupervisorNotRunning) {
+		card = bot.StatusCard{Supervisor: name, Status: bot.NotStarted}
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(struct {
+		bot.StatusCard
+		GameTimeSeconds int `json:"gameTimeSeconds"`
+		RunTimeSeconds  int `json:"runTimeSeconds"`
+	}{
+		StatusCard:      card,
+		GameTimeSeconds: int(card.GameTime().Seconds()),
+		RunTimeSeconds:  int(card.RunTime().Seconds()),
+	})
+}
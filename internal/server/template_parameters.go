@@ -11,11 +11,14 @@ type IndexData struct {
 	Version      string
 	Status       map[string]bot.Stats
 	DropCount    map[string]int
+	Goals        map[string][]bot.GoalProgress
+	Experience   map[string]bot.ExperienceProgress
 }
 
 type DropData struct {
 	NumberOfDrops int
 	Character     string
+	Supervisor    string
 	Drops         []data.Drop
 }
 
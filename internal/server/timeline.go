@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/hectorgimenez/koolo/internal/bot"
+	"github.com/hectorgimenez/koolo/internal/event"
+)
+
+type timelineMarker struct {
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurredAt"`
+	Text       string    `json:"text"`
+}
+
+type timelineRun struct {
+	Name       string           `json:"name"`
+	Reason     string           `json:"reason"`
+	StartedAt  time.Time        `json:"startedAt"`
+	FinishedAt time.Time        `json:"finishedAt"`
+	Markers    []timelineMarker `json:"markers"`
+}
+
+type timelineGame struct {
+	Index         int           `json:"index"`
+	StartedAt     time.Time     `json:"startedAt"`
+	FinishedAt    time.Time     `json:"finishedAt"`
+	Reason        string        `json:"reason"`
+	Message       string        `json:"message,omitempty"`
+	HasScreenshot bool          `json:"hasScreenshot"`
+	Runs          []timelineRun `json:"runs"`
+}
+
+type timeline struct {
+	Supervisor string         `json:"supervisor"`
+	Now        time.Time      `json:"now"`
+	Games      []timelineGame `json:"games"`
+}
+
+func (s *HttpServer) timelinePage(w http.ResponseWriter, r *http.Request) {
+	s.templates.ExecuteTemplate(w, "timeline.gohtml", nil)
+}
+
+// timeline returns the games of the supervisor with their runs and what happened during every run
+func (s *HttpServer) timeline(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !slices.Contains(s.manager.AvailableSupervisors(), name) {
+		http.Error(w, "supervisor not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(buildTimeline(name, s.manager.GetSupervisorStats(name)))
+}
+
+// timelineScreenshot returns the screenshot taken when the given game finished with an error
+func (s *HttpServer) timelineScreenshot(w http.ResponseWriter, r *http.Request) {
+	games := s.manager.GetSupervisorStats(r.PathValue("name")).Games
+	idx, err := strconv.Atoi(r.PathValue("game"))
+	if err != nil || idx < 0 || idx >= len(games) || games[idx].Screenshot == "" {
+		http.Error(w, "screenshot not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	http.ServeFile(w, r, games[idx].Screenshot)
+}
+
+func buildTimeline(name string, stats bot.Stats) timeline {
+	tl := timeline{Supervisor: name, Now: time.Now(), Games: make([]timelineGame, 0, len(stats.Games))}
+	for i, g := range stats.Games {
+		game := timelineGame{
+			Index:         i,
+			StartedAt:     g.StartedAt,
+			FinishedAt:    g.FinishedAt,
+			Reason:        reasonOrRunning(g.Reason, g.FinishedAt),
+			Message:       g.Message,
+			HasScreenshot: g.Screenshot != "",
+			Runs:          make([]timelineRun, 0, len(g.Runs)),
+		}
+
+		for _, r := range g.Runs {
+			run := timelineRun{
+				Name:       r.Name,
+				Reason:     reasonOrRunning(r.Reason, r.FinishedAt),
+				StartedAt:  r.StartedAt,
+				FinishedAt: r.FinishedAt,
+				Markers:    []timelineMarker{},
+			}
+			for _, p := range r.UsedPotions {
+				text := fmt.Sprintf("%s potion", p.PotionType)
+				if p.OnMerc {
+					text += " (merc)"
+				}
+				run.Markers = append(run.Markers, timelineMarker{Type: "potion", OccurredAt: p.OccurredAt(), Text: text})
+			}
+			for _, st := range r.Stashed {
+				itemName := st.Drop.Item.IdentifiedName
+				if itemName == "" {
+					itemName = string(st.Drop.Item.Name)
+				}
+				text := fmt.Sprintf("%s (%s)", itemName, st.Drop.Item.Quality.ToString())
+				run.Markers = append(run.Markers, timelineMarker{Type: "stash", OccurredAt: st.StashedAt, Text: text})
+			}
+			switch r.Reason {
+			case event.FinishedChicken, event.FinishedMercChicken:
+				run.Markers = append(run.Markers, timelineMarker{Type: "chicken", OccurredAt: r.FinishedAt, Text: string(r.Reason)})
+			case event.FinishedDied:
+				run.Markers = append(run.Markers, timelineMarker{Type: "death", OccurredAt: r.FinishedAt, Text: "Died"})
+			case event.FinishedError:
+				run.Markers = append(run.Markers, timelineMarker{Type: "error", OccurredAt: r.FinishedAt, Text: g.Message})
+			}
+			game.Runs = append(game.Runs, run)
+		}
+
+		tl.Games = append(tl.Games, game)
+	}
+
+	return tl
+}
+
+// reasonOrRunning returns the finish reason, or "running" if it didn't finish yet
+func reasonOrRunning(reason event.FinishReason, finishedAt time.Time) string {
+	if finishedAt.IsZero() {
+		return "running"
+	}
+
+	return string(reason)
+}
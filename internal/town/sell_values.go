@@ -0,0 +1,120 @@
+package town
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hectorgimenez/d2go/pkg/data"
+	"github.com/hectorgimenez/koolo/internal/context"
+)
+
+// sellValueMaxAge is how long a learned sell value is trusted, after that the item is sold again to learn it again
+const sellValueMaxAge = 7 * 24 * time.Hour
+
+// savedSellValues caches the sell values files read by SellValue, by supervisor
+var (
+	savedSellValuesMu sync.Mutex
+	savedSellValues   = make(map[string]savedSellValuesFile)
+)
+
+type savedSellValuesFile struct {
+	modTime time.Time
+	values  map[string]context.SellValue
+}
+
+// learnedSellValue returns the gold received the last time the same item and quality was sold, the values are kept on
+// disk so they aren't lost when Koolo is restarted
+func learnedSellValue(itm data.Item) (int, bool) {
+	ctx := context.Get()
+	loadSellValues()
+
+	value, found := ctx.SellValues[sellValueKey(itm)]
+
+	return trustedSellValue(value, found)
+}
+
+// SellValue returns the gold received the last time the supervisor sold the same item and quality. Unlike
+// learnedSellValue it reads the values saved on disk, so it can be used outside of the supervisor goroutine. The old
+// values are not trusted either.
+func SellValue(supervisor string, itm data.Item) (int, bool) {
+	path := sellValuesFile(supervisor)
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+
+	savedSellValuesMu.Lock()
+	defer savedSellValuesMu.Unlock()
+
+	saved, found := savedSellValues[supervisor]
+	if !found || !saved.modTime.Equal(info.ModTime()) {
+		saved = savedSellValuesFile{modTime: info.ModTime(), values: make(map[string]context.SellValue)}
+		content, err := os.ReadFile(path)
+		if err != nil || json.Unmarshal(content, &saved.values) != nil {
+			return 0, false
+		}
+		savedSellValues[supervisor] = saved
+	}
+
+	value, found := saved.values[sellValueKey(itm)]
+
+	return trustedSellValue(value, found)
+}
+
+// trustedSellValue returns the gold of the learned sell value, it's not found when it's older than sellValueMaxAge
+func trustedSellValue(value context.SellValue, found bool) (int, bool) {
+	if !found || time.Since(value.SoldAt) > sellValueMaxAge {
+		return 0, false
+	}
+
+	return value.Gold, true
+}
+
+func recordSellValue(itm data.Item, gold int) {
+	ctx := context.Get()
+	loadSellValues()
+
+	ctx.SellValues[sellValueKey(itm)] = context.SellValue{Gold: gold, SoldAt: time.Now()}
+
+	content, err := json.MarshalIndent(ctx.SellValues, "", "  ")
+	if err == nil {
+		err = os.WriteFile(sellValuesFile(ctx.Name), content, 0644)
+	}
+	if err != nil {
+		ctx.Logger.Warn("Error saving learned sell values", slog.Any("error", err))
+	}
+}
+
+// loadSellValues reads the learned sell values of the supervisor the first time they are needed
+func loadSellValues() {
+	ctx := context.Get()
+	if ctx.SellValues != nil {
+		return
+	}
+
+	ctx.SellValues = make(map[string]context.SellValue)
+	content, err := os.ReadFile(sellValuesFile(ctx.Name))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			ctx.Logger.Warn("Error reading learned sell values", slog.Any("error", err))
+		}
+		return
+	}
+
+	if err = json.Unmarshal(content, &ctx.SellValues); err != nil {
+		ctx.Logger.Warn("Error reading learned sell values", slog.Any("error", err))
+	}
+}
+
+func sellValuesFile(supervisor string) string {
+	return filepath.Join("config", supervisor, "sell_values.json")
+}
+
+func sellValueKey(itm data.Item) string {
+	return fmt.Sprintf("%s:%d", itm.Name, itm.Quality)
+}
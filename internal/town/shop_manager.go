@@ -2,6 +2,8 @@ package town
 
 import (
 	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/hectorgimenez/d2go/pkg/data"
@@ -125,13 +127,49 @@ func ShouldBuyKeys() (int, bool) {
 }
 
 func SellJunk() {
+	ctx := context.Get()
+
 	for _, i := range ItemsToBeSold() {
-		if context.Get().Data.CharacterCfg.Inventory.InventoryLock[i.Position.Y][i.Position.X] == 1 {
-			SellItem(i)
+		if ctx.Data.CharacterCfg.Inventory.InventoryLock[i.Position.Y][i.Position.X] != 1 {
+			continue
+		}
+
+		// Dry run items are logged by LogDryRunJunk on every town visit
+		if ctx.CharacterCfg.Selling.DryRun {
+			continue
+		}
+
+		goldBeforeSelling := ctx.Data.PlayerUnit.TotalPlayerGold()
+		SellItem(i)
+		ctx.RefreshGameData()
+		if goldEarned := ctx.Data.PlayerUnit.TotalPlayerGold() - goldBeforeSelling; goldEarned > 0 {
+			recordSellValue(i, goldEarned)
 		}
 	}
 }
 
+// LogDryRunJunk logs the items that would be sold or dropped when the selling dry run mode is enabled
+func LogDryRunJunk() {
+	ctx := context.Get()
+	if !ctx.CharacterCfg.Selling.DryRun {
+		return
+	}
+
+	sell, drop := junkItems()
+	for _, i := range sell {
+		ctx.Logger.Info(fmt.Sprintf("Dry run: item %s [%s] would be sold", i.Desc().Name, i.Quality.ToString()),
+			slog.Bool("identified", i.Identified),
+			slog.Any("stats", i.Stats),
+		)
+	}
+	for _, i := range drop {
+		ctx.Logger.Info(fmt.Sprintf("Dry run: item %s [%s] would be dropped, it's worth less than the min sell value", i.Desc().Name, i.Quality.ToString()),
+			slog.Bool("identified", i.Identified),
+			slog.Any("stats", i.Stats),
+		)
+	}
+}
+
 func SellItem(i data.Item) {
 	ctx := context.Get()
 	screenPos := ui.GetScreenCoordsForItem(i)
@@ -161,7 +199,23 @@ func buyFullStack(i data.Item) {
 	time.Sleep(500 * time.Millisecond)
 }
 
-func ItemsToBeSold() (items []data.Item) {
+func ItemsToBeSold() []data.Item {
+	sell, _ := junkItems()
+
+	return sell
+}
+
+// ItemsToBeDropped returns the junk items worth less than the min sell value, they would stay in the inventory forever
+// if they were just not sold
+func ItemsToBeDropped() []data.Item {
+	_, drop := junkItems()
+
+	return drop
+}
+
+// junkItems returns the items in unlocked inventory slots that we don't want, split by the ones worth selling and the
+// ones to be dropped
+func junkItems() (sell []data.Item, drop []data.Item) {
 	ctx := context.Get()
 	for _, itm := range ctx.Data.Inventory.ByLocation(item.LocationInventory) {
 		if itm.IsFromQuest() {
@@ -176,14 +230,57 @@ func ItemsToBeSold() (items []data.Item) {
 			continue
 		}
 
-		if ctx.Data.CharacterCfg.Inventory.InventoryLock[itm.Position.Y][itm.Position.X] == 1 {
-			// If item is a full match will be stashed, we don't want to sell it
-			if _, result := ctx.Data.CharacterCfg.Runtime.Rules.EvaluateAll(itm); result == nip.RuleResultFullMatch && !itm.IsPotion() {
-				continue
-			}
-			items = append(items, itm)
+		// Items kept while gambling or shopping will be stashed
+		if _, kept := ctx.CurrentGame.KeptVendorItemRule(itm.UnitID); kept {
+			continue
+		}
+
+		if ctx.Data.CharacterCfg.Inventory.InventoryLock[itm.Position.Y][itm.Position.X] != 1 || !shouldSellItem(itm) {
+			continue
+		}
+
+		if isWorthSelling(itm) {
+			sell = append(sell, itm)
+		} else {
+			drop = append(drop, itm)
 		}
 	}
 
 	return
 }
+
+func shouldSellItem(itm data.Item) bool {
+	ctx := context.Get()
+
+	// Keep rules always win, they are the last line of defense against a bad pickit edit
+	if _, result := ctx.CharacterCfg.Runtime.SellKeepRules.EvaluateAll(itm); result == nip.RuleResultFullMatch {
+		return false
+	}
+
+	if _, result := ctx.CharacterCfg.Runtime.SellRules.EvaluateAll(itm); result == nip.RuleResultFullMatch {
+		return true
+	}
+
+	// If item is a full match will be stashed, we don't want to sell it
+	if _, result := ctx.CharacterCfg.Runtime.Rules.EvaluateAll(itm); result == nip.RuleResultFullMatch && !itm.IsPotion() {
+		return false
+	}
+
+	if !itm.Identified {
+		for _, q := range ctx.CharacterCfg.Selling.KeepUnidentifiedQualities {
+			if strings.EqualFold(q, itm.Quality.ToString()) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// isWorthSelling returns false for the items that were sold before for less than the min sell value, we only know the
+// value of items we already sold so unknown items are always worth it
+func isWorthSelling(itm data.Item) bool {
+	value, found := learnedSellValue(itm)
+
+	return !found || value >= context.Get().CharacterCfg.Selling.MinSellValue
+}
@@ -0,0 +1,31 @@
+package town
+
+import (
+	"github.com/hectorgimenez/d2go/pkg/data/area"
+	"github.com/hectorgimenez/d2go/pkg/data/npc"
+)
+
+type Vendor struct {
+	NPC  npc.ID
+	Town area.ID
+}
+
+// Vendors maps the vendor names that can be used in the configuration to the NPC and the town where it can be found
+var Vendors = map[string]Vendor{
+	"akara":    {NPC: npc.Akara, Town: area.RogueEncampment},
+	"charsi":   {NPC: npc.Charsi, Town: area.RogueEncampment},
+	"gheed":    {NPC: npc.Gheed, Town: area.RogueEncampment},
+	"fara":     {NPC: npc.Fara, Town: area.LutGholein},
+	"drognan":  {NPC: npc.Drognan, Town: area.LutGholein},
+	"elzix":    {NPC: npc.Elzix, Town: area.LutGholein},
+	"lysander": {NPC: npc.Lysander, Town: area.LutGholein},
+	"ormus":    {NPC: npc.Ormus, Town: area.KurastDocks},
+	"hratli":   {NPC: npc.Hratli, Town: area.KurastDocks},
+	"asheara":  {NPC: npc.Asheara, Town: area.KurastDocks},
+	"alkor":    {NPC: npc.Alkor, Town: area.KurastDocks},
+	"jamella":  {NPC: npc.Jamella, Town: area.ThePandemoniumFortress},
+	"halbu":    {NPC: npc.Halbu, Town: area.ThePandemoniumFortress},
+	"larzuk":   {NPC: npc.Larzuk, Town: area.Harrogath},
+	"malah":    {NPC: npc.Malah, Town: area.Harrogath},
+	"anya":     {NPC: npc.Drehya, Town: area.Harrogath},
+}
@@ -54,3 +54,20 @@ func getScreenCoordsForItemClassic(itm data.Item) data.Position {
 
 	return data.Position{X: x, Y: y}
 }
+
+// GetScreenCoordsToPlaceInInventory returns the screen coordinates where an item held on the cursor should be dropped,
+// so it gets placed in the inventory with its top left corner at the given position
+func GetScreenCoordsToPlaceInInventory(itm data.Item, pos data.Position) data.Position {
+	ctx := context.Get()
+	if ctx.GameReader.LegacyGraphics() {
+		return data.Position{
+			X: inventoryTopLeftXClassic + pos.X*itemBoxSizeClassic + itm.Desc().InventoryWidth*itemBoxSizeClassic/2,
+			Y: inventoryTopLeftYClassic + pos.Y*itemBoxSizeClassic + itm.Desc().InventoryHeight*itemBoxSizeClassic/2,
+		}
+	}
+
+	return data.Position{
+		X: inventoryTopLeftX + pos.X*itemBoxSize + itm.Desc().InventoryWidth*itemBoxSize/2,
+		Y: inventoryTopLeftY + pos.Y*itemBoxSize + itm.Desc().InventoryHeight*itemBoxSize/2,
+	}
+}
@@ -5,9 +5,11 @@ import "golang.org/x/sys/windows"
 const (
 	EXECUTION_STATE_ES_DISPLAY_REQUIRED = 0x00000002
 	EXECUTION_STATE_ES_CONTINUOUS       = 0x80000000
+	ATTACH_PARENT_PROCESS               = ^uint32(0) // (DWORD)-1
 )
 
 var (
 	KERNEL32                = windows.NewLazySystemDLL("kernel32.dll")
 	SetThreadExecutionState = KERNEL32.NewProc("SetThreadExecutionState")
+	AttachConsole           = KERNEL32.NewProc("AttachConsole")
 )